@@ -28,6 +28,40 @@ type ConfigSpec struct {
 
 	// Configuration for validation
 	Validation Validation `json:"validation,omitempty"`
+
+	// Configuration for policy sets
+	PolicySets PolicySets `json:"policySets,omitempty"`
+
+	// Configuration for enforcement actions by namespace
+	NamespaceEnforcement NamespaceEnforcement `json:"namespaceEnforcement,omitempty"`
+}
+
+type NamespaceEnforcement struct {
+	// How the enforcement action of a rule combines with the action of a
+	// constraint. `override` (the default) replaces the constraint's action,
+	// `escalate` only replaces it with a stricter action.
+	// +kubebuilder:validation:Enum=override;escalate
+	Mode string `json:"mode,omitempty"`
+	// Rules assigning enforcement actions to namespaces. The first rule that
+	// matches the namespace of a request applies.
+	Rules []NamespaceEnforcementRule `json:"rules,omitempty"`
+}
+
+type NamespaceEnforcementRule struct {
+	// Names of the namespaces the rule applies to
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Labels of the namespaces the rule applies to. A rule without namespaces
+	// or a namespaceSelector applies to every namespace.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Enforcement action of constraints evaluated for objects in these
+	// namespaces
+	EnforcementAction string `json:"enforcementAction"`
+}
+
+type PolicySets struct {
+	// Names of the policy sets to enforce. Constraints labeled with a policy
+	// set that is not listed here are ignored by admission and audit.
+	Active []string `json:"active,omitempty"`
 }
 
 type Validation struct {
@@ -53,11 +87,22 @@ type SyncOnlyEntry struct {
 	Group   string `json:"group,omitempty"`
 	Version string `json:"version,omitempty"`
 	Kind    string `json:"kind,omitempty"`
+	// Only replicate objects matching this field selector, e.g.
+	// `status.phase=Running`. The kind must support the fields used.
+	FieldSelector string `json:"fieldSelector,omitempty"`
 }
 
 // ConfigStatus defines the observed state of Config
 type ConfigStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Sync entries that could not be replicated
+	SyncErrors []SyncError `json:"syncErrors,omitempty"`
+}
+
+type SyncError struct {
+	Kind    GVK    `json:"kind,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 type GVK struct {
@@ -0,0 +1,81 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectViolationsSpec identifies the object the violations are for
+type ObjectViolationsSpec struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// The audited object
+	Object ObjectReference `json:"object,omitempty"`
+}
+
+type ObjectReference struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// ObjectViolationsStatus defines the violations found by the last audit
+type ObjectViolationsStatus struct {
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Timestamp of the audit that last changed the violations
+	AuditTimestamp string `json:"auditTimestamp,omitempty"`
+	// Total number of violations of the object, which may be more than are listed
+	TotalViolations int64 `json:"totalViolations,omitempty"`
+	// Violations of the object, up to the constraint violations limit
+	Violations []ObjectViolation `json:"violations,omitempty"`
+}
+
+type ObjectViolation struct {
+	ConstraintKind    string `json:"constraintKind,omitempty"`
+	ConstraintName    string `json:"constraintName,omitempty"`
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+	Message           string `json:"message,omitempty"`
+}
+
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:object:root=true
+
+// ObjectViolations is the Schema for the objectviolations API. Audit keeps
+// one for every object with violations.
+type ObjectViolations struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObjectViolationsSpec   `json:"spec,omitempty"`
+	Status ObjectViolationsStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ObjectViolationsList contains a list of ObjectViolations
+type ObjectViolationsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ObjectViolations `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ObjectViolations{}, &ObjectViolationsList{})
+}
@@ -20,6 +20,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -29,7 +30,7 @@ func (in *Config) DeepCopyInto(out *Config) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Config.
@@ -87,6 +88,8 @@ func (in *ConfigSpec) DeepCopyInto(out *ConfigSpec) {
 	*out = *in
 	in.Sync.DeepCopyInto(&out.Sync)
 	in.Validation.DeepCopyInto(&out.Validation)
+	in.PolicySets.DeepCopyInto(&out.PolicySets)
+	in.NamespaceEnforcement.DeepCopyInto(&out.NamespaceEnforcement)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigSpec.
@@ -102,6 +105,11 @@ func (in *ConfigSpec) DeepCopy() *ConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigStatus) DeepCopyInto(out *ConfigStatus) {
 	*out = *in
+	if in.SyncErrors != nil {
+		in, out := &in.SyncErrors, &out.SyncErrors
+		*out = make([]SyncError, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigStatus.
@@ -129,6 +137,198 @@ func (in *GVK) DeepCopy() *GVK {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
+func (in *ObjectReference) DeepCopy() *ObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectViolation) DeepCopyInto(out *ObjectViolation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectViolation.
+func (in *ObjectViolation) DeepCopy() *ObjectViolation {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectViolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectViolations) DeepCopyInto(out *ObjectViolations) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectViolations.
+func (in *ObjectViolations) DeepCopy() *ObjectViolations {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectViolations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObjectViolations) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectViolationsList) DeepCopyInto(out *ObjectViolationsList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObjectViolations, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectViolationsList.
+func (in *ObjectViolationsList) DeepCopy() *ObjectViolationsList {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectViolationsList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObjectViolationsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectViolationsSpec) DeepCopyInto(out *ObjectViolationsSpec) {
+	*out = *in
+	out.Object = in.Object
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectViolationsSpec.
+func (in *ObjectViolationsSpec) DeepCopy() *ObjectViolationsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectViolationsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectViolationsStatus) DeepCopyInto(out *ObjectViolationsStatus) {
+	*out = *in
+	if in.Violations != nil {
+		in, out := &in.Violations, &out.Violations
+		*out = make([]ObjectViolation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectViolationsStatus.
+func (in *ObjectViolationsStatus) DeepCopy() *ObjectViolationsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectViolationsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceEnforcement) DeepCopyInto(out *NamespaceEnforcement) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]NamespaceEnforcementRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceEnforcement.
+func (in *NamespaceEnforcement) DeepCopy() *NamespaceEnforcement {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceEnforcement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceEnforcementRule) DeepCopyInto(out *NamespaceEnforcementRule) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceEnforcementRule.
+func (in *NamespaceEnforcementRule) DeepCopy() *NamespaceEnforcementRule {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceEnforcementRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicySets) DeepCopyInto(out *PolicySets) {
+	*out = *in
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicySets.
+func (in *PolicySets) DeepCopy() *PolicySets {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicySets)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Sync) DeepCopyInto(out *Sync) {
 	*out = *in
@@ -149,6 +349,22 @@ func (in *Sync) DeepCopy() *Sync {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncError) DeepCopyInto(out *SyncError) {
+	*out = *in
+	out.Kind = in.Kind
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncError.
+func (in *SyncError) DeepCopy() *SyncError {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SyncOnlyEntry) DeepCopyInto(out *SyncOnlyEntry) {
 	*out = *in
@@ -0,0 +1,31 @@
+package k8sallowedregistries
+
+import data.lib.containers
+import data.lib.images
+
+violation[{"msg": msg, "details": {"image": image, "path": c.path}}] {
+  c := containers.all_containers(input.review.object)[_]
+  image := c.container.image
+  prefix := input.parameters.denied[_]
+  images.matches_prefix(image, prefix)
+  msg := sprintf("container <%v> has image <%v> from denied registry <%v>", [c.container.name, image, prefix])
+}
+
+violation[{"msg": msg, "details": {"image": image, "path": c.path}}] {
+  c := containers.all_containers(input.review.object)[_]
+  image := c.container.image
+  allowed := input.parameters.allowed
+  not allowed_image(image, allowed)
+  msg := sprintf("container <%v> has image <%v> (%v), which is not from an allowed registry, allowed registries are %v", [c.container.name, image, images.name(image), allowed])
+}
+
+violation[{"msg": msg, "details": {"image": image, "path": c.path}}] {
+  c := containers.all_containers(input.review.object)[_]
+  image := c.container.image
+  not images.parse(image)
+  msg := sprintf("container <%v> has invalid image reference <%v>", [c.container.name, image])
+}
+
+allowed_image(image, allowed) {
+  images.matches_prefix(image, allowed[_])
+}
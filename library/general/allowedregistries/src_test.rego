@@ -0,0 +1,91 @@
+package k8sallowedregistries
+
+# These tests need the libraries the template uses:
+#   opa test ../../lib/containers/src.rego ../../lib/images/src.rego src.rego src_test.rego
+
+test_allowed_registry {
+  results := violation with input as input_pod(["gcr.io/proj/app:1"], {"allowed": ["gcr.io/proj"]})
+  count(results) == 0
+}
+
+test_not_allowed_registry {
+  results := violation with input as input_pod(["gcr.io/other/app:1"], {"allowed": ["gcr.io/proj"]})
+  count(results) == 1
+}
+
+test_implicit_docker_hub_not_allowed {
+  results := violation with input as input_pod(["nginx"], {"allowed": ["gcr.io"]})
+  count(results) == 1
+}
+
+test_implicit_docker_hub_allowed {
+  results := violation with input as input_pod(["nginx:1.19"], {"allowed": ["docker.io/library"]})
+  count(results) == 0
+}
+
+test_registry_port_must_match {
+  results := violation with input as input_pod(["registry.local:5000/app", "registry.local/app"], {"allowed": ["registry.local:5000"]})
+  count(results) == 1
+}
+
+test_digest_allowed {
+  results := violation with input as input_pod(["gcr.io/proj/app@sha256:abc"], {"allowed": ["gcr.io/proj"]})
+  count(results) == 0
+}
+
+test_prefix_is_not_a_string_prefix {
+  results := violation with input as input_pod(["gcr.io/proj-evil/app", "gcr.io.evil.com/proj/app"], {"allowed": ["gcr.io/proj"]})
+  count(results) == 2
+}
+
+test_empty_allowed_denies_everything {
+  results := violation with input as input_pod(["gcr.io/proj/app"], {"allowed": []})
+  count(results) == 1
+}
+
+test_no_allowed_list_allows_everything {
+  results := violation with input as input_pod(["gcr.io/proj/app"], {})
+  count(results) == 0
+}
+
+test_denied_registry {
+  results := violation with input as input_pod(["docker.io/evil/miner", "gcr.io/proj/app"], {"denied": ["docker.io/evil"]})
+  count(results) == 1
+}
+
+test_denied_overrides_allowed {
+  results := violation with input as input_pod(["gcr.io/proj/bad"], {"allowed": ["gcr.io/proj"], "denied": ["gcr.io/proj/bad"]})
+  count(results) == 1
+}
+
+test_invalid_reference {
+  results := violation with input as input_pod(["a@b@c"], {})
+  count(results) == 1
+}
+
+test_all_container_types {
+  obj := {
+    "kind": "Pod",
+    "spec": {
+      "containers": [{"name": "a", "image": "gcr.io/proj/a"}],
+      "initContainers": [{"name": "b", "image": "bad.io/b"}],
+      "ephemeralContainers": [{"name": "c", "image": "bad.io/c"}],
+    },
+  }
+  results := violation with input as {"review": {"object": obj}, "parameters": {"allowed": ["gcr.io/proj"]}}
+  paths := {r.details.path | r := results[_]}
+  paths == {"spec.initContainers[0]", "spec.ephemeralContainers[0]"}
+}
+
+test_deployment {
+  obj := {"kind": "Deployment", "spec": {"template": {"spec": {"containers": [{"name": "a", "image": "bad.io/a"}]}}}}
+  results := violation with input as {"review": {"object": obj}, "parameters": {"allowed": ["gcr.io/proj"]}}
+  count(results) == 1
+}
+
+input_pod(imgs, parameters) = {"review": {"object": obj}, "parameters": parameters} {
+  obj := {
+    "kind": "Pod",
+    "spec": {"containers": [c | img := imgs[i]; c := {"name": sprintf("c%v", [i]), "image": img}]},
+  }
+}
@@ -0,0 +1,28 @@
+package k8srequiredpoddisruptionbudget
+
+import data.lib.pdb
+
+default_min_replicas = 2
+
+min_replicas = n {
+  n := input.parameters.minReplicas
+} else = default_min_replicas {
+  true
+}
+
+# Deployments, ReplicaSets and StatefulSets default to a single replica
+replicas(obj) = object.get(obj.spec, "replicas", 1)
+
+namespace(review) = ns {
+  ns := review.object.metadata.namespace
+} else = ns {
+  ns := review.namespace
+}
+
+violation[{"msg": msg, "details": {"replicas": n, "minReplicas": min_replicas}}] {
+  obj := input.review.object
+  n := replicas(obj)
+  n >= min_replicas
+  count(pdb.matching(obj, namespace(input.review))) == 0
+  msg := sprintf("%v <%v> has %v replicas but no PodDisruptionBudget selects its pods, one is required from %v replicas", [obj.kind, obj.metadata.name, n, min_replicas])
+}
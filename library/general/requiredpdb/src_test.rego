@@ -0,0 +1,76 @@
+package k8srequiredpoddisruptionbudget
+
+# These tests need the library the template uses:
+#   opa test ../../lib/pdb/src.rego src.rego src_test.rego
+
+test_no_budget {
+  results := violation with input as input_review(deployment(3, {"app": "cache"}), {}) with data.inventory as inventory
+  count(results) == 1
+}
+
+test_matching_budget {
+  results := violation with input as input_review(deployment(3, {"app": "api"}), {}) with data.inventory as inventory
+  count(results) == 0
+}
+
+test_matching_budget_with_match_expressions {
+  results := violation with input as input_review(deployment(3, {"app": "web", "track": "canary"}), {}) with data.inventory as inventory
+  count(results) == 0
+}
+
+test_match_expressions_exclude_workload {
+  results := violation with input as input_review(deployment(3, {"app": "web", "track": "stable"}), {}) with data.inventory as inventory
+  count(results) == 1
+}
+
+test_budget_in_other_namespace {
+  review := input_review(deployment(3, {"app": "api"}), {})
+  results := violation with input as object.union(review, {"review": {"namespace": "dev", "object": {"metadata": {"namespace": "dev"}}}}) with data.inventory as inventory
+  count(results) == 1
+}
+
+test_below_min_replicas {
+  results := violation with input as input_review(deployment(1, {"app": "cache"}), {}) with data.inventory as inventory
+  count(results) == 0
+}
+
+test_default_replicas {
+  results := violation with input as input_review(deployment(null, {"app": "cache"}), {"minReplicas": 1}) with data.inventory as inventory
+  count(results) == 1
+}
+
+test_min_replicas {
+  results := violation with input as input_review(deployment(3, {"app": "cache"}), {"minReplicas": 5}) with data.inventory as inventory
+  count(results) == 0
+}
+
+test_namespace_from_request {
+  review := input_review(deployment(3, {"app": "api"}), {})
+  results := violation with input as {"review": {"namespace": "prod", "object": {"kind": "StatefulSet", "metadata": {"name": "api"}, "spec": review.review.object.spec}}, "parameters": {}} with data.inventory as inventory
+  count(results) == 0
+}
+
+inventory = {"namespace": {"prod": {"policy/v1beta1": {"PodDisruptionBudget": {
+  "api": budget("api", {"matchLabels": {"app": "api"}}),
+  "canary": budget("canary", {"matchExpressions": [
+    {"key": "app", "operator": "In", "values": ["web"]},
+    {"key": "track", "operator": "NotIn", "values": ["stable"]},
+  ]}),
+}}}}}
+
+budget(name, selector) = {"apiVersion": "policy/v1beta1", "kind": "PodDisruptionBudget", "metadata": {"name": name, "namespace": "prod"}, "spec": {"minAvailable": 1, "selector": selector}}
+
+deployment(replicas, labels) = obj {
+  replicas == null
+  obj := {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "prod"}, "spec": {"template": {"metadata": {"labels": labels}}}}
+}
+
+deployment(replicas, labels) = obj {
+  replicas != null
+  obj := {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "prod"}, "spec": {"replicas": replicas, "template": {"metadata": {"labels": labels}}}}
+}
+
+input_review(obj, parameters) = {
+  "review": {"namespace": obj.metadata.namespace, "object": obj},
+  "parameters": parameters,
+}
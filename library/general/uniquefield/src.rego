@@ -0,0 +1,97 @@
+package k8suniquefield
+
+get_default(obj, param, _default) = out {
+  out := obj[param]
+} else = out {
+  out := _default
+}
+
+groups[group] {
+  group := input.parameters.apiGroups[_]
+}
+
+groups[group] {
+  not input.parameters.apiGroups
+  group := input.review.kind.group
+}
+
+group_of(apiversion) = group {
+  parts := split(apiversion, "/")
+  count(parts) == 2
+  group := parts[0]
+}
+
+group_of(apiversion) = "" {
+  not contains(apiversion, "/")
+}
+
+# "*" matches any key or index
+path_mismatch(path, pattern) {
+  segment := pattern[i]
+  segment != "*"
+  sprintf("%v", [path[i]]) != segment
+}
+
+field_values(obj) = {value |
+  walk(obj, [path, value])
+  count(path) == count(input.parameters.path)
+  not path_mismatch(path, input.parameters.path)
+}
+
+namespace_scoped {
+  get_default(input.parameters, "scope", "Cluster") == "Namespace"
+}
+
+candidates[other] {
+  input.review.object.metadata.namespace
+  not namespace_scoped
+  other := data.inventory.namespace[_][apiversion][input.review.kind.kind][_]
+  groups[group_of(apiversion)]
+}
+
+candidates[other] {
+  ns := input.review.object.metadata.namespace
+  namespace_scoped
+  other := data.inventory.namespace[ns][apiversion][input.review.kind.kind][_]
+  groups[group_of(apiversion)]
+}
+
+candidates[other] {
+  not input.review.object.metadata.namespace
+  other := data.inventory.cluster[apiversion][input.review.kind.kind][_]
+  groups[group_of(apiversion)]
+}
+
+# Objects are compared by UID, which also matches the same object synced at
+# more than one API version. Objects that are being created have no UID yet,
+# so an object of the same name, which can only be a deleted one that has not
+# been removed from the inventory, is skipped.
+same_object(other, obj) {
+  uid := obj.metadata.uid
+  other.metadata.uid == uid
+}
+
+same_object(other, obj) {
+  not obj.metadata.uid
+  object_name(other) == object_name(obj)
+}
+
+object_name(obj) = name {
+  ns := obj.metadata.namespace
+  name := sprintf("%v/%v", [ns, obj.metadata.name])
+}
+
+object_name(obj) = name {
+  not obj.metadata.namespace
+  name := obj.metadata.name
+}
+
+violation[{"msg": msg, "details": {"value": value, "conflict": conflict}}] {
+  values := field_values(input.review.object)
+  other := candidates[_]
+  not same_object(other, input.review.object)
+  value := values[_]
+  field_values(other)[value]
+  conflict := object_name(other)
+  msg := sprintf("%v <%v> is already used by %v <%v>", [concat(".", input.parameters.path), value, input.review.kind.kind, conflict])
+}
@@ -0,0 +1,135 @@
+package k8suniquefield
+
+host_path := ["spec", "rules", "*", "host"]
+
+test_no_data {
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path})
+  count(results) == 0
+}
+
+test_conflict_across_namespaces {
+  inv := inventory([ingress("b", "dev", "uid-b", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 1
+}
+
+test_no_conflict {
+  inv := inventory([ingress("b", "dev", "uid-b", ["b.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 0
+}
+
+test_multiple_conflicts {
+  inv := inventory([ingress("b", "dev", "uid-b", ["a.abc.com"]), ingress("c", "dev", "uid-c", ["c.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com", "c.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 2
+}
+
+test_update_does_not_conflict_with_itself {
+  inv := inventory([ingress("a", "prod", "uid-a", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 0
+}
+
+test_create_skips_deleted_object_with_same_name {
+  inv := inventory([ingress("a", "prod", "uid-old", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress_without_uid("a", "prod", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 0
+}
+
+test_create_conflicts {
+  inv := inventory([ingress("b", "prod", "uid-b", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress_without_uid("a", "prod", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 1
+}
+
+test_recreated_object_with_new_uid_conflicts {
+  inv := inventory([ingress("a", "prod", "uid-old", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-new", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 1
+}
+
+test_namespace_scope {
+  inv := inventory([ingress("b", "dev", "uid-b", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path, "scope": "Namespace"}) with data.inventory as inv
+  count(results) == 0
+}
+
+test_namespace_scope_conflict {
+  inv := inventory([ingress("b", "prod", "uid-b", ["a.abc.com"])], "extensions/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path, "scope": "Namespace"}) with data.inventory as inv
+  count(results) == 1
+}
+
+test_other_group_ignored {
+  inv := inventory([ingress("b", "dev", "uid-b", ["a.abc.com"])], "networking.k8s.io/v1beta1")
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), {"path": host_path}) with data.inventory as inv
+  count(results) == 0
+}
+
+test_api_groups {
+  inv := inventory([ingress("b", "dev", "uid-b", ["a.abc.com"])], "networking.k8s.io/v1beta1")
+  params := {"path": host_path, "apiGroups": ["extensions", "networking.k8s.io"]}
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), params) with data.inventory as inv
+  count(results) == 1
+}
+
+test_same_object_at_two_versions {
+  inv := {"namespace": {"prod": {
+    "extensions/v1beta1": {"Ingress": {"a": ingress("a", "prod", "uid-a", ["a.abc.com"])}},
+    "networking.k8s.io/v1beta1": {"Ingress": {"a": ingress("a", "prod", "uid-a", ["a.abc.com"])}},
+  }}}
+  params := {"path": host_path, "apiGroups": ["extensions", "networking.k8s.io"]}
+  results := violation with input as input_ingress(ingress("a", "prod", "uid-a", ["a.abc.com"]), params) with data.inventory as inv
+  count(results) == 0
+}
+
+test_cluster_scoped {
+  inv := {"cluster": {"v1": {"Namespace": {"b": namespace("b", "uid-b", "team-a")}}}}
+  params := {"path": ["metadata", "labels", "owner"]}
+  obj := namespace("a", "uid-a", "team-a")
+  results := violation with input as {"review": {"kind": {"group": "", "version": "v1", "kind": "Namespace"}, "object": obj}, "parameters": params} with data.inventory as inv
+  count(results) == 1
+}
+
+test_cluster_scoped_no_conflict {
+  inv := {"cluster": {"v1": {"Namespace": {"b": namespace("b", "uid-b", "team-b")}}}}
+  params := {"path": ["metadata", "labels", "owner"]}
+  obj := namespace("a", "uid-a", "team-a")
+  results := violation with input as {"review": {"kind": {"group": "", "version": "v1", "kind": "Namespace"}, "object": obj}, "parameters": params} with data.inventory as inv
+  count(results) == 0
+}
+
+input_ingress(obj, params) = {
+  "review": {
+    "kind": {"group": "extensions", "version": "v1beta1", "kind": "Ingress"},
+    "object": obj,
+  },
+  "parameters": params,
+}
+
+ingress(name, ns, uid, hosts) = out {
+  out := {
+    "kind": "Ingress",
+    "metadata": {"name": name, "namespace": ns, "uid": uid},
+    "spec": {"rules": [{"host": host} | host := hosts[_]]},
+  }
+}
+
+ingress_without_uid(name, ns, hosts) = out {
+  out := {
+    "kind": "Ingress",
+    "metadata": {"name": name, "namespace": ns},
+    "spec": {"rules": [{"host": host} | host := hosts[_]]},
+  }
+}
+
+namespace(name, uid, owner) = {
+  "kind": "Namespace",
+  "metadata": {"name": name, "uid": uid, "labels": {"owner": owner}},
+}
+
+inventory(objs, apiversion) = out {
+  namespaces := {ns | ns := objs[_].metadata.namespace}
+  out := {"namespace": {ns: {apiversion: {"Ingress": {o.metadata.name: o | o := objs[_]; o.metadata.namespace == ns}}} | ns := namespaces[_]}}
+}
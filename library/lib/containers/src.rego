@@ -0,0 +1,52 @@
+package lib.containers
+
+# Container types, keyed by the pod spec field that holds them.
+types = {
+  "containers": "container",
+  "initContainers": "init",
+  "ephemeralContainers": "ephemeral",
+}
+
+# pod_spec returns the pod spec of a Pod, of a CronJob, or of any workload
+# with a pod template under spec.template (Deployment, Job, DaemonSet, ...),
+# along with the path to it.
+pod_spec(obj) = [path, spec] {
+  obj.kind == "Pod"
+  path := "spec"
+  spec := obj.spec
+}
+
+pod_spec(obj) = [path, spec] {
+  obj.kind == "CronJob"
+  path := "spec.jobTemplate.spec.template.spec"
+  spec := obj.spec.jobTemplate.spec.template.spec
+}
+
+pod_spec(obj) = [path, spec] {
+  obj.kind != "Pod"
+  obj.kind != "CronJob"
+  path := "spec.template.spec"
+  spec := obj.spec.template.spec
+}
+
+# all_containers returns every container in obj, each tagged with its type
+# and the path it was found at:
+#   {"type": "init", "path": "spec.initContainers[0]", "container": {...}}
+all_containers(obj) = tagged {
+  [path, spec] := pod_spec(obj)
+  tagged := {c |
+    type := types[field]
+    container := spec[field][i]
+    c := {
+      "type": type,
+      "path": sprintf("%v.%v[%v]", [path, field, i]),
+      "container": container,
+    }
+  }
+}
+
+# of_type returns the containers of the given type ("container", "init" or
+# "ephemeral") in obj.
+of_type(obj, type) = tagged {
+  tagged := {c | c := all_containers(obj)[_]; c.type == type}
+}
@@ -0,0 +1,71 @@
+package lib.containers
+
+test_pod_all_types {
+  results := all_containers(pod)
+  count(results) == 4
+  results[{"type": "container", "path": "spec.containers[0]", "container": {"name": "app"}}]
+  results[{"type": "container", "path": "spec.containers[1]", "container": {"name": "sidecar"}}]
+  results[{"type": "init", "path": "spec.initContainers[0]", "container": {"name": "setup"}}]
+  results[{"type": "ephemeral", "path": "spec.ephemeralContainers[0]", "container": {"name": "debugger"}}]
+}
+test_pod_of_type_ephemeral {
+  results := of_type(pod, "ephemeral")
+  count(results) == 1
+  results[{"type": "ephemeral", "path": "spec.ephemeralContainers[0]", "container": {"name": "debugger"}}]
+}
+test_pod_of_type_init {
+  results := of_type(pod, "init")
+  count(results) == 1
+  results[_].container.name == "setup"
+}
+test_pod_without_init {
+  results := of_type({"kind": "Pod", "spec": {"containers": [{"name": "app"}]}}, "init")
+  count(results) == 0
+}
+test_deployment {
+  results := all_containers(workload("Deployment"))
+  count(results) == 2
+  results[{"type": "container", "path": "spec.template.spec.containers[0]", "container": {"name": "app"}}]
+  results[{"type": "init", "path": "spec.template.spec.initContainers[0]", "container": {"name": "setup"}}]
+}
+test_job {
+  results := of_type(workload("Job"), "container")
+  count(results) == 1
+  results[{"type": "container", "path": "spec.template.spec.containers[0]", "container": {"name": "app"}}]
+}
+test_cronjob {
+  results := all_containers(cronjob)
+  count(results) == 2
+  results[{"type": "container", "path": "spec.jobTemplate.spec.template.spec.containers[0]", "container": {"name": "app"}}]
+  results[{"type": "init", "path": "spec.jobTemplate.spec.template.spec.initContainers[0]", "container": {"name": "setup"}}]
+}
+test_no_pod_spec {
+  results := [c | c := all_containers({"kind": "ConfigMap", "data": {}})[_]]
+  count(results) == 0
+}
+
+pod = {
+  "kind": "Pod",
+  "spec": {
+    "containers": [{"name": "app"}, {"name": "sidecar"}],
+    "initContainers": [{"name": "setup"}],
+    "ephemeralContainers": [{"name": "debugger"}],
+  }
+}
+
+pod_template_spec = {
+  "containers": [{"name": "app"}],
+  "initContainers": [{"name": "setup"}],
+}
+
+workload(kind) = obj {
+  obj := {
+    "kind": kind,
+    "spec": {"template": {"spec": pod_template_spec}}
+  }
+}
+
+cronjob = {
+  "kind": "CronJob",
+  "spec": {"jobTemplate": {"spec": {"template": {"spec": pod_template_spec}}}}
+}
@@ -0,0 +1,34 @@
+package lib.drift
+
+# Annotation holding the expected hash of an object's selected fields, as set
+# by a GitOps tool. The hash may be prefixed with "sha256:".
+expected_hash_annotation = "drift.gatekeeper.sh/expected-hash"
+
+# canonical returns the fields of obj selected by paths as JSON, with object
+# keys sorted and no whitespace, so equal fields always serialize the same
+# way. Paths are slash-separated, e.g. "spec/template" or "metadata/labels".
+# Selected fields that obj doesn't have are left out.
+canonical(obj, paths) = json.marshal(json.filter(obj, paths))
+
+# hash returns the hex encoded sha256 hash of the canonical fields.
+hash(obj, paths) = crypto.sha256(canonical(obj, paths))
+
+# expected_hash returns the hash declared by the annotation, without its
+# prefix. It is undefined if obj has no or an empty annotation.
+expected_hash(obj) = h {
+  declared := trim_space(obj.metadata.annotations[expected_hash_annotation])
+  h := lower(trim_prefix(declared, "sha256:"))
+  h != ""
+}
+
+# bootstrapping is true for objects that don't declare an expected hash yet,
+# such as objects created before the GitOps tool annotated them.
+bootstrapping(obj) {
+  not expected_hash(obj)
+}
+
+# drifted is true if obj declares an expected hash that its selected fields
+# no longer match. It is false while bootstrapping.
+drifted(obj, paths) {
+  expected_hash(obj) != hash(obj, paths)
+}
@@ -0,0 +1,44 @@
+package lib.drift
+
+test_canonical_sorts_keys {
+  canonical({"spec": {"selector": {"app": "web"}, "replicas": 3}, "status": {}}, ["spec"]) == `{"spec":{"replicas":3,"selector":{"app":"web"}}}`
+}
+test_canonical_selects_fields {
+  canonical(deployment(null), ["spec/replicas", "metadata/labels"]) == `{"metadata":{"labels":{"app":"web"}},"spec":{"replicas":3}}`
+}
+test_canonical_missing_fields {
+  canonical(deployment(null), ["spec/paused"]) == `{"spec":{}}`
+}
+test_hash {
+  hash(deployment(null), ["spec"]) == spec_hash
+}
+test_in_sync {
+  not drifted(deployment(spec_hash), ["spec"])
+}
+test_in_sync_prefixed {
+  not drifted(deployment(sprintf("sha256:%v", [upper(spec_hash)])), ["spec"])
+}
+test_drifted {
+  drifted(deployment("0000"), ["spec"])
+}
+test_bootstrapping {
+  bootstrapping(deployment(null))
+  not drifted(deployment(null), ["spec"])
+}
+test_empty_annotation_is_bootstrapping {
+  bootstrapping(deployment(" "))
+}
+
+spec_hash = "ea84ec5afdc74943e63dd4dbb2fd02f76d1a4d9bb8a5e84d95a2031abcf25012"
+
+deployment(expected) = obj {
+  expected == null
+  obj := {"metadata": {"labels": {"app": "web"}}, "spec": spec}
+}
+
+deployment(expected) = obj {
+  expected != null
+  obj := {"metadata": {"labels": {"app": "web"}, "annotations": {expected_hash_annotation: expected}}, "spec": spec}
+}
+
+spec = {"replicas": 3, "selector": {"app": "web"}}
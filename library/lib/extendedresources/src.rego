@@ -0,0 +1,102 @@
+package lib.extendedresources
+
+# is_extended is true for extended resource names, such as nvidia.com/gpu:
+# names with a domain outside of kubernetes.io, as opposed to native
+# resources like cpu or hugepages-2Mi.
+is_extended(name) {
+  contains(name, "/")
+  not contains(name, "kubernetes.io/")
+  not startswith(name, "requests.")
+}
+
+# quantity returns a resource quantity as a number. Extended resources can
+# only be requested in whole numbers, e.g. 1 or "2".
+quantity(q) = n {
+  is_number(q)
+  n := q
+} else = n {
+  is_string(q)
+  n := to_number(q)
+}
+
+# amounts returns the extended resources of a container, by name. Extended
+# resources can't be overcommitted, so a container gets its limit, which
+# also defaults its request. A request without a limit, which the API server
+# rejects for extended resources, is counted as its own limit.
+amounts(container) = {name: n |
+  resources := object.get(container, "resources", {})
+  names := {k | object.get(resources, "limits", {})[k]} | {k | object.get(resources, "requests", {})[k]}
+  name := names[_]
+  is_extended(name)
+  n := quantity(amount(resources, name))
+}
+
+amount(resources, name) = q {
+  q := resources.limits[name]
+} else = q {
+  q := resources.requests[name]
+}
+
+# requests_without_limits returns the extended resources a container
+# requests without a limit.
+requests_without_limits(container) = {name |
+  container.resources.requests[name]
+  is_extended(name)
+  not container.resources.limits[name]
+}
+
+# mismatched returns the extended resources whose request and limit differ,
+# which the API server rejects.
+mismatched(container) = {name |
+  request := container.resources.requests[name]
+  is_extended(name)
+  limit := container.resources.limits[name]
+  quantity(request) != quantity(limit)
+}
+
+# pod_spec returns the pod spec of a Pod, of a CronJob, or of any workload
+# with a pod template under spec.template (Deployment, Job, DaemonSet, ...).
+# Keep in sync with lib.containers.
+pod_spec(obj) = spec {
+  obj.kind == "Pod"
+  spec := obj.spec
+}
+
+pod_spec(obj) = spec {
+  obj.kind == "CronJob"
+  spec := obj.spec.jobTemplate.spec.template.spec
+}
+
+pod_spec(obj) = spec {
+  obj.kind != "Pod"
+  obj.kind != "CronJob"
+  spec := obj.spec.template.spec
+}
+
+# pod_amounts returns the extended resources a pod of obj is scheduled with,
+# by name. Init containers run one at a time before the other containers, so
+# a pod needs the larger of the sum of its containers and its largest init
+# container. Ephemeral containers can't have resources.
+pod_amounts(obj) = {name: n |
+  spec := pod_spec(obj)
+  names := {k | amounts(spec.containers[_])[k]} | {k | amounts(spec.initContainers[_])[k]}
+  name := names[_]
+  n := max({
+    sum([q | q := amounts(spec.containers[_])[name]]),
+    max(array.concat([0], [q | q := amounts(spec.initContainers[_])[name]])),
+  })
+}
+
+# requests is true if a pod of obj is scheduled with some of the named
+# extended resource.
+requests(obj, name) {
+  pod_amounts(obj)[name] > 0
+}
+
+# requests_any is true if a pod of obj is scheduled with any extended
+# resource of the given domain, e.g. "nvidia.com".
+requests_any(obj, domain) {
+  n := pod_amounts(obj)[name]
+  startswith(name, concat("", [domain, "/"]))
+  n > 0
+}
@@ -0,0 +1,54 @@
+package lib.extendedresources
+
+test_is_extended {
+  is_extended("nvidia.com/gpu")
+  is_extended("example.com/foo")
+  not is_extended("cpu")
+  not is_extended("hugepages-2Mi")
+  not is_extended("kubernetes.io/foo")
+  not is_extended("requests.nvidia.com/gpu")
+}
+test_limit_only {
+  amounts(gpu_container({"limits": {"nvidia.com/gpu": "2"}})) == {"nvidia.com/gpu": 2}
+}
+test_request_only {
+  amounts(gpu_container({"requests": {"nvidia.com/gpu": 1}})) == {"nvidia.com/gpu": 1}
+  requests_without_limits(gpu_container({"requests": {"nvidia.com/gpu": 1}})) == {"nvidia.com/gpu"}
+}
+test_limit_wins {
+  amounts(gpu_container({"requests": {"nvidia.com/gpu": "1"}, "limits": {"nvidia.com/gpu": "2"}})) == {"nvidia.com/gpu": 2}
+  mismatched(gpu_container({"requests": {"nvidia.com/gpu": "1"}, "limits": {"nvidia.com/gpu": "2"}})) == {"nvidia.com/gpu"}
+}
+test_matching_request_and_limit {
+  count(mismatched(gpu_container({"requests": {"nvidia.com/gpu": "1"}, "limits": {"nvidia.com/gpu": 1}}))) == 0
+  count(requests_without_limits(gpu_container({"requests": {"nvidia.com/gpu": "1"}, "limits": {"nvidia.com/gpu": 1}}))) == 0
+}
+test_native_resources_ignored {
+  amounts(gpu_container({"limits": {"cpu": "1", "memory": "1Gi"}})) == {}
+}
+test_no_resources {
+  amounts({"name": "app"}) == {}
+  pod_amounts(pod([{"name": "app"}], [])) == {}
+}
+test_pod_sums_containers {
+  pod_amounts(pod([gpu_container({"limits": {"nvidia.com/gpu": 1}}), gpu_container({"limits": {"nvidia.com/gpu": 2}})], [])) == {"nvidia.com/gpu": 3}
+}
+test_pod_largest_init_container {
+  pod_amounts(pod([gpu_container({"limits": {"nvidia.com/gpu": 1}})], [gpu_container({"limits": {"nvidia.com/gpu": 4}}), gpu_container({"limits": {"nvidia.com/gpu": 2}})])) == {"nvidia.com/gpu": 4}
+}
+test_pod_init_only {
+  pod_amounts(pod([{"name": "app"}], [gpu_container({"limits": {"example.com/fpga": 1}})])) == {"example.com/fpga": 1}
+}
+test_workloads {
+  requests({"kind": "Deployment", "spec": {"template": {"spec": {"containers": [gpu_container({"limits": {"nvidia.com/gpu": 1}})]}}}}, "nvidia.com/gpu")
+  requests({"kind": "CronJob", "spec": {"jobTemplate": {"spec": {"template": {"spec": {"containers": [gpu_container({"limits": {"nvidia.com/gpu": 1}})]}}}}}}, "nvidia.com/gpu")
+}
+test_requests_any {
+  requests_any(pod([gpu_container({"limits": {"nvidia.com/mig-1g.5gb": 1}})], []), "nvidia.com")
+  not requests_any(pod([gpu_container({"limits": {"nvidia.com/gpu": 0}})], []), "nvidia.com")
+  not requests_any(pod([gpu_container({"limits": {"example.com/gpu": 1}})], []), "nvidia.com")
+}
+
+gpu_container(resources) = {"name": "app", "resources": resources}
+
+pod(containers, init) = {"kind": "Pod", "spec": {"containers": containers, "initContainers": init}}
@@ -0,0 +1,94 @@
+package lib.helm
+
+# Helm 3 records the release an object belongs to in the
+# meta.helm.sh/release-name and release-namespace annotations. Objects that
+# don't have them, such as the pods of a Deployment, fall back to the
+# app.kubernetes.io/instance label of charts that follow the recommended
+# labels, and then to the release label Helm 2 charts set by convention.
+helm2_heritage = {"Helm", "Tiller"}
+
+# release returns {"name": <release name>, "namespace": <release namespace>}
+# for the object. It is undefined for objects without release metadata, so
+# they never match a release.
+release(obj) = r {
+  name := obj.metadata.annotations["meta.helm.sh/release-name"]
+  name != ""
+  r := {"name": name, "namespace": release_namespace(obj)}
+} else = r {
+  labels := obj.metadata.labels
+  labels["app.kubernetes.io/managed-by"] == "Helm"
+  name := labels["app.kubernetes.io/instance"]
+  name != ""
+  r := {"name": name, "namespace": object_namespace(obj)}
+} else = r {
+  labels := obj.metadata.labels
+  helm2_heritage[labels.heritage]
+  name := labels.release
+  name != ""
+  r := {"name": name, "namespace": object_namespace(obj)}
+}
+
+release_namespace(obj) = ns {
+  ns := obj.metadata.annotations["meta.helm.sh/release-namespace"]
+} else = ns {
+  ns := object_namespace(obj)
+}
+
+object_namespace(obj) = ns {
+  ns := obj.metadata.namespace
+} else = "" {
+  true
+}
+
+# release_name returns the name of the object's release
+release_name(obj) = name {
+  name := release(obj).name
+}
+
+# chart returns the chart and version the object was rendered from, e.g.
+# "nginx-1.2.3", from the helm.sh/chart label, or Helm 2's chart label.
+chart(obj) = c {
+  c := obj.metadata.labels["helm.sh/chart"]
+} else = c {
+  helm2_heritage[obj.metadata.labels.heritage]
+  c := obj.metadata.labels.chart
+}
+
+# chart_name returns the chart without its version, e.g. "nginx"
+chart_name(obj) = name {
+  c := chart(obj)
+  parts := split(c, "-")
+  count(parts) > 1
+  re_match("^v?[0-9]", parts[count(parts) - 1])
+  name := concat("-", array.slice(parts, 0, count(parts) - 1))
+} else = name {
+  name := chart(obj)
+}
+
+# matches_release returns whether the object belongs to a release matched by
+# selector. The selector's optional "names", "namespaces" and "charts" lists
+# each match when any of their entries does, and all present lists must
+# match. Charts are matched by name, without their version. Objects without
+# release metadata never match.
+matches_release(obj, selector) {
+  r := release(obj)
+  list_matches(selector, "names", r.name)
+  list_matches(selector, "namespaces", r.namespace)
+  chart_matches(obj, selector)
+}
+
+list_matches(selector, key, value) {
+  not selector[key]
+}
+
+list_matches(selector, key, value) {
+  selector[key][_] == value
+}
+
+chart_matches(obj, selector) {
+  not selector.charts
+}
+
+chart_matches(obj, selector) {
+  selector.charts[_] == chart_name(obj)
+}
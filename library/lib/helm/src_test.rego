@@ -0,0 +1,100 @@
+package lib.helm
+
+helm3_obj = {"metadata": {
+  "name": "web",
+  "namespace": "apps",
+  "annotations": {"meta.helm.sh/release-name": "frontend", "meta.helm.sh/release-namespace": "releases"},
+  "labels": {"helm.sh/chart": "nginx-ingress-1.41.3", "app.kubernetes.io/managed-by": "Helm"},
+}}
+
+labeled_obj = {"metadata": {
+  "name": "web-abc",
+  "namespace": "apps",
+  "labels": {"app.kubernetes.io/instance": "frontend", "app.kubernetes.io/managed-by": "Helm"},
+}}
+
+helm2_obj = {"metadata": {
+  "name": "web",
+  "namespace": "apps",
+  "labels": {"release": "legacy", "heritage": "Tiller", "chart": "redis-10.5.7"},
+}}
+
+plain_obj = {"metadata": {"name": "web", "namespace": "apps", "labels": {"release": "stable"}}}
+
+test_release_from_annotations {
+  release(helm3_obj) == {"name": "frontend", "namespace": "releases"}
+}
+
+test_release_namespace_defaults_to_object_namespace {
+  obj := {"metadata": {"namespace": "apps", "annotations": {"meta.helm.sh/release-name": "frontend"}}}
+  release(obj) == {"name": "frontend", "namespace": "apps"}
+}
+
+test_release_of_cluster_scoped_object {
+  obj := {"metadata": {"name": "admin", "annotations": {"meta.helm.sh/release-name": "rbac"}}}
+  release(obj) == {"name": "rbac", "namespace": ""}
+}
+
+test_release_from_recommended_labels {
+  release(labeled_obj) == {"name": "frontend", "namespace": "apps"}
+}
+
+test_release_from_helm2_labels {
+  release(helm2_obj) == {"name": "legacy", "namespace": "apps"}
+}
+
+test_no_release_without_heritage {
+  not release(plain_obj)
+}
+
+test_no_release_without_metadata {
+  not release({"metadata": {"name": "web"}})
+}
+
+test_release_name {
+  release_name(helm3_obj) == "frontend"
+}
+
+test_chart {
+  chart(helm3_obj) == "nginx-ingress-1.41.3"
+}
+
+test_chart_name {
+  chart_name(helm3_obj) == "nginx-ingress"
+}
+
+test_chart_name_helm2 {
+  chart_name(helm2_obj) == "redis"
+}
+
+test_chart_name_without_version {
+  chart_name({"metadata": {"labels": {"helm.sh/chart": "custom"}}}) == "custom"
+}
+
+test_matches_release_name {
+  matches_release(helm3_obj, {"names": ["frontend", "backend"]})
+}
+
+test_matches_empty_selector {
+  matches_release(helm3_obj, {})
+}
+
+test_no_match_other_release {
+  not matches_release(helm3_obj, {"names": ["backend"]})
+}
+
+test_matches_namespace_and_chart {
+  matches_release(helm3_obj, {"namespaces": ["releases"], "charts": ["nginx-ingress"]})
+}
+
+test_no_match_other_chart {
+  not matches_release(helm3_obj, {"names": ["frontend"], "charts": ["redis"]})
+}
+
+test_no_match_chart_without_chart_label {
+  not matches_release(labeled_obj, {"charts": ["nginx-ingress"]})
+}
+
+test_no_match_without_release {
+  not matches_release(plain_obj, {})
+}
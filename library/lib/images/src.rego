@@ -0,0 +1,144 @@
+package lib.images
+
+# parse splits an image reference into its components, normalized the way
+# the container runtime resolves them:
+#   parse("nginx") == {"registry": "docker.io", "repository": "library/nginx", "tag": "latest", "digest": ""}
+#   parse("registry.local:5000/team/app@sha256:abc") == {"registry": "registry.local:5000", "repository": "team/app", "tag": "", "digest": "sha256:abc"}
+# Images without a registry are pulled from Docker Hub, and single component
+# Docker Hub repositories are official images under library/. The tag
+# defaults to latest unless the image is pinned by digest.
+parse(image) = ref {
+  [name, digest] := split_digest(image)
+  [registry, path] := split_registry(name)
+  [repository, tag] := split_tag(path)
+  ref := {
+    "registry": registry,
+    "repository": normalize_repository(registry, repository),
+    "tag": default_tag(tag, digest),
+    "digest": digest,
+  }
+}
+
+# name returns the fully qualified repository of an image, without its tag
+# or digest, e.g. "docker.io/library/nginx".
+name(image) = n {
+  ref := parse(image)
+  n := sprintf("%v/%v", [ref.registry, ref.repository])
+}
+
+# matches_prefix is true if the image's repository is prefix, or is below
+# prefix on a path component boundary. The prefix is a registry host,
+# optionally followed by a repository path, e.g. "gcr.io" or
+# "gcr.io/my-project". "gcr.io/my-project" matches
+# "gcr.io/my-project/app" but not "gcr.io/my-project-2/app". A prefix
+# without a registry host is a Docker Hub path.
+matches_prefix(image, prefix) {
+  name(image) == normalize_prefix(prefix)
+}
+
+matches_prefix(image, prefix) {
+  startswith(name(image), concat("", [normalize_prefix(prefix), "/"]))
+}
+
+# normalize_prefix lowercases the registry host of prefix and resolves Docker
+# Hub aliases. Official Docker Hub images must be written as
+# docker.io/library/<name>.
+normalize_prefix(prefix) = p {
+  trimmed := trim_right(prefix, "/")
+  first := split(trimmed, "/")[0]
+  is_registry(first)
+  rest := substring(trimmed, count(first), -1)
+  p := concat("", [normalize_registry(first), rest])
+}
+
+normalize_prefix(prefix) = p {
+  trimmed := trim_right(prefix, "/")
+  first := split(trimmed, "/")[0]
+  not is_registry(first)
+  p := concat("/", ["docker.io", trimmed])
+}
+
+split_digest(image) = [name, digest] {
+  parts := split(image, "@")
+  count(parts) == 2
+  name := parts[0]
+  digest := parts[1]
+}
+
+split_digest(image) = [image, ""] {
+  not contains(image, "@")
+}
+
+# The first path component is a registry host if it looks like a hostname,
+# has a port, or is localhost.
+split_registry(name) = [registry, path] {
+  i := indexof(name, "/")
+  i > 0
+  first := substring(name, 0, i)
+  is_registry(first)
+  registry := normalize_registry(first)
+  path := substring(name, i + 1, -1)
+}
+
+split_registry(name) = ["docker.io", name] {
+  i := indexof(name, "/")
+  i > 0
+  not is_registry(substring(name, 0, i))
+}
+
+split_registry(name) = ["docker.io", name] {
+  indexof(name, "/") < 0
+}
+
+is_registry(component) {
+  contains(component, ".")
+}
+
+is_registry(component) {
+  contains(component, ":")
+}
+
+is_registry(component) {
+  component == "localhost"
+}
+
+normalize_registry(registry) = "docker.io" {
+  lower(registry) == "index.docker.io"
+}
+
+normalize_registry(registry) = lower(registry) {
+  lower(registry) != "index.docker.io"
+}
+
+# Once the registry is removed, a colon can only separate the tag.
+split_tag(path) = [repository, tag] {
+  parts := split(path, ":")
+  count(parts) == 2
+  repository := parts[0]
+  tag := parts[1]
+}
+
+split_tag(path) = [path, ""] {
+  not contains(path, ":")
+}
+
+normalize_repository("docker.io", repository) = r {
+  not contains(repository, "/")
+  r := concat("/", ["library", repository])
+}
+
+normalize_repository(registry, repository) = repository {
+  registry != "docker.io"
+}
+
+normalize_repository(registry, repository) = repository {
+  contains(repository, "/")
+}
+
+default_tag("", "") = "latest"
+
+default_tag(tag, digest) = tag {
+  not all_empty(tag, digest)
+}
+
+all_empty("", "")
@@ -0,0 +1,105 @@
+package lib.images
+
+test_parse_official_image {
+  parse("nginx") == {"registry": "docker.io", "repository": "library/nginx", "tag": "latest", "digest": ""}
+}
+
+test_parse_docker_hub_org {
+  parse("myorg/app:1.0") == {"registry": "docker.io", "repository": "myorg/app", "tag": "1.0", "digest": ""}
+}
+
+test_parse_explicit_docker_hub {
+  parse("docker.io/nginx:1.19") == {"registry": "docker.io", "repository": "library/nginx", "tag": "1.19", "digest": ""}
+}
+
+test_parse_docker_hub_alias {
+  parse("index.docker.io/library/nginx") == {"registry": "docker.io", "repository": "library/nginx", "tag": "latest", "digest": ""}
+}
+
+test_parse_registry_with_port {
+  parse("registry.local:5000/team/app:v2") == {"registry": "registry.local:5000", "repository": "team/app", "tag": "v2", "digest": ""}
+}
+
+test_parse_registry_port_no_tag {
+  parse("registry.local:5000/app") == {"registry": "registry.local:5000", "repository": "app", "tag": "latest", "digest": ""}
+}
+
+test_parse_localhost {
+  parse("localhost/app") == {"registry": "localhost", "repository": "app", "tag": "latest", "digest": ""}
+}
+
+test_parse_uppercase_registry {
+  parse("GCR.io/proj/app") == {"registry": "gcr.io", "repository": "proj/app", "tag": "latest", "digest": ""}
+}
+
+test_parse_digest {
+  parse("gcr.io/proj/app@sha256:abc") == {"registry": "gcr.io", "repository": "proj/app", "tag": "", "digest": "sha256:abc"}
+}
+
+test_parse_tag_and_digest {
+  parse("nginx:1.19@sha256:abc") == {"registry": "docker.io", "repository": "library/nginx", "tag": "1.19", "digest": "sha256:abc"}
+}
+
+test_parse_invalid {
+  not parse("a@b@c")
+}
+
+test_name {
+  name("nginx:1.19") == "docker.io/library/nginx"
+}
+
+test_matches_registry {
+  matches_prefix("gcr.io/proj/app:1", "gcr.io")
+}
+
+test_matches_repository_prefix {
+  matches_prefix("gcr.io/proj/app:1", "gcr.io/proj")
+}
+
+test_matches_exact_repository {
+  matches_prefix("gcr.io/proj/app:1", "gcr.io/proj/app")
+}
+
+test_matches_trailing_slash {
+  matches_prefix("gcr.io/proj/app:1", "gcr.io/proj/")
+}
+
+test_no_match_partial_component {
+  not matches_prefix("gcr.io/proj-2/app", "gcr.io/proj")
+}
+
+test_no_match_registry_as_path {
+  not matches_prefix("evil.com/gcr.io/app", "gcr.io")
+}
+
+test_no_match_registry_suffix {
+  not matches_prefix("gcr.io.evil.com/app", "gcr.io")
+}
+
+test_no_match_different_port {
+  not matches_prefix("registry.local:5000/app", "registry.local")
+}
+
+test_matches_port {
+  matches_prefix("registry.local:5000/app", "registry.local:5000")
+}
+
+test_matches_implicit_docker_hub {
+  matches_prefix("nginx", "docker.io/library")
+}
+
+test_matches_docker_hub_alias_prefix {
+  matches_prefix("nginx", "index.docker.io/library/nginx")
+}
+
+test_matches_prefix_without_registry {
+  matches_prefix("myorg/app", "myorg")
+}
+
+test_no_match_official_without_library {
+  not matches_prefix("nginx", "docker.io/nginx")
+}
+
+test_matches_digest {
+  matches_prefix("gcr.io/proj/app@sha256:abc", "gcr.io/proj")
+}
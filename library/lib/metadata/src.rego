@@ -0,0 +1,47 @@
+package lib.metadata
+
+# The API server rejects objects whose annotation keys and values add up to
+# more than this many bytes.
+annotations_size_limit = 262144
+
+# labels returns the labels of obj, or an empty object if it has none or they
+# are null.
+labels(obj) = l {
+  l := obj.metadata.labels
+  is_object(l)
+} else = l {
+  l := {}
+}
+
+# annotations returns the annotations of obj, or an empty object if it has
+# none or they are null.
+annotations(obj) = a {
+  a := obj.metadata.annotations
+  is_object(a)
+} else = a {
+  a := {}
+}
+
+label_count(obj) = count(labels(obj))
+
+annotation_count(obj) = count(annotations(obj))
+
+# labels_size returns the bytes used by the keys and values of the labels of
+# obj, measured the same way as annotations_size.
+labels_size(obj) = size(labels(obj))
+
+# annotations_size returns the bytes used by the keys and values of the
+# annotations of obj, as the API server measures them against
+# annotations_size_limit.
+annotations_size(obj) = size(annotations(obj))
+
+# size returns the total length in bytes of the keys and values of m.
+size(m) = sum([n | v := m[k]; n := byte_size(k) + byte_size(v)])
+
+# byte_size returns the length of s in bytes. count(s) counts characters,
+# which is less than the length in bytes for non-ASCII strings.
+byte_size(s) = n {
+  e := base64.encode(s)
+  padding := count(e) - count(trim_right(e, "="))
+  n := ((count(e) / 4) * 3) - padding
+}
@@ -0,0 +1,37 @@
+package lib.metadata
+
+test_counts {
+  label_count(labeled) == 2
+  annotation_count(labeled) == 1
+}
+test_missing_maps {
+  label_count({"metadata": {}}) == 0
+  annotation_count({}) == 0
+  annotations_size({"metadata": {"name": "foo"}}) == 0
+}
+test_null_maps {
+  label_count({"metadata": {"labels": null}}) == 0
+  labels_size({"metadata": {"labels": null}}) == 0
+}
+test_sizes {
+  labels_size(labeled) == 18
+  annotations_size(labeled) == 27
+}
+test_byte_size {
+  byte_size("") == 0
+  byte_size("abc") == 3
+  byte_size("abcd") == 4
+  byte_size("é") == 2
+  byte_size("日本") == 6
+}
+test_size_counts_bytes {
+  annotations_size({"metadata": {"annotations": {"note": "日本"}}}) == 10
+}
+test_size_limit {
+  annotations_size(labeled) < annotations_size_limit
+}
+
+labeled = {"metadata": {
+  "labels": {"app": "web", "tier": "frontend"},
+  "annotations": {"example.com/owner": "team-a-sre"},
+}}
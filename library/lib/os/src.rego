@@ -0,0 +1,86 @@
+package lib.os
+
+# Node labels holding a node's OS, current label first.
+os_labels = ["kubernetes.io/os", "beta.kubernetes.io/os"]
+
+# Taint keys used to keep pods that don't tolerate them off Windows nodes.
+windows_taint_keys = {"os", "kubernetes.io/os", "node.kubernetes.io/os"}
+
+# pod_spec returns the pod spec of a Pod, of a CronJob, or of any workload
+# with a pod template under spec.template (Deployment, Job, DaemonSet, ...).
+pod_spec(obj) = spec {
+  obj.kind == "Pod"
+  spec := obj.spec
+}
+
+pod_spec(obj) = spec {
+  obj.kind == "CronJob"
+  spec := obj.spec.jobTemplate.spec.template.spec
+}
+
+pod_spec(obj) = spec {
+  obj.kind != "Pod"
+  obj.kind != "CronJob"
+  spec := obj.spec.template.spec
+}
+
+# name returns the OS, "linux" or "windows", the pods of obj run on. The
+# first of these decides:
+# - spec.os.name
+# - the kubernetes.io/os (or beta.kubernetes.io/os) nodeSelector
+# - a required node affinity restricting every term to the same OS
+# - a toleration of an os=windows taint, which means "windows"
+# Without any of them the OS is "linux", the default of the scheduler's nodes.
+# It is undefined for objects without a pod spec.
+name(obj) = os {
+  os := spec_os(pod_spec(obj))
+}
+
+windows(obj) {
+  name(obj) == "windows"
+}
+
+linux(obj) {
+  name(obj) == "linux"
+}
+
+spec_os(spec) = os {
+  os := lower(spec.os.name)
+} else = os {
+  os := lower(spec.nodeSelector[os_labels[0]])
+} else = os {
+  os := lower(spec.nodeSelector[os_labels[1]])
+} else = os {
+  os := affinity_os(spec)
+} else = "windows" {
+  tolerates_windows(spec)
+} else = "linux" {
+  true
+}
+
+# Node selector terms are ORed, so the pod is only restricted if every term
+# restricts it, and only to one OS if they all agree.
+affinity_os(spec) = os {
+  terms := spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms
+  count(terms) > 0
+  restricting := [t | t := terms[_]; count(term_values(t)) > 0]
+  count(restricting) == count(terms)
+  values := {v | v := term_values(terms[_])[_]}
+  count(values) == 1
+  os := values[_]
+}
+
+term_values(term) = values {
+  values := {lower(v) |
+    e := term.matchExpressions[_]
+    e.key == os_labels[_]
+    e.operator == "In"
+    v := e.values[_]
+  }
+}
+
+tolerates_windows(spec) {
+  t := spec.tolerations[_]
+  windows_taint_keys[t.key]
+  lower(t.value) == "windows"
+}
@@ -0,0 +1,57 @@
+package lib.os
+
+test_no_indication_is_linux {
+  name(pod({})) == "linux"
+  linux(pod({}))
+  not windows(pod({}))
+}
+test_os_name {
+  name(pod({"os": {"name": "windows"}})) == "windows"
+}
+test_os_name_wins {
+  name(pod({"os": {"name": "linux"}, "nodeSelector": {"kubernetes.io/os": "windows"}})) == "linux"
+}
+test_node_selector {
+  name(pod({"nodeSelector": {"kubernetes.io/os": "windows"}})) == "windows"
+}
+test_beta_node_selector {
+  name(pod({"nodeSelector": {"beta.kubernetes.io/os": "Windows"}})) == "windows"
+}
+test_node_selector_wins {
+  name(pod({"nodeSelector": {"kubernetes.io/os": "linux"}, "tolerations": [windows_toleration]})) == "linux"
+}
+test_affinity {
+  name(pod({"affinity": affinity([term(["windows"])])})) == "windows"
+}
+test_affinity_every_term {
+  name(pod({"affinity": affinity([term(["windows"]), term(["windows"])])})) == "windows"
+}
+test_affinity_unrestricted_term {
+  name(pod({"affinity": affinity([term(["windows"]), {"matchExpressions": []}])})) == "linux"
+}
+test_affinity_several_os {
+  name(pod({"affinity": affinity([term(["windows", "linux"])])})) == "linux"
+}
+test_toleration {
+  name(pod({"tolerations": [windows_toleration]})) == "windows"
+}
+test_unrelated_toleration {
+  name(pod({"tolerations": [{"key": "dedicated", "value": "windows", "effect": "NoSchedule"}]})) == "linux"
+}
+test_deployment {
+  name({"kind": "Deployment", "spec": {"template": {"spec": {"os": {"name": "windows"}}}}}) == "windows"
+}
+test_cronjob {
+  windows({"kind": "CronJob", "spec": {"jobTemplate": {"spec": {"template": {"spec": {"nodeSelector": {"kubernetes.io/os": "windows"}}}}}}})
+}
+test_no_pod_spec {
+  not name({"kind": "ConfigMap", "data": {}})
+}
+
+pod(spec) = {"kind": "Pod", "spec": spec}
+
+windows_toleration = {"key": "os", "operator": "Equal", "value": "windows", "effect": "NoSchedule"}
+
+affinity(terms) = {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": terms}}}
+
+term(values) = {"matchExpressions": [{"key": "kubernetes.io/os", "operator": "In", "values": values}]}
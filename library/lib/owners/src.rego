@@ -0,0 +1,99 @@
+package lib.owners
+
+# Owners are looked up in the synced inventory, so the owning kinds must be
+# synced, e.g. apps/v1 ReplicaSet and Deployment to resolve a Pod's
+# Deployment, or batch/v1 Job and batch/v1beta1 CronJob for a Job's pods.
+# Owner chains are only followed up to this many levels.
+depths = [1, 2, 3, 4, 5]
+
+# controller_ref returns the ownerReference of the object's managing
+# controller. It is undefined for objects without one.
+controller_ref(obj) = ref {
+  ref := obj.metadata.ownerReferences[_]
+  ref.controller == true
+}
+
+# owner returns the object's controller from the inventory. It is undefined
+# if the object has no controller or the controller is not synced. An owner
+# whose uid doesn't match the reference has been replaced and is ignored.
+owner(obj) = o {
+  ref := controller_ref(obj)
+  o := lookup(obj, ref)
+  uid_matches(o, ref)
+}
+
+# chain returns the object's owners, starting with its controller and ending
+# with the top-level owner, e.g. [ReplicaSet, Deployment] for a Pod. It stops
+# at the first owner that is not synced.
+chain(obj) = owners {
+  owners := [o | d := depths[_]; o := ancestor(obj, d)]
+}
+
+# top_owner returns the last owner in the object's chain, or the object
+# itself if it has no synced owner.
+top_owner(obj) = o {
+  owners := chain(obj)
+  count(owners) > 0
+  o := owners[count(owners) - 1]
+}
+
+top_owner(obj) = obj {
+  count(chain(obj)) == 0
+}
+
+# missing_owner is true if the owner chain could not be followed to the top
+# because an owner is not synced or has been deleted.
+missing_owner(obj) {
+  top := top_owner(obj)
+  controller_ref(top)
+  not owner(top)
+}
+
+# owner_labels returns the labels of the top-level owner.
+owner_labels(obj) = labels {
+  labels := object.get(top_owner(obj).metadata, "labels", {})
+}
+
+# owner_annotations returns the annotations of the top-level owner.
+owner_annotations(obj) = annotations {
+  annotations := object.get(top_owner(obj).metadata, "annotations", {})
+}
+
+ancestor(obj, 1) = owner(obj)
+
+ancestor(obj, 2) = owner(owner(obj))
+
+ancestor(obj, 3) = owner(owner(owner(obj)))
+
+ancestor(obj, 4) = owner(owner(owner(owner(obj))))
+
+ancestor(obj, 5) = owner(owner(owner(owner(owner(obj)))))
+
+# Namespaced objects can be owned by namespaced or cluster scoped objects.
+lookup(obj, ref) = o {
+  o := data.inventory.namespace[obj.metadata.namespace][ref.apiVersion][ref.kind][ref.name]
+}
+
+lookup(obj, ref) = o {
+  ns := obj.metadata.namespace
+  not data.inventory.namespace[ns][ref.apiVersion][ref.kind][ref.name]
+  o := data.inventory.cluster[ref.apiVersion][ref.kind][ref.name]
+}
+
+# Cluster scoped objects can only be owned by cluster scoped objects.
+lookup(obj, ref) = o {
+  not obj.metadata.namespace
+  o := data.inventory.cluster[ref.apiVersion][ref.kind][ref.name]
+}
+
+uid_matches(o, ref) {
+  not ref.uid
+}
+
+uid_matches(o, ref) {
+  not o.metadata.uid
+}
+
+uid_matches(o, ref) {
+  o.metadata.uid == ref.uid
+}
@@ -0,0 +1,113 @@
+package lib.owners
+
+deployment = {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {
+  "name": "web", "namespace": "prod", "uid": "d1",
+  "labels": {"team": "payments"},
+  "annotations": {"owner": "payments@example.com"},
+}}
+
+replicaset = {"apiVersion": "apps/v1", "kind": "ReplicaSet", "metadata": {
+  "name": "web-abc", "namespace": "prod", "uid": "r1",
+  "ownerReferences": [{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web", "uid": "d1", "controller": true}],
+}}
+
+orphaned_replicaset = {"apiVersion": "apps/v1", "kind": "ReplicaSet", "metadata": {
+  "name": "old-abc", "namespace": "prod", "uid": "r2",
+  "ownerReferences": [{"apiVersion": "apps/v1", "kind": "Deployment", "name": "old", "uid": "d2", "controller": true}],
+}}
+
+node = {"apiVersion": "v1", "kind": "Node", "metadata": {"name": "node-a", "uid": "n1", "labels": {"pool": "gpu"}}}
+
+inventory = {
+  "namespace": {"prod": {"apps/v1": {
+    "Deployment": {"web": deployment},
+    "ReplicaSet": {"web-abc": replicaset, "old-abc": orphaned_replicaset},
+  }}},
+  "cluster": {"v1": {"Node": {"node-a": node}}},
+}
+
+pod(refs) = {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "p", "namespace": "prod", "ownerReferences": refs}}
+
+rs_ref = {"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-abc", "uid": "r1", "controller": true}
+
+test_controller_ref {
+  controller_ref(pod([{"apiVersion": "v1", "kind": "Foo", "name": "x"}, rs_ref])) == rs_ref
+}
+
+test_no_controller_ref {
+  not controller_ref(pod([{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-abc"}]))
+  not controller_ref({"metadata": {"name": "p"}})
+}
+
+test_owner {
+  owner(pod([rs_ref])) == replicaset with data.inventory as inventory
+}
+
+test_chain {
+  chain(pod([rs_ref])) == [replicaset, deployment] with data.inventory as inventory
+}
+
+test_top_owner {
+  top_owner(pod([rs_ref])) == deployment with data.inventory as inventory
+}
+
+test_top_owner_without_owner {
+  p := {"metadata": {"name": "p", "namespace": "prod"}}
+  top_owner(p) == p with data.inventory as inventory
+  chain(p) == [] with data.inventory as inventory
+}
+
+test_owner_labels {
+  owner_labels(pod([rs_ref])) == {"team": "payments"} with data.inventory as inventory
+}
+
+test_owner_annotations {
+  owner_annotations(pod([rs_ref]))["owner"] == "payments@example.com" with data.inventory as inventory
+}
+
+test_owner_without_labels {
+  owner_labels(pod([{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "old-abc", "controller": true}])) == {} with data.inventory as inventory
+}
+
+test_not_missing_owner {
+  not missing_owner(pod([rs_ref])) with data.inventory as inventory
+  not missing_owner({"metadata": {"name": "p"}}) with data.inventory as inventory
+}
+
+test_missing_owner {
+  p := pod([{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "old-abc", "controller": true}])
+  missing_owner(p) with data.inventory as inventory
+  top_owner(p) == orphaned_replicaset with data.inventory as inventory
+}
+
+test_owner_not_synced {
+  p := pod([{"apiVersion": "batch/v1", "kind": "Job", "name": "j", "controller": true}])
+  not owner(p) with data.inventory as inventory
+  top_owner(p) == p with data.inventory as inventory
+  missing_owner(p) with data.inventory as inventory
+}
+
+test_replaced_owner {
+  p := pod([{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-abc", "uid": "other", "controller": true}])
+  not owner(p) with data.inventory as inventory
+  missing_owner(p) with data.inventory as inventory
+}
+
+test_cluster_scoped_owner {
+  p := pod([{"apiVersion": "v1", "kind": "Node", "name": "node-a", "uid": "n1", "controller": true}])
+  owner_labels(p) == {"pool": "gpu"} with data.inventory as inventory
+}
+
+test_depth_limit {
+  chain_inventory := {"cluster": {"v1": {"Thing": {
+    "t1": {"metadata": {"name": "t1", "ownerReferences": [{"apiVersion": "v1", "kind": "Thing", "name": "t2", "controller": true}]}},
+    "t2": {"metadata": {"name": "t2", "ownerReferences": [{"apiVersion": "v1", "kind": "Thing", "name": "t3", "controller": true}]}},
+    "t3": {"metadata": {"name": "t3", "ownerReferences": [{"apiVersion": "v1", "kind": "Thing", "name": "t4", "controller": true}]}},
+    "t4": {"metadata": {"name": "t4", "ownerReferences": [{"apiVersion": "v1", "kind": "Thing", "name": "t5", "controller": true}]}},
+    "t5": {"metadata": {"name": "t5", "ownerReferences": [{"apiVersion": "v1", "kind": "Thing", "name": "t6", "controller": true}]}},
+    "t6": {"metadata": {"name": "t6"}},
+  }}}}
+  obj := {"metadata": {"name": "t0", "ownerReferences": [{"apiVersion": "v1", "kind": "Thing", "name": "t1", "controller": true}]}}
+  count(chain(obj)) == 5 with data.inventory as chain_inventory
+  top_owner(obj).metadata.name == "t5" with data.inventory as chain_inventory
+}
@@ -0,0 +1,158 @@
+package lib.pdb
+
+# PodDisruptionBudgets are looked up in the synced inventory, so
+# policy/v1beta1 PodDisruptionBudget must be synced.
+
+operators = {"In", "NotIn", "Exists", "DoesNotExist"}
+
+# requirements returns the requirements of a label selector, with each
+# matchLabels entry turned into an In requirement with a single value.
+requirements(selector) = reqs {
+  labels := [{"key": k, "operator": "In", "values": [v]} | v := object.get(selector, "matchLabels", {})[k]]
+  exprs := [r | e := object.get(selector, "matchExpressions", [])[_]; r := {"key": e.key, "operator": e.operator, "values": object.get(e, "values", [])}]
+  reqs := array.concat(labels, exprs)
+}
+
+# valid is true if the selector is an object whose requirements all have a
+# known operator, and values for In and NotIn.
+valid(selector) {
+  is_object(selector)
+  count([r | r := requirements(selector)[_]; not valid_requirement(r)]) == 0
+}
+
+valid_requirement(r) {
+  operators[r.operator]
+  needs_values(r.operator)
+  count(r.values) > 0
+}
+
+valid_requirement(r) {
+  operators[r.operator]
+  not needs_values(r.operator)
+}
+
+needs_values("In")
+
+needs_values("NotIn")
+
+# matches is true if the labels satisfy the selector. An empty selector
+# matches any labels.
+matches(selector, labels) {
+  valid(selector)
+  count([r | r := requirements(selector)[_]; not requirement_matches(r, labels)]) == 0
+}
+
+requirement_matches(r, labels) {
+  r.operator == "In"
+  labels[r.key] == r.values[_]
+}
+
+requirement_matches(r, labels) {
+  r.operator == "NotIn"
+  not in_values(r, labels)
+}
+
+requirement_matches(r, labels) {
+  r.operator == "Exists"
+  has_key(labels, r.key)
+}
+
+requirement_matches(r, labels) {
+  r.operator == "DoesNotExist"
+  not has_key(labels, r.key)
+}
+
+in_values(r, labels) {
+  labels[r.key] == r.values[_]
+}
+
+has_key(labels, key) {
+  _ = labels[key]
+}
+
+# intersects is true if some set of labels satisfies both selectors, i.e.
+# they can select the same pod. Requirements on different keys are
+# independent, so this holds if the requirements of both selectors on each
+# key can be satisfied together.
+intersects(a, b) {
+  valid(a)
+  valid(b)
+  reqs := array.concat(requirements(a), requirements(b))
+  count({k | k := reqs[_].key; not satisfiable(reqs, k)}) == 0
+}
+
+# Leaving the key out satisfies NotIn and DoesNotExist.
+satisfiable(reqs, key) {
+  count([r | r := reqs[_]; r.key == key; requires_key(r.operator)]) == 0
+}
+
+# Otherwise the key must be allowed and take a value allowed by every In and
+# no NotIn. Without an In, a value no NotIn lists can always be picked.
+satisfiable(reqs, key) {
+  count([r | r := reqs[_]; r.key == key; r.operator == "DoesNotExist"]) == 0
+  count([r | r := reqs[_]; r.key == key; r.operator == "In"]) == 0
+}
+
+satisfiable(reqs, key) {
+  count([r | r := reqs[_]; r.key == key; r.operator == "DoesNotExist"]) == 0
+  count(allowed_values(reqs, key)) > 0
+}
+
+requires_key("In")
+
+requires_key("Exists")
+
+allowed_values(reqs, key) = values {
+  in_sets := {s | r := reqs[_]; r.key == key; r.operator == "In"; s := {v | v := r.values[_]}}
+  count(in_sets) > 0
+  excluded := {v | r := reqs[_]; r.key == key; r.operator == "NotIn"; v := r.values[_]}
+  values := intersection(in_sets) - excluded
+}
+
+# selector returns the selector of a PodDisruptionBudget. It is undefined if
+# the budget selects no pods: without a selector, or with an empty one in
+# policy/v1beta1. An empty selector selects every pod in the namespace from
+# policy/v1 on.
+selector(budget) = s {
+  s := budget.spec.selector
+  is_object(s)
+  not empty_v1beta1(budget, s)
+}
+
+empty_v1beta1(budget, s) {
+  budget.apiVersion == "policy/v1beta1"
+  count(requirements(s)) == 0
+}
+
+# selects is true if the budget selects pods with the labels.
+selects(budget, labels) {
+  matches(selector(budget), labels)
+}
+
+# overlaps is true if two budgets in the same namespace can select the same
+# pod. The eviction API refuses to evict a pod covered by more than one
+# budget.
+overlaps(a, b) {
+  a.metadata.namespace == b.metadata.namespace
+  intersects(selector(a), selector(b))
+}
+
+# pod_labels returns the labels of the pods a workload creates, from its pod
+# template. It is the object's own labels for a Pod.
+pod_labels(obj) = labels {
+  obj.kind == "Pod"
+  labels := object.get(obj.metadata, "labels", {})
+}
+
+pod_labels(obj) = labels {
+  obj.kind != "Pod"
+  labels := obj.spec.template.metadata.labels
+}
+
+# matching returns the names of the synced budgets in the namespace that
+# select the pods of the workload. It is empty if there are none, and
+# undefined if the workload has no pod template.
+matching(obj, namespace) = names {
+  labels := pod_labels(obj)
+  names := {name | budget := data.inventory.namespace[namespace][_].PodDisruptionBudget[name]; selects(budget, labels)}
+}
@@ -0,0 +1,105 @@
+package lib.pdb
+
+budget(name, selector) = {"apiVersion": "policy/v1beta1", "kind": "PodDisruptionBudget", "metadata": {"name": name, "namespace": "prod"}, "spec": {"minAvailable": 1, "selector": selector}}
+
+deployment(labels) = {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "prod"}, "spec": {
+  "replicas": 3,
+  "selector": {"matchLabels": {"app": "web"}},
+  "template": {"metadata": {"labels": labels}},
+}}
+
+expr(key, op, values) = {"key": key, "operator": op, "values": values}
+
+inventory = {"namespace": {
+  "prod": {"policy/v1beta1": {"PodDisruptionBudget": {
+    "web": budget("web", {"matchLabels": {"app": "web"}}),
+    "canary": budget("canary", {"matchExpressions": [expr("app", "In", ["web", "api"]), expr("track", "NotIn", ["stable"])]}),
+    "db": budget("db", {"matchLabels": {"app": "db"}}),
+  }}},
+  "dev": {"policy/v1beta1": {"PodDisruptionBudget": {"web": budget("web", {"matchLabels": {"app": "web"}})}}},
+}}
+
+test_requirements {
+  requirements({"matchLabels": {"app": "web"}, "matchExpressions": [{"key": "tier", "operator": "Exists"}]}) == [
+    {"key": "app", "operator": "In", "values": ["web"]},
+    {"key": "tier", "operator": "Exists", "values": []},
+  ]
+}
+
+test_matches_match_labels {
+  matches({"matchLabels": {"app": "web"}}, {"app": "web", "tier": "frontend"})
+  not matches({"matchLabels": {"app": "web"}}, {"app": "db"})
+  not matches({"matchLabels": {"app": "web"}}, {})
+}
+
+test_matches_expressions {
+  labels := {"app": "web", "track": "canary"}
+  matches({"matchExpressions": [expr("app", "In", ["web", "api"])]}, labels)
+  matches({"matchExpressions": [expr("track", "NotIn", ["stable"])]}, labels)
+  matches({"matchExpressions": [expr("missing", "NotIn", ["x"])]}, labels)
+  matches({"matchExpressions": [{"key": "app", "operator": "Exists"}]}, labels)
+  matches({"matchExpressions": [{"key": "missing", "operator": "DoesNotExist"}]}, labels)
+  not matches({"matchExpressions": [expr("app", "NotIn", ["web"])]}, labels)
+  not matches({"matchExpressions": [{"key": "app", "operator": "DoesNotExist"}]}, labels)
+  not matches({"matchLabels": {"app": "web"}, "matchExpressions": [expr("track", "In", ["stable"])]}, labels)
+}
+
+test_matches_empty_selector {
+  matches({}, {"app": "web"})
+}
+
+test_invalid_selector_matches_nothing {
+  not matches({"matchExpressions": [expr("app", "Equals", ["web"])]}, {"app": "web"})
+  not matches({"matchExpressions": [expr("app", "NotIn", [])]}, {"app": "web"})
+  not matches(null, {"app": "web"})
+}
+
+test_intersects {
+  intersects({"matchLabels": {"app": "web"}}, {"matchLabels": {"app": "web", "tier": "frontend"}})
+  intersects({"matchLabels": {"app": "web"}}, {"matchLabels": {"tier": "frontend"}})
+  intersects({"matchLabels": {"app": "web"}}, {"matchExpressions": [expr("app", "In", ["api", "web"])]})
+  intersects({"matchExpressions": [expr("app", "In", ["a", "b"])]}, {"matchExpressions": [expr("app", "NotIn", ["a"])]})
+  intersects({"matchExpressions": [expr("app", "NotIn", ["a"])]}, {"matchExpressions": [{"key": "app", "operator": "DoesNotExist"}]})
+  intersects({"matchExpressions": [{"key": "app", "operator": "Exists"}]}, {"matchExpressions": [expr("app", "NotIn", ["a"])]})
+  intersects({}, {"matchLabels": {"app": "web"}})
+}
+
+test_does_not_intersect {
+  not intersects({"matchLabels": {"app": "web"}}, {"matchLabels": {"app": "db"}})
+  not intersects({"matchLabels": {"app": "web"}}, {"matchExpressions": [expr("app", "NotIn", ["web"])]})
+  not intersects({"matchExpressions": [expr("app", "In", ["a", "b"])]}, {"matchExpressions": [expr("app", "NotIn", ["a", "b"])]})
+  not intersects({"matchLabels": {"app": "web"}}, {"matchExpressions": [{"key": "app", "operator": "DoesNotExist"}]})
+  not intersects({"matchExpressions": [{"key": "app", "operator": "Exists"}]}, {"matchExpressions": [{"key": "app", "operator": "DoesNotExist"}]})
+  not intersects({"matchExpressions": [expr("app", "In", ["a"]), expr("app", "In", ["b"])]}, {})
+}
+
+test_selector_v1beta1_empty_selects_nothing {
+  not selector(budget("all", {}))
+  not selector({"apiVersion": "policy/v1beta1", "spec": {}})
+  selector({"apiVersion": "policy/v1", "spec": {"selector": {}}}) == {}
+  not selects(budget("all", {}), {"app": "web"})
+}
+
+test_overlaps {
+  overlaps(budget("a", {"matchLabels": {"app": "web"}}), budget("b", {"matchExpressions": [expr("app", "In", ["web", "api"])]}))
+  not overlaps(budget("a", {"matchLabels": {"app": "web"}}), budget("b", {"matchLabels": {"app": "db"}}))
+}
+
+test_pod_labels {
+  pod_labels(deployment({"app": "web"})) == {"app": "web"}
+  pod_labels({"kind": "Pod", "metadata": {"labels": {"app": "web"}}}) == {"app": "web"}
+  pod_labels({"kind": "Pod", "metadata": {}}) == {}
+  not pod_labels({"kind": "Service", "spec": {}})
+}
+
+test_matching {
+  matching(deployment({"app": "web", "track": "canary"}), "prod") == {"web", "canary"} with data.inventory as inventory
+  matching(deployment({"app": "web", "track": "stable"}), "prod") == {"web"} with data.inventory as inventory
+  matching(deployment({"app": "web"}), "dev") == {"web"} with data.inventory as inventory
+}
+
+test_no_matching {
+  matching(deployment({"app": "cache"}), "prod") == set() with data.inventory as inventory
+  matching(deployment({"app": "web"}), "staging") == set() with data.inventory as inventory
+  matching(deployment({"app": "web"}), "prod") == set() with data.inventory as {}
+}
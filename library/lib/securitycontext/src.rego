@@ -0,0 +1,105 @@
+package lib.securitycontext
+
+# security_context returns the effective security context of a container in
+# a pod spec, with the pod's security context applied and Kubernetes
+# defaults filled in:
+#   {
+#     "privileged": false,
+#     "allowPrivilegeEscalation": true,
+#     "readOnlyRootFilesystem": false,
+#     "procMount": "Default",
+#     "runAsUser": null,
+#     "runAsGroup": null,
+#     "runAsNonRoot": false,
+#     "seLinuxOptions": null,
+#     "seccompProfile": null,
+#     "capabilities": {"add": set(), "drop": set()},
+#   }
+# Fields that can be set on both the pod and the container take the
+# container's value if it is set. null means unset, in which case the
+# container image or runtime decides. Capability names are upper case
+# without the CAP_ prefix.
+security_context(spec, container) = sc {
+  pod := object.get(spec, "securityContext", {})
+  c := object.get(container, "securityContext", {})
+  capabilities := object.get(c, "capabilities", {})
+  sc := {
+    "privileged": object.get(c, "privileged", false),
+    "allowPrivilegeEscalation": allow_privilege_escalation(c),
+    "readOnlyRootFilesystem": object.get(c, "readOnlyRootFilesystem", false),
+    "procMount": object.get(c, "procMount", "Default"),
+    "runAsUser": inherited(c, pod, "runAsUser", null),
+    "runAsGroup": inherited(c, pod, "runAsGroup", null),
+    "runAsNonRoot": inherited(c, pod, "runAsNonRoot", false),
+    "seLinuxOptions": inherited(c, pod, "seLinuxOptions", null),
+    "seccompProfile": inherited(c, pod, "seccompProfile", null),
+    "capabilities": {
+      "add": normalize_capabilities(object.get(capabilities, "add", [])),
+      "drop": normalize_capabilities(object.get(capabilities, "drop", [])),
+    },
+  }
+}
+
+# may_run_as_root is true unless the effective security context ensures the
+# container runs as a non-root user. A container without runAsUser or
+# runAsNonRoot may run as root, depending on its image.
+may_run_as_root(spec, container) {
+  sc := security_context(spec, container)
+  sc.runAsUser == 0
+}
+
+may_run_as_root(spec, container) {
+  sc := security_context(spec, container)
+  sc.runAsUser == null
+  sc.runAsNonRoot == false
+}
+
+# adds_capability is true if the container adds cap, directly or with ALL.
+adds_capability(spec, container, cap) {
+  added := security_context(spec, container).capabilities.add
+  added[normalize_capability(cap)]
+}
+
+adds_capability(spec, container, cap) {
+  security_context(spec, container).capabilities.add.ALL
+}
+
+# drops_capability is true if the container drops cap, directly or with ALL.
+drops_capability(spec, container, cap) {
+  dropped := security_context(spec, container).capabilities.drop
+  dropped[normalize_capability(cap)]
+}
+
+drops_capability(spec, container, cap) {
+  security_context(spec, container).capabilities.drop.ALL
+}
+
+# Host namespaces are shared by every container in the pod.
+host_network(spec) = object.get(spec, "hostNetwork", false)
+
+host_pid(spec) = object.get(spec, "hostPID", false)
+
+host_ipc(spec) = object.get(spec, "hostIPC", false)
+
+inherited(c, pod, key, fallback) = object.get(c, key, object.get(pod, key, fallback))
+
+# Privilege escalation is always allowed for privileged containers and
+# containers with CAP_SYS_ADMIN, whatever allowPrivilegeEscalation says.
+allow_privilege_escalation(c) = true {
+  c.privileged == true
+}
+
+allow_privilege_escalation(c) = true {
+  not c.privileged == true
+  normalize_capabilities(object.get(object.get(c, "capabilities", {}), "add", []))["SYS_ADMIN"]
+}
+
+allow_privilege_escalation(c) = allowed {
+  not c.privileged == true
+  not normalize_capabilities(object.get(object.get(c, "capabilities", {}), "add", []))["SYS_ADMIN"]
+  allowed := object.get(c, "allowPrivilegeEscalation", true)
+}
+
+normalize_capabilities(caps) = {normalize_capability(cap) | cap := caps[_]}
+
+normalize_capability(cap) = trim_prefix(upper(cap), "CAP_")
@@ -0,0 +1,92 @@
+package lib.securitycontext
+
+test_defaults {
+  security_context({}, {"name": "app"}) == {
+    "privileged": false,
+    "allowPrivilegeEscalation": true,
+    "readOnlyRootFilesystem": false,
+    "procMount": "Default",
+    "runAsUser": null,
+    "runAsGroup": null,
+    "runAsNonRoot": false,
+    "seLinuxOptions": null,
+    "seccompProfile": null,
+    "capabilities": {"add": set(), "drop": set()},
+  }
+}
+
+test_inherits_pod_user {
+  sc := security_context({"securityContext": {"runAsUser": 1000, "runAsGroup": 3000}}, {})
+  sc.runAsUser == 1000
+  sc.runAsGroup == 3000
+}
+
+test_container_overrides_pod {
+  spec := {"securityContext": {"runAsUser": 1000, "runAsNonRoot": true, "seLinuxOptions": {"level": "s0:c1"}}}
+  sc := security_context(spec, {"securityContext": {"runAsUser": 0, "runAsNonRoot": false, "seLinuxOptions": {"level": "s0:c2"}}})
+  sc.runAsUser == 0
+  sc.runAsNonRoot == false
+  sc.seLinuxOptions == {"level": "s0:c2"}
+}
+
+test_container_only_fields_not_inherited {
+  sc := security_context({"securityContext": {"privileged": true, "readOnlyRootFilesystem": true}}, {})
+  sc.privileged == false
+  sc.readOnlyRootFilesystem == false
+}
+
+test_privileged_allows_escalation {
+  security_context({}, {"securityContext": {"privileged": true, "allowPrivilegeEscalation": false}}).allowPrivilegeEscalation == true
+}
+
+test_sys_admin_allows_escalation {
+  security_context({}, {"securityContext": {"allowPrivilegeEscalation": false, "capabilities": {"add": ["CAP_SYS_ADMIN"]}}}).allowPrivilegeEscalation == true
+}
+
+test_escalation_disabled {
+  security_context({}, {"securityContext": {"allowPrivilegeEscalation": false}}).allowPrivilegeEscalation == false
+}
+
+test_capabilities_normalized {
+  sc := security_context({}, {"securityContext": {"capabilities": {"add": ["cap_net_admin", "SYS_TIME"], "drop": ["all"]}}})
+  sc.capabilities == {"add": {"NET_ADMIN", "SYS_TIME"}, "drop": {"ALL"}}
+}
+
+test_adds_capability {
+  c := {"securityContext": {"capabilities": {"add": ["NET_ADMIN"]}}}
+  adds_capability({}, c, "CAP_NET_ADMIN")
+  not adds_capability({}, c, "SYS_ADMIN")
+}
+
+test_adds_all_capabilities {
+  adds_capability({}, {"securityContext": {"capabilities": {"add": ["ALL"]}}}, "SYS_ADMIN")
+}
+
+test_drops_capability {
+  c := {"securityContext": {"capabilities": {"drop": ["ALL"]}}}
+  drops_capability({}, c, "NET_RAW")
+  not drops_capability({}, {}, "NET_RAW")
+}
+
+test_may_run_as_root_unset {
+  may_run_as_root({}, {})
+}
+
+test_may_run_as_root_uid_zero {
+  may_run_as_root({"securityContext": {"runAsNonRoot": true}}, {"securityContext": {"runAsUser": 0}})
+}
+
+test_not_root_with_run_as_non_root {
+  not may_run_as_root({"securityContext": {"runAsNonRoot": true}}, {})
+}
+
+test_not_root_with_user {
+  not may_run_as_root({"securityContext": {"runAsUser": 1000}}, {})
+}
+
+test_host_namespaces {
+  spec := {"hostNetwork": true, "hostPID": false}
+  host_network(spec) == true
+  host_pid(spec) == false
+  host_ipc(spec) == false
+}
@@ -0,0 +1,95 @@
+package lib.topology
+
+# Node labels holding a node's zone and region, current label first.
+zone_labels = ["topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"]
+
+region_labels = ["topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"]
+
+# scheduled is true if the pod has been bound to a node. Pods are usually
+# admitted before they are scheduled, so this is false for most admission
+# requests and true for most audited pods.
+scheduled(pod) {
+  pod.spec.nodeName != ""
+}
+
+# node returns the Node the pod is scheduled on. It is undefined if the pod
+# is not scheduled or Nodes are not synced.
+node(pod) = n {
+  scheduled(pod)
+  n := data.inventory.cluster["v1"].Node[pod.spec.nodeName]
+}
+
+# node_labels returns the labels of the Node the pod is scheduled on.
+node_labels(pod) = labels {
+  labels := object.get(node(pod).metadata, "labels", {})
+}
+
+# zones returns the zones the pod runs in, or can run in:
+# - for a scheduled pod, the zone of its node
+# - otherwise, the zones the pod is restricted to by its nodeSelector or
+#   required node affinity, which is empty if the pod isn't restricted
+# It is undefined for a pod scheduled on a Node that is not synced.
+zones(pod) = label_values(pod, zone_labels)
+
+# regions returns the regions the pod runs in, or can run in. See zones.
+regions(pod) = label_values(pod, region_labels)
+
+label_values(pod, keys) = values {
+  labels := node_labels(pod)
+  values := {v | v := labels[keys[_]]}
+}
+
+label_values(pod, keys) = values {
+  not scheduled(pod)
+  values := selected_values(pod, keys)
+}
+
+# A nodeSelector is ANDed with node affinity, so it alone decides.
+selected_values(pod, keys) = values {
+  values := {v | v := pod.spec.nodeSelector[keys[_]]}
+  count(values) > 0
+}
+
+# Node selector terms are ORed, so the pod is only restricted if every term
+# restricts it.
+selected_values(pod, keys) = values {
+  no_node_selector(pod, keys)
+  terms := required_terms(pod)
+  count(terms) > 0
+  restricting := [t | t := terms[_]; count(term_values(t, keys)) > 0]
+  count(restricting) == count(terms)
+  values := {v | v := term_values(terms[_], keys)[_]}
+}
+
+selected_values(pod, keys) = set() {
+  no_node_selector(pod, keys)
+  terms := required_terms(pod)
+  restricting := [t | t := terms[_]; count(term_values(t, keys)) > 0]
+  count(restricting) < count(terms)
+}
+
+selected_values(pod, keys) = set() {
+  no_node_selector(pod, keys)
+  count(required_terms(pod)) == 0
+}
+
+no_node_selector(pod, keys) {
+  count({v | v := pod.spec.nodeSelector[keys[_]]}) == 0
+}
+
+required_terms(pod) = terms {
+  terms := pod.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms
+}
+
+required_terms(pod) = [] {
+  not pod.spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms
+}
+
+term_values(term, keys) = values {
+  values := {v |
+    expr := term.matchExpressions[_]
+    expr.key == keys[_]
+    expr.operator == "In"
+    v := expr.values[_]
+  }
+}
@@ -0,0 +1,93 @@
+package lib.topology
+
+inventory = {"cluster": {"v1": {"Node": {
+  "node-a": {"metadata": {"name": "node-a", "labels": {
+    "topology.kubernetes.io/zone": "us-east1-b",
+    "topology.kubernetes.io/region": "us-east1",
+  }}},
+  "node-legacy": {"metadata": {"name": "node-legacy", "labels": {
+    "failure-domain.beta.kubernetes.io/zone": "eu-west1-a",
+  }}},
+  "node-bare": {"metadata": {"name": "node-bare"}},
+}}}}
+
+test_scheduled {
+  scheduled({"spec": {"nodeName": "node-a"}})
+}
+
+test_not_scheduled {
+  not scheduled({"spec": {}})
+  not scheduled({"spec": {"nodeName": ""}})
+}
+
+test_node_labels {
+  labels := node_labels({"spec": {"nodeName": "node-a"}}) with data.inventory as inventory
+  labels["topology.kubernetes.io/region"] == "us-east1"
+}
+
+test_node_without_labels {
+  node_labels({"spec": {"nodeName": "node-bare"}}) == {} with data.inventory as inventory
+}
+
+test_scheduled_zone {
+  zones({"spec": {"nodeName": "node-a"}}) == {"us-east1-b"} with data.inventory as inventory
+}
+
+test_scheduled_region {
+  regions({"spec": {"nodeName": "node-a"}}) == {"us-east1"} with data.inventory as inventory
+}
+
+test_legacy_zone_label {
+  zones({"spec": {"nodeName": "node-legacy"}}) == {"eu-west1-a"} with data.inventory as inventory
+}
+
+test_node_not_synced {
+  not zones({"spec": {"nodeName": "node-missing"}}) with data.inventory as inventory
+}
+
+test_unscheduled_unrestricted {
+  zones({"spec": {}}) == set() with data.inventory as inventory
+}
+
+test_unscheduled_node_selector {
+  pod := {"spec": {"nodeSelector": {"topology.kubernetes.io/zone": "us-east1-c"}}}
+  zones(pod) == {"us-east1-c"} with data.inventory as inventory
+}
+
+test_unscheduled_affinity {
+  pod := {"spec": {"affinity": {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [
+    {"matchExpressions": [{"key": "topology.kubernetes.io/zone", "operator": "In", "values": ["a", "b"]}]},
+    {"matchExpressions": [{"key": "failure-domain.beta.kubernetes.io/zone", "operator": "In", "values": ["c"]}]},
+  ]}}}}}
+  zones(pod) == {"a", "b", "c"} with data.inventory as inventory
+}
+
+test_unscheduled_affinity_with_unrestricted_term {
+  pod := {"spec": {"affinity": {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [
+    {"matchExpressions": [{"key": "topology.kubernetes.io/zone", "operator": "In", "values": ["a"]}]},
+    {"matchExpressions": [{"key": "disktype", "operator": "In", "values": ["ssd"]}]},
+  ]}}}}}
+  zones(pod) == set() with data.inventory as inventory
+}
+
+test_unscheduled_affinity_not_in {
+  pod := {"spec": {"affinity": {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [
+    {"matchExpressions": [{"key": "topology.kubernetes.io/zone", "operator": "NotIn", "values": ["a"]}]},
+  ]}}}}}
+  zones(pod) == set() with data.inventory as inventory
+}
+
+test_node_selector_wins_over_affinity {
+  pod := {"spec": {
+    "nodeSelector": {"topology.kubernetes.io/zone": "a"},
+    "affinity": {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [
+      {"matchExpressions": [{"key": "topology.kubernetes.io/zone", "operator": "In", "values": ["a", "b"]}]},
+    ]}}},
+  }}
+  zones(pod) == {"a"} with data.inventory as inventory
+}
+
+test_scheduled_ignores_selector {
+  pod := {"spec": {"nodeName": "node-a", "nodeSelector": {"topology.kubernetes.io/zone": "other"}}}
+  zones(pod) == {"us-east1-b"} with data.inventory as inventory
+}
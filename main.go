@@ -30,7 +30,9 @@ import (
 	"github.com/open-policy-agent/gatekeeper/pkg/controller"
 	configController "github.com/open-policy-agent/gatekeeper/pkg/controller/config"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller/constrainttemplate"
+	"github.com/open-policy-agent/gatekeeper/pkg/debug"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/statusreaper"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	"github.com/open-policy-agent/gatekeeper/pkg/upgrade"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
@@ -194,12 +196,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	setupLog.Info("setting up status reaper")
+	if err := statusreaper.AddToManager(mgr); err != nil {
+		setupLog.Error(err, "unable to register status reaper to the manager")
+		os.Exit(1)
+	}
+
 	setupLog.Info("setting up metrics")
 	if err := metrics.AddToManager(mgr); err != nil {
 		setupLog.Error(err, "unable to register metrics to the manager")
 		os.Exit(1)
 	}
 
+	setupLog.Info("setting up debug server")
+	if err := debug.AddToManager(mgr, dc, wm, driver, client); err != nil {
+		setupLog.Error(err, "unable to register debug server to the manager")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddReadyzCheck("default", healthz.Ping); err != nil {
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"flag"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var auditHistorySize = flag.Uint("audit-history-size", 0, "number of audit cycles whose violation and finding counts are kept in a constraint's status.auditHistory, newest first. defaulted to 0, which keeps no history, if unspecified ")
+
+// StatusAuditSummary summarizes the results of one audit cycle for a
+// constraint under status.auditHistory
+type StatusAuditSummary struct {
+	AuditTimestamp  string `json:"auditTimestamp"`
+	TotalViolations int64  `json:"totalViolations"`
+	TotalFindings   int64  `json:"totalFindings"`
+}
+
+// recordHistory prepends the summary of the current cycle to the constraint's
+// audit history and drops the summaries beyond size. A cycle that is already
+// recorded, because its status write is retried, is not added twice.
+func recordHistory(instance *unstructured.Unstructured, summary StatusAuditSummary, size uint) error {
+	if size == 0 {
+		unstructured.RemoveNestedField(instance.Object, "status", "auditHistory")
+		return nil
+	}
+	old, _, err := unstructured.NestedSlice(instance.Object, "status", "auditHistory")
+	if err != nil {
+		return err
+	}
+	history := []interface{}{map[string]interface{}{
+		"auditTimestamp":  summary.AuditTimestamp,
+		"totalViolations": summary.TotalViolations,
+		"totalFindings":   summary.TotalFindings,
+	}}
+	for _, h := range old {
+		if uint(len(history)) >= size {
+			break
+		}
+		if m, ok := h.(map[string]interface{}); ok && m["auditTimestamp"] == summary.AuditTimestamp {
+			continue
+		}
+		history = append(history, h)
+	}
+	return unstructured.SetNestedSlice(instance.Object, history, "status", "auditHistory")
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRecordHistory(t *testing.T) {
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	timestamps := func() []string {
+		history, _, _ := unstructured.NestedSlice(instance.Object, "status", "auditHistory")
+		var ts []string
+		for _, h := range history {
+			ts = append(ts, h.(map[string]interface{})["auditTimestamp"].(string))
+		}
+		return ts
+	}
+	for i := 1; i <= 4; i++ {
+		summary := StatusAuditSummary{AuditTimestamp: fmt.Sprintf("t%d", i), TotalViolations: int64(i)}
+		if err := recordHistory(instance, summary, 3); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := fmt.Sprint(timestamps()); got != "[t4 t3 t2]" {
+		t.Errorf("got history %v, want [t4 t3 t2]", got)
+	}
+
+	// a retried write of the same cycle replaces its summary
+	if err := recordHistory(instance, StatusAuditSummary{AuditTimestamp: "t4", TotalViolations: 5}, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fmt.Sprint(timestamps()); got != "[t4 t3 t2]" {
+		t.Errorf("got history %v, want [t4 t3 t2]", got)
+	}
+
+	if err := recordHistory(instance, StatusAuditSummary{AuditTimestamp: "t5"}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(instance.Object, "status", "auditHistory"); found {
+		t.Error("expected the history to be removed")
+	}
+}
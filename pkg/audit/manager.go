@@ -4,18 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	"github.com/open-policy-agent/gatekeeper/pkg/detailsschema"
+	"github.com/open-policy-agent/gatekeeper/pkg/export"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"github.com/open-policy-agent/gatekeeper/pkg/invalidparams"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/nsenforcement"
+	"github.com/open-policy-agent/gatekeeper/pkg/objdiff"
+	"github.com/open-policy-agent/gatekeeper/pkg/policyset"
+	"github.com/open-policy-agent/gatekeeper/pkg/prune"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -41,7 +55,9 @@ var (
 	auditInterval             = flag.Uint("audit-interval", defaultAuditInterval, "interval to run audit in seconds. defaulted to 60 secs if unspecified, 0 to disable ")
 	constraintViolationsLimit = flag.Uint("constraint-violations-limit", defaultConstraintViolationsLimit, "limit of number of violations per constraint. defaulted to 20 violations if unspecified ")
 	auditFromCache            = flag.Bool("audit-from-cache", false, "pull resources from OPA cache when auditing")
-	emptyAuditResults         []auditResult
+	statusUpdateRetries       = flag.Int("audit-status-update-retries", 5, "number of attempts to write audit results to a constraint's status before giving up for the current audit cycle. defaulted to 5 if unspecified ")
+	statusUpdateBackoff       = flag.Duration("audit-status-update-backoff", 1*time.Second, "delay before retrying a failed constraint status write, doubled after each retry. defaulted to 1s if unspecified ")
+	auditConcurrency          = flag.Int("audit-concurrency", 1, "number of objects evaluated in parallel when auditing via the discovery client. defaulted to 1 if unspecified ")
 )
 
 // Manager allows us to audit resources periodically
@@ -55,6 +71,10 @@ type Manager struct {
 	ucloop   *updateConstraintLoop
 	reporter *reporter
 	log      logr.Logger
+	// sampler thins out the processing messages of the current audit
+	sampler *logSampler
+	// exporter is nil when audit results are not exported
+	exporter export.Reporter
 }
 
 type auditResult struct {
@@ -62,12 +82,23 @@ type auditResult struct {
 	cnamespace        string
 	cgvk              schema.GroupVersionKind
 	capiversion       string
+	rapiversion       string
 	rkind             string
 	rname             string
 	rnamespace        string
 	message           string
 	enforcementAction string
 	constraint        *unstructured.Unstructured
+	// finding is set for informational findings, which are not violations
+	finding bool
+}
+
+// StatusFinding represents each informational finding under status
+type StatusFinding struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Message   string `json:"message"`
 }
 
 // StatusViolation represents each violation under status
@@ -81,6 +112,7 @@ type StatusViolation struct {
 
 // nsCache is used for caching namespaces and their labels
 type nsCache struct {
+	mux   sync.RWMutex
 	cache map[string]corev1.Namespace
 }
 
@@ -91,14 +123,19 @@ func newNSCache() *nsCache {
 }
 
 func (c *nsCache) Get(ctx context.Context, client client.Client, namespace string) (corev1.Namespace, error) {
-	if ns, ok := c.cache[namespace]; !ok {
-		if err := client.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
-			return corev1.Namespace{}, err
-		}
-		c.cache[namespace] = ns
-	}
-
-	return c.cache[namespace], nil
+	c.mux.RLock()
+	ns, ok := c.cache[namespace]
+	c.mux.RUnlock()
+	if ok {
+		return ns, nil
+	}
+	if err := client.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return corev1.Namespace{}, err
+	}
+	c.mux.Lock()
+	c.cache[namespace] = ns
+	c.mux.Unlock()
+	return ns, nil
 }
 
 // New creates a new manager for audit
@@ -108,6 +145,10 @@ func New(ctx context.Context, mgr manager.Manager, opa *opa.Client) (*Manager, e
 		log.Error(err, "StatsReporter could not start")
 		return nil, err
 	}
+	exporter, err := export.New()
+	if err != nil {
+		return nil, err
+	}
 
 	am := &Manager{
 		opa:      opa,
@@ -116,6 +157,7 @@ func New(ctx context.Context, mgr manager.Manager, opa *opa.Client) (*Manager, e
 		mgr:      mgr,
 		ctx:      ctx,
 		reporter: reporter,
+		exporter: exporter,
 	}
 	return am, nil
 }
@@ -125,6 +167,7 @@ func (am *Manager) audit(ctx context.Context) error {
 	startTime := time.Now()
 	timestamp := startTime.UTC().Format(time.RFC3339)
 	am.log = log.WithValues(logging.AuditID, timestamp)
+	am.sampler = newLogSampler(*auditLogSampleRate)
 	logStart(am.log)
 	// record audit latency
 	defer func() {
@@ -171,10 +214,23 @@ func (am *Manager) audit(ctx context.Context) error {
 		am.log.Info("Audit discovery client results", "violations", len(res))
 	}
 
-	updateLists, totalViolationsPerConstraint, totalViolationsPerEnforcementAction, err := am.getUpdateListsFromAuditResponses(res)
+	sortResults(res)
+	res = invalidparams.Filter(res)
+	res = objdiff.Templates.FilterAudit(res)
+	cfg := am.config(ctx)
+	res = policyset.ActiveSets(cfg).Filter(res)
+	detailsschema.Templates.Sanitize(res, am.log)
+	res = am.resolveActions(ctx, cfg, res)
+
+	updateLists, totalViolationsPerConstraint, totalFindingsPerConstraint, totalViolationsPerEnforcementAction, err := am.getUpdateListsFromAuditResponses(res)
 	if err != nil {
 		return err
 	}
+	if *auditObjectViolations {
+		if err := am.writeObjectViolations(ctx, res, timestamp); err != nil {
+			am.log.Error(err, "failed to write object violations")
+		}
+	}
 	for k, v := range totalViolationsPerEnforcementAction {
 		if err := am.reporter.reportTotalViolations(k, v); err != nil {
 			am.log.Error(err, "failed to report total violations")
@@ -188,7 +244,47 @@ func (am *Manager) audit(ctx context.Context) error {
 		return nil
 	}
 	// update constraints for each kind
-	return am.writeAuditResults(ctx, rs, updateLists, timestamp, totalViolationsPerConstraint)
+	if err := am.writeAuditResults(ctx, rs, updateLists, timestamp, totalViolationsPerConstraint, totalFindingsPerConstraint); err != nil {
+		return err
+	}
+	if am.exporter != nil {
+		if err := am.exporter.ReportAudit(ctx, &export.Audit{Timestamp: timestamp, Violations: export.Violations(res)}); err != nil {
+			am.log.Error(err, "failed to export audit results")
+		}
+	}
+	return nil
+}
+
+// config returns the Config resource, or nil if it can't be read
+func (am *Manager) config(ctx context.Context) *v1alpha1.Config {
+	cfg := &v1alpha1.Config{}
+	if err := am.client.Get(ctx, config.CfgKey, cfg); err != nil {
+		if !apierrors.IsNotFound(err) {
+			am.log.Error(err, "unable to get config, using default policy sets and enforcement actions")
+		}
+		return nil
+	}
+	return cfg
+}
+
+// resolveActions resolves the enforcement action of each result the same way
+// the admission webhook does, using the namespace of the violating object
+func (am *Manager) resolveActions(ctx context.Context, cfg *v1alpha1.Config, res []*constraintTypes.Result) []*constraintTypes.Result {
+	nsCache := newNSCache()
+	resolved := make([]*constraintTypes.Result, 0, len(res))
+	for _, r := range res {
+		var ns *corev1.Namespace
+		if u, ok := r.Resource.(*unstructured.Unstructured); ok && u.GetNamespace() != "" {
+			n, err := nsCache.Get(ctx, am.client, u.GetNamespace())
+			if err != nil {
+				am.log.Error(err, "unable to get namespace, using the constraint's enforcement action", "namespace", u.GetNamespace())
+			} else {
+				ns = &n
+			}
+		}
+		resolved = append(resolved, nsenforcement.Resolve(cfg, ns, []*constraintTypes.Result{r})...)
+	}
+	return resolved
 }
 
 // Audits server resources via the discovery client, as an alternative to opa.Client.Audit()
@@ -230,8 +326,32 @@ func (am *Manager) auditResources(ctx context.Context) ([]*constraintTypes.Resul
 
 	var responses []*constraintTypes.Result
 	var errs opa.Errors
+	var mux sync.Mutex
 	nsCache := newNSCache()
 
+	workers := *auditConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	objs := make(chan unstructured.Unstructured, workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objs {
+				res, err := am.reviewObject(ctx, nsCache, obj)
+				mux.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					responses = append(responses, res...)
+				}
+				mux.Unlock()
+			}
+		}()
+	}
+
 	for gv, gvKinds := range clusterAPIResources {
 		for kind := range gvKinds {
 			objList := &unstructured.UnstructuredList{}
@@ -248,29 +368,12 @@ func (am *Manager) auditResources(ctx context.Context) ([]*constraintTypes.Resul
 			}
 
 			for _, obj := range objList.Items {
-				ns := corev1.Namespace{}
-				if obj.GetNamespace() != "" {
-					ns, err = nsCache.Get(ctx, am.client, obj.GetNamespace())
-					if err != nil {
-						am.log.Error(err, "Unable to look up object namespace", "group", gv.Group, "version", gv.Version, "kind", kind)
-						continue
-					}
-				}
-
-				augmentedObj := target.AugmentedUnstructured{
-					Object:    obj,
-					Namespace: &ns,
-				}
-				resp, err := am.opa.Review(ctx, augmentedObj)
-
-				if err != nil {
-					errs = append(errs, err)
-				} else if len(resp.Results()) > 0 {
-					responses = append(responses, resp.Results()...)
-				}
+				objs <- obj
 			}
 		}
 	}
+	close(objs)
+	wg.Wait()
 
 	if len(errs) > 0 {
 		return responses, errs
@@ -278,6 +381,68 @@ func (am *Manager) auditResources(ctx context.Context) ([]*constraintTypes.Resul
 	return responses, nil
 }
 
+// reviewObject evaluates a single object. It is called concurrently by the
+// audit workers.
+func (am *Manager) reviewObject(ctx context.Context, nsCache *nsCache, obj unstructured.Unstructured) ([]*constraintTypes.Result, error) {
+	if am.sampler.sample() {
+		am.log.V(logging.DebugLevel).Info(
+			"auditing object",
+			logging.ResourceAPIVersion, obj.GetAPIVersion(),
+			logging.ResourceKind, obj.GetKind(),
+			logging.ResourceNamespace, obj.GetNamespace(),
+			logging.ResourceName, obj.GetName(),
+		)
+	}
+	ns := corev1.Namespace{}
+	if obj.GetNamespace() != "" {
+		var err error
+		ns, err = nsCache.Get(ctx, am.client, obj.GetNamespace())
+		if err != nil {
+			gvk := obj.GroupVersionKind()
+			am.log.Error(err, "Unable to look up object namespace", "group", gvk.Group, "version", gvk.Version, "kind", gvk.Kind)
+			return nil, nil
+		}
+	}
+
+	if !prune.KeepManagedFields() {
+		prune.StripManagedFields(obj.Object)
+	}
+	augmentedObj := target.AugmentedUnstructured{
+		Object:    obj,
+		Namespace: &ns,
+	}
+	resp, err := am.opa.Review(ctx, augmentedObj)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results(), nil
+}
+
+// sortResults orders results by constraint, then by resource, so the
+// violations written to a constraint's status don't depend on the order
+// objects were listed and evaluated in.
+func sortResults(res []*constraintTypes.Result) {
+	key := func(r *constraintTypes.Result) []string {
+		k := []string{"", "", "", "", "", "", r.Msg}
+		if r.Constraint != nil {
+			k[0], k[1] = r.Constraint.GetKind(), r.Constraint.GetName()
+		}
+		if u, ok := r.Resource.(*unstructured.Unstructured); ok {
+			k[2], k[3], k[4], k[5] = u.GetAPIVersion(), u.GetKind(), u.GetNamespace(), u.GetName()
+		}
+		return k
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		ki, kj := key(res[i]), key(res[j])
+		for n := range ki {
+			if ki[n] != kj[n] {
+				return ki[n] < kj[n]
+			}
+		}
+		return false
+	})
+}
+
 func (am *Manager) auditManagerLoop(ctx context.Context) {
 	for {
 		select {
@@ -334,9 +499,15 @@ func (am *Manager) getAllConstraintKinds() ([]schema.GroupVersionKind, error) {
 	return ret, nil
 }
 
-func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Result) (map[string][]auditResult, map[string]int64, map[util.EnforcementAction]int64, error) {
+// getUpdateListsFromAuditResponses returns the results to list in the status
+// of each constraint, at most --constraint-violations-limit violations and as
+// many findings, along with the total numbers of violations and findings.
+func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Result) (map[string][]auditResult, map[string]int64, map[string]int64, map[util.EnforcementAction]int64, error) {
 	updateLists := make(map[string][]auditResult)
 	totalViolationsPerConstraint := make(map[string]int64)
+	totalFindingsPerConstraint := make(map[string]int64)
+	// the first result of each constraint, which is logged with its totals
+	firstResults := make(map[string]auditResult)
 	totalViolationsPerEnforcementAction := make(map[util.EnforcementAction]int64)
 	// resetting total violations per enforcement action
 	for _, action := range util.KnownEnforcementActions {
@@ -345,7 +516,12 @@ func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Resul
 
 	for _, r := range res {
 		selfLink := r.Constraint.GetSelfLink()
-		totalViolationsPerConstraint[selfLink]++
+		finding := findings.IsFinding(r)
+		total := totalViolationsPerConstraint
+		if finding {
+			total = totalFindingsPerConstraint
+		}
+		total[selfLink]++
 		name := r.Constraint.GetName()
 		namespace := r.Constraint.GetNamespace()
 		apiVersion := r.Constraint.GetAPIVersion()
@@ -354,9 +530,10 @@ func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Resul
 		message := r.Msg
 		resource, ok := r.Resource.(*unstructured.Unstructured)
 		if !ok {
-			return nil, nil, nil, errors.Errorf("could not cast resource as reviewResource: %v", r.Resource)
+			return nil, nil, nil, nil, errors.Errorf("could not cast resource as reviewResource: %v", r.Resource)
 		}
 		rname := resource.GetName()
+		rapiversion := resource.GetAPIVersion()
 		rkind := resource.GetKind()
 		rnamespace := resource.GetNamespace()
 		result := auditResult{
@@ -364,27 +541,38 @@ func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Resul
 			capiversion:       apiVersion,
 			cname:             name,
 			cnamespace:        namespace,
+			rapiversion:       rapiversion,
 			rkind:             rkind,
 			rname:             rname,
 			rnamespace:        rnamespace,
 			message:           message,
 			enforcementAction: enforcementAction,
 			constraint:        r.Constraint,
+			finding:           finding,
+		}
+		if _, ok := firstResults[selfLink]; !ok {
+			firstResults[selfLink] = result
+		}
+		// results beyond the limit are never listed, so they aren't kept
+		if uint(total[selfLink]) <= *constraintViolationsLimit {
+			updateLists[selfLink] = append(updateLists[selfLink], result)
+		}
+		if finding {
+			logFinding(am.log, r.Constraint, result)
+			continue
 		}
-		updateLists[selfLink] = append(updateLists[selfLink], result)
 		ea := util.EnforcementAction(enforcementAction)
 		totalViolationsPerEnforcementAction[ea]++
 		logViolation(am.log, r.Constraint, r.EnforcementAction, result)
 	}
 	// log constraints with violations
-	for link := range updateLists {
-		ar := updateLists[link][0]
+	for link, ar := range firstResults {
 		logConstraint(am.log, ar.constraint, ar.enforcementAction, totalViolationsPerConstraint[link])
 	}
-	return updateLists, totalViolationsPerConstraint, totalViolationsPerEnforcementAction, nil
+	return updateLists, totalViolationsPerConstraint, totalFindingsPerConstraint, totalViolationsPerEnforcementAction, nil
 }
 
-func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.GroupVersionKind, updateLists map[string][]auditResult, timestamp string, totalViolations map[string]int64) error {
+func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.GroupVersionKind, updateLists map[string][]auditResult, timestamp string, totalViolations, totalFindings map[string]int64) error {
 	// get constraints for each Kind
 	for _, constraintGvk := range resourceList {
 		am.log.Info("constraint", "resource kind", constraintGvk.Kind)
@@ -410,13 +598,16 @@ func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.
 				}
 			}
 			am.ucloop = &updateConstraintLoop{
-				uc:      updateConstraints,
-				client:  am.client,
-				stop:    make(chan struct{}),
-				stopped: make(chan struct{}),
-				ul:      updateLists,
-				ts:      timestamp,
-				tv:      totalViolations,
+				uc:       updateConstraints,
+				client:   am.client,
+				stop:     make(chan struct{}),
+				stopped:  make(chan struct{}),
+				ul:       updateLists,
+				ts:       timestamp,
+				tv:       totalViolations,
+				tf:       totalFindings,
+				reporter: am.reporter,
+				sampler:  am.sampler,
 			}
 			am.log.Info("starting update constraints loop", "updateConstraints", updateConstraints)
 			go am.ucloop.update()
@@ -425,37 +616,27 @@ func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.
 	return nil
 }
 
-func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context, instance *unstructured.Unstructured, auditResults []auditResult, timestamp string, totalViolations int64) error {
+func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context, instance *unstructured.Unstructured, auditResults []auditResult, timestamp string, totalViolations, totalFindings int64) error {
 	constraintName := instance.GetName()
-	log.Info("updating constraint status", "constraintName", constraintName)
-	// create constraint status violations
-	var statusViolations []interface{}
+	logged := ucloop.sampler.sample()
+	if logged {
+		log.Info("updating constraint status", "constraintName", constraintName)
+	}
+	// create constraint status violations and findings, bounded in number
+	// and size
+	statusViolations := newStatusList(*constraintViolationsLimit, *constraintViolationsMaxBytes)
+	statusFindings := newStatusList(*constraintViolationsLimit, *constraintViolationsMaxBytes)
 	for _, ar := range auditResults {
-		// append statusViolations for this constraint until constraintViolationsLimit has reached
-		if uint(len(statusViolations)) < *constraintViolationsLimit {
-			msg := ar.message
-			if len(msg) > msgSize {
-				msg = truncateString(msg, msgSize)
-			}
-			statusViolations = append(statusViolations, StatusViolation{
-				Kind:              ar.rkind,
-				Name:              ar.rname,
-				Namespace:         ar.rnamespace,
-				Message:           msg,
-				EnforcementAction: ar.enforcementAction,
-			})
+		list := statusViolations
+		if ar.finding {
+			list = statusFindings
+		}
+		if err := list.add(statusEntry(ar)); err != nil {
+			return err
 		}
 	}
-	raw, err := json.Marshal(statusViolations)
-	if err != nil {
-		return err
-	}
-	// need to convert to []interface{}
-	violations := make([]interface{}, 0)
-	err = json.Unmarshal(raw, &violations)
-	if err != nil {
-		return err
-	}
+	violations := statusViolations.items
+	var err error
 	// update constraint status auditTimestamp
 	if err = unstructured.SetNestedField(instance.Object, timestamp, "status", "auditTimestamp"); err != nil {
 		return err
@@ -464,6 +645,21 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 	if err = unstructured.SetNestedField(instance.Object, totalViolations, "status", "totalViolations"); err != nil {
 		return err
 	}
+	if err = setStatusFindings(instance, statusFindings.items, totalFindings); err != nil {
+		return err
+	}
+	// flag statuses that list fewer violations than there are
+	if totalViolations > int64(len(violations)) {
+		if err = unstructured.SetNestedField(instance.Object, true, "status", "violationsTruncated"); err != nil {
+			return err
+		}
+	} else {
+		unstructured.RemoveNestedField(instance.Object, "status", "violationsTruncated")
+	}
+	summary := StatusAuditSummary{AuditTimestamp: timestamp, TotalViolations: totalViolations, TotalFindings: totalFindings}
+	if err = recordHistory(instance, summary, *auditHistorySize); err != nil {
+		return err
+	}
 	// update constraint status violations
 	if len(violations) == 0 {
 		_, found, err := unstructured.NestedSlice(instance.Object, "status", "violations")
@@ -472,7 +668,9 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 		}
 		if found {
 			unstructured.RemoveNestedField(instance.Object, "status", "violations")
-			log.Info("removed status violations", "constraintName", constraintName)
+			if logged {
+				log.Info("removed status violations", "constraintName", constraintName)
+			}
 		}
 		err = ucloop.client.Status().Update(ctx, instance)
 		if err != nil {
@@ -482,16 +680,42 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 		if err := unstructured.SetNestedSlice(instance.Object, violations, "status", "violations"); err != nil {
 			return err
 		}
-		log.Info("update constraint", "object", instance)
+		if logged {
+			log.Info("update constraint", "object", instance)
+		}
 		err = ucloop.client.Status().Update(ctx, instance)
 		if err != nil {
 			return err
 		}
-		log.Info("updated constraint status violations", "constraintName", constraintName, "count", len(violations))
+		if logged {
+			log.Info("updated constraint status violations", "constraintName", constraintName, "count", len(violations))
+		}
 	}
 	return nil
 }
 
+// setStatusFindings records the constraint's findings in its status, or
+// removes them if there are none
+func setStatusFindings(instance *unstructured.Unstructured, statusFindings []interface{}, totalFindings int64) error {
+	if totalFindings == 0 {
+		unstructured.RemoveNestedField(instance.Object, "status", "totalFindings")
+		unstructured.RemoveNestedField(instance.Object, "status", "findings")
+		return nil
+	}
+	raw, err := json.Marshal(statusFindings)
+	if err != nil {
+		return err
+	}
+	findingsList := make([]interface{}, 0)
+	if err := json.Unmarshal(raw, &findingsList); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(instance.Object, totalFindings, "status", "totalFindings"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedSlice(instance.Object, findingsList, "status", "findings")
+}
+
 func truncateString(str string, size int) string {
 	shortenStr := str
 	if len(str) > size {
@@ -504,13 +728,16 @@ func truncateString(str string, size int) string {
 }
 
 type updateConstraintLoop struct {
-	uc      map[string]unstructured.Unstructured
-	client  client.Client
-	stop    chan struct{}
-	stopped chan struct{}
-	ul      map[string][]auditResult
-	ts      string
-	tv      map[string]int64
+	uc       map[string]unstructured.Unstructured
+	client   client.Client
+	stop     chan struct{}
+	stopped  chan struct{}
+	ul       map[string][]auditResult
+	ts       string
+	tv       map[string]int64
+	tf       map[string]int64
+	reporter *reporter
+	sampler  *logSampler
 }
 
 func (ucloop *updateConstraintLoop) update() {
@@ -521,38 +748,18 @@ func (ucloop *updateConstraintLoop) update() {
 			case <-ucloop.stop:
 				return true, nil
 			default:
-				failure := false
-				ctx := context.Background()
-				var latestItem unstructured.Unstructured
-				item.DeepCopyInto(&latestItem)
-				name := latestItem.GetName()
-				namespace := latestItem.GetNamespace()
-				namespacedName := types.NamespacedName{
-					Name:      name,
-					Namespace: namespace,
-				}
-				// get the latest constraint
-				err := ucloop.client.Get(ctx, namespacedName, &latestItem)
-				if err != nil {
-					failure = true
-					log.Error(err, "could not get latest constraint during update", "name", name, "namespace", namespace)
-				}
-				if constraintAuditResults, ok := ucloop.ul[latestItem.GetSelfLink()]; !ok {
-					err := ucloop.updateConstraintStatus(ctx, &latestItem, emptyAuditResults, ucloop.ts, 0)
-					if err != nil {
-						failure = true
-						log.Error(err, "could not update constraint status", "name", name, "namespace", namespace)
-					}
-				} else {
-					totalViolations := ucloop.tv[latestItem.GetSelfLink()]
-					// update the constraint
-					err := ucloop.updateConstraintStatus(ctx, &latestItem, constraintAuditResults, ucloop.ts, totalViolations)
-					if err != nil {
-						failure = true
-						log.Error(err, "could not update constraint status", "name", name, "namespace", namespace)
-					}
-				}
-				if !failure {
+				name := item.GetName()
+				namespace := item.GetNamespace()
+				err := ucloop.updateItem(item)
+				switch {
+				case err == nil:
+					delete(ucloop.uc, item.GetSelfLink())
+				case isRetryableStatusError(err):
+					log.Error(err, "could not update constraint status, will retry", "name", name, "namespace", namespace)
+				default:
+					// retrying will not help, e.g. the constraint was deleted
+					log.Error(err, "could not update constraint status", "name", name, "namespace", namespace)
+					ucloop.reportStatusUpdateFailures(1)
 					delete(ucloop.uc, item.GetSelfLink())
 				}
 			}
@@ -563,16 +770,63 @@ func (ucloop *updateConstraintLoop) update() {
 		return false, nil
 	}
 
+	steps := *statusUpdateRetries
+	if steps < 1 {
+		steps = 1
+	}
 	if err := wait.ExponentialBackoff(wait.Backoff{
-		Duration: 1 * time.Second,
+		Duration: *statusUpdateBackoff,
 		Factor:   2,
 		Jitter:   1,
-		Steps:    5,
+		Steps:    steps,
 	}, updateLoop); err != nil {
-		log.Error(err, "could not update constraint reached max retries", "remaining update constraints", ucloop.uc)
+		var remaining []string
+		for _, item := range ucloop.uc {
+			remaining = append(remaining, item.GetSelfLink())
+		}
+		log.Error(err, "could not update constraint reached max retries", "remaining update constraints", remaining)
+		ucloop.reportStatusUpdateFailures(int64(len(ucloop.uc)))
 	}
 }
 
+// updateItem re-fetches the latest version of the constraint and writes the audit results to its status
+func (ucloop *updateConstraintLoop) updateItem(item unstructured.Unstructured) error {
+	ctx := context.Background()
+	var latestItem unstructured.Unstructured
+	item.DeepCopyInto(&latestItem)
+	namespacedName := types.NamespacedName{
+		Name:      latestItem.GetName(),
+		Namespace: latestItem.GetNamespace(),
+	}
+	// get the latest constraint
+	if err := ucloop.client.Get(ctx, namespacedName, &latestItem); err != nil {
+		return errors.Wrap(err, "could not get latest constraint during update")
+	}
+	// constraints without results have no list and no totals
+	link := latestItem.GetSelfLink()
+	return ucloop.updateConstraintStatus(ctx, &latestItem, ucloop.ul[link], ucloop.ts, ucloop.tv[link], ucloop.tf[link])
+}
+
+func (ucloop *updateConstraintLoop) reportStatusUpdateFailures(count int64) {
+	if ucloop.reporter == nil || count == 0 {
+		return
+	}
+	if err := ucloop.reporter.reportStatusUpdateFailures(count); err != nil {
+		log.Error(err, "failed to report status update failures")
+	}
+}
+
+// isRetryableStatusError returns true for errors caused by contention on the API server
+func isRetryableStatusError(err error) bool {
+	err = errors.Cause(err)
+	return apierrors.IsConflict(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
 func logStart(l logr.Logger) {
 	l.Info(
 		"auditing constraints and violations",
@@ -596,7 +850,22 @@ func logConstraint(l logr.Logger, constraint *unstructured.Unstructured, enforce
 		logging.ConstraintNamespace, constraint.GetNamespace(),
 		logging.ConstraintAction, enforcementAction,
 		logging.ConstraintStatus, "enforced",
-		logging.ConstraintViolations, string(totalViolations),
+		logging.ConstraintViolations, strconv.FormatInt(totalViolations, 10),
+	)
+}
+
+func logFinding(l logr.Logger, constraint *unstructured.Unstructured, finding auditResult) {
+	l.Info(
+		finding.message,
+		logging.EventType, "finding_audited",
+		logging.ConstraintKind, constraint.GetKind(),
+		logging.ConstraintName, constraint.GetName(),
+		logging.ConstraintNamespace, constraint.GetNamespace(),
+		logging.ResourceAPIVersion, finding.rapiversion,
+		logging.ResourceKind, finding.rkind,
+		logging.ResourceNamespace, finding.rnamespace,
+		logging.ResourceName, finding.rname,
+		logging.ViolationMessage, finding.message,
 	)
 }
 
@@ -608,8 +877,10 @@ func logViolation(l logr.Logger, constraint *unstructured.Unstructured, enforcem
 		logging.ConstraintName, constraint.GetName(),
 		logging.ConstraintNamespace, constraint.GetNamespace(),
 		logging.ConstraintAction, enforcementAction,
+		logging.ResourceAPIVersion, violation.rapiversion,
 		logging.ResourceKind, violation.rkind,
 		logging.ResourceNamespace, violation.rnamespace,
 		logging.ResourceName, violation.rname,
+		logging.ViolationMessage, violation.message,
 	)
 }
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	pkgerrors "github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsRetryableStatusError(t *testing.T) {
+	gr := schema.GroupResource{Group: "constraints.gatekeeper.sh", Resource: "k8srequiredlabels"}
+	tc := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "conflict", err: apierrors.NewConflict(gr, "foo", errors.New("changed")), expected: true},
+		{name: "throttled", err: apierrors.NewTooManyRequests("slow down", 1), expected: true},
+		{name: "server timeout", err: apierrors.NewServerTimeout(gr, "update", 1), expected: true},
+		{name: "wrapped conflict", err: pkgerrors.Wrap(apierrors.NewConflict(gr, "foo", errors.New("changed")), "get"), expected: true},
+		{name: "not found", err: apierrors.NewNotFound(gr, "foo"), expected: false},
+		{name: "invalid", err: apierrors.NewBadRequest("bad"), expected: false},
+		{name: "other", err: errors.New("boom"), expected: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableStatusError(tt.err); got != tt.expected {
+				t.Errorf("isRetryableStatusError(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func newResult(ckind, cname, rkind, rnamespace, rname, msg string) *constraintTypes.Result {
+	c := &unstructured.Unstructured{}
+	c.SetKind(ckind)
+	c.SetName(cname)
+	r := &unstructured.Unstructured{}
+	r.SetAPIVersion("v1")
+	r.SetKind(rkind)
+	r.SetNamespace(rnamespace)
+	r.SetName(rname)
+	return &constraintTypes.Result{Constraint: c, Resource: r, Msg: msg}
+}
+
+func TestSortResults(t *testing.T) {
+	a := newResult("K8sRequiredLabels", "a", "Pod", "default", "foo", "x")
+	b := newResult("K8sRequiredLabels", "a", "Pod", "default", "foo", "y")
+	c := newResult("K8sRequiredLabels", "a", "Pod", "kube-system", "bar", "x")
+	d := newResult("K8sRequiredLabels", "b", "Namespace", "", "default", "x")
+	e := newResult("K8sAllowedRepos", "z", "Pod", "default", "foo", "x")
+	expected := []*constraintTypes.Result{e, a, b, c, d}
+
+	for _, order := range [][]*constraintTypes.Result{
+		{a, b, c, d, e},
+		{e, d, c, b, a},
+		{c, a, e, b, d},
+	} {
+		res := append([]*constraintTypes.Result{}, order...)
+		sortResults(res)
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("sortResults(%v) = %v, want %v", order, res, expected)
+		}
+	}
+}
+
+func TestSetStatusFindings(t *testing.T) {
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	statusFindings := []interface{}{StatusFinding{Kind: "Pod", Name: "web", Namespace: "apps", Message: "uses registry gcr.io"}}
+	if err := setStatusFindings(instance, statusFindings, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	total, _, _ := unstructured.NestedInt64(instance.Object, "status", "totalFindings")
+	list, _, _ := unstructured.NestedSlice(instance.Object, "status", "findings")
+	if total != 3 || len(list) != 1 {
+		t.Errorf("got %d total findings and %d listed, want 3 and 1", total, len(list))
+	}
+
+	if err := setStatusFindings(instance, nil, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(instance.Object, "status", "findings"); found {
+		t.Error("expected findings to be removed")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(instance.Object, "status", "totalFindings"); found {
+		t.Error("expected totalFindings to be removed")
+	}
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"reflect"
+	"strings"
+
+	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	objectGroupLabel     = "objectviolations.gatekeeper.sh/group"
+	objectKindLabel      = "objectviolations.gatekeeper.sh/kind"
+	objectNamespaceLabel = "objectviolations.gatekeeper.sh/namespace"
+	objectNameLabel      = "objectviolations.gatekeeper.sh/name"
+)
+
+var auditObjectViolations = flag.Bool("audit-object-violations", false, "maintain an ObjectViolations resource in the Gatekeeper namespace for every object with audit violations")
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=objectviolations,verbs=get;list;watch;create;update;patch;delete
+
+// objectViolationsName returns the name of the ObjectViolations for ref. The
+// version is left out so an object has the same name whichever version it
+// was audited at.
+func objectViolationsName(ref v1alpha1.ObjectReference) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{ref.Group, ref.Kind, ref.Namespace, ref.Name}, "/")))
+	return hex.EncodeToString(sum[:])[:40]
+}
+
+// objectLabels labels the ObjectViolations with the parts of ref that are
+// valid label values, so they can be found with a label selector
+func objectLabels(ref v1alpha1.ObjectReference) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range map[string]string{
+		objectGroupLabel:     ref.Group,
+		objectKindLabel:      ref.Kind,
+		objectNamespaceLabel: ref.Namespace,
+		objectNameLabel:      ref.Name,
+	} {
+		if len(validation.IsValidLabelValue(v)) == 0 {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// buildObjectViolations inverts the audit results into one ObjectViolations
+// per violating object, keyed by name
+func buildObjectViolations(res []*constraintTypes.Result, timestamp string) (map[string]*v1alpha1.ObjectViolations, error) {
+	objects := make(map[string]*v1alpha1.ObjectViolations)
+	for _, r := range findings.Violations(res) {
+		resource, ok := r.Resource.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.Errorf("could not cast resource as reviewResource: %v", r.Resource)
+		}
+		gvk := resource.GroupVersionKind()
+		ref := v1alpha1.ObjectReference{
+			Group:     gvk.Group,
+			Version:   gvk.Version,
+			Kind:      gvk.Kind,
+			Namespace: resource.GetNamespace(),
+			Name:      resource.GetName(),
+		}
+		name := objectViolationsName(ref)
+		ov, ok := objects[name]
+		if !ok {
+			ov = &v1alpha1.ObjectViolations{
+				Spec:   v1alpha1.ObjectViolationsSpec{Object: ref},
+				Status: v1alpha1.ObjectViolationsStatus{AuditTimestamp: timestamp},
+			}
+			ov.SetName(name)
+			ov.SetNamespace(util.GetNamespace())
+			ov.SetLabels(objectLabels(ref))
+			objects[name] = ov
+		}
+		ov.Status.TotalViolations++
+		if uint(len(ov.Status.Violations)) < *constraintViolationsLimit {
+			ov.Status.Violations = append(ov.Status.Violations, v1alpha1.ObjectViolation{
+				ConstraintKind:    r.Constraint.GetKind(),
+				ConstraintName:    r.Constraint.GetName(),
+				EnforcementAction: r.EnforcementAction,
+				Message:           truncateString(r.Msg, msgSize),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// objectViolationsEqual returns whether the existing ObjectViolations already
+// has the labels, spec and status of ov. The audit timestamp changes on every
+// audit and is ignored, so objects whose violations didn't change are not
+// rewritten.
+func objectViolationsEqual(current, ov *v1alpha1.ObjectViolations) bool {
+	if len(current.GetLabels()) != len(ov.GetLabels()) {
+		return false
+	}
+	for k, v := range ov.GetLabels() {
+		if l, ok := current.GetLabels()[k]; !ok || l != v {
+			return false
+		}
+	}
+	status := current.Status
+	status.AuditTimestamp = ov.Status.AuditTimestamp
+	return reflect.DeepEqual(current.Spec, ov.Spec) && reflect.DeepEqual(status, ov.Status)
+}
+
+// writeObjectViolations replaces the ObjectViolations in the Gatekeeper
+// namespace with the ones for res. Objects without violations, including
+// deleted objects, no longer have one.
+func (am *Manager) writeObjectViolations(ctx context.Context, res []*constraintTypes.Result, timestamp string) error {
+	objects, err := buildObjectViolations(res, timestamp)
+	if err != nil {
+		return err
+	}
+	count := len(objects)
+	existing := &v1alpha1.ObjectViolationsList{}
+	if err := am.client.List(ctx, existing, client.InNamespace(util.GetNamespace())); err != nil {
+		return err
+	}
+	var errs []string
+	for i := range existing.Items {
+		current := &existing.Items[i]
+		ov, ok := objects[current.GetName()]
+		if !ok {
+			if err := am.client.Delete(ctx, current); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		delete(objects, current.GetName())
+		if objectViolationsEqual(current, ov) {
+			continue
+		}
+		current.SetLabels(ov.GetLabels())
+		current.Spec = ov.Spec
+		current.Status = ov.Status
+		if err := am.client.Update(ctx, current); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	for _, ov := range objects {
+		if err := am.client.Create(ctx, ov); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("unable to write object violations: %s", strings.Join(errs, "; "))
+	}
+	am.log.Info("wrote object violations", logging.EventType, "object_violations_written", "count", count)
+	return nil
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestBuildObjectViolations(t *testing.T) {
+	defer func(limit uint) { *constraintViolationsLimit = limit }(*constraintViolationsLimit)
+	*constraintViolationsLimit = 2
+
+	res := []*constraintTypes.Result{
+		newResult("K8sRequiredLabels", "a", "Pod", "default", "foo", "x"),
+		newResult("K8sAllowedRepos", "b", "Pod", "default", "foo", "y"),
+		newResult("K8sAllowedRepos", "c", "Pod", "default", "foo", "z"),
+		newResult("K8sRequiredLabels", "a", "Pod", "kube-system", "foo", "x"),
+		newResult("K8sRequiredLabels", "a", "Namespace", "", strings.Repeat("n", 70), "x"),
+	}
+	objects, err := buildObjectViolations(res, "now")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 {
+		t.Fatalf("got %d objects, want 3", len(objects))
+	}
+
+	ref := v1alpha1.ObjectReference{Version: "v1", Kind: "Pod", Namespace: "default", Name: "foo"}
+	foo, ok := objects[objectViolationsName(ref)]
+	if !ok {
+		t.Fatalf("no object violations for %v", ref)
+	}
+	if foo.Spec.Object != ref {
+		t.Errorf("object = %v, want %v", foo.Spec.Object, ref)
+	}
+	expected := v1alpha1.ObjectViolationsStatus{
+		AuditTimestamp:  "now",
+		TotalViolations: 3,
+		Violations: []v1alpha1.ObjectViolation{
+			{ConstraintKind: "K8sRequiredLabels", ConstraintName: "a", Message: "x"},
+			{ConstraintKind: "K8sAllowedRepos", ConstraintName: "b", Message: "y"},
+		},
+	}
+	if !reflect.DeepEqual(foo.Status, expected) {
+		t.Errorf("status = %v, want %v", foo.Status, expected)
+	}
+	expectedLabels := map[string]string{
+		objectGroupLabel:     "",
+		objectKindLabel:      "Pod",
+		objectNamespaceLabel: "default",
+		objectNameLabel:      "foo",
+	}
+	if !reflect.DeepEqual(foo.GetLabels(), expectedLabels) {
+		t.Errorf("labels = %v, want %v", foo.GetLabels(), expectedLabels)
+	}
+
+	long := objects[objectViolationsName(v1alpha1.ObjectReference{Kind: "Namespace", Name: strings.Repeat("n", 70)})]
+	if long == nil {
+		t.Fatal("no object violations for the namespace")
+	}
+	if _, ok := long.GetLabels()[objectNameLabel]; ok {
+		t.Error("expected no name label for a name that is not a valid label value")
+	}
+}
+
+// objectViolationsClient stores ObjectViolations and counts the writes
+type objectViolationsClient struct {
+	client.Client
+	objects map[string]*v1alpha1.ObjectViolations
+	writes  int
+}
+
+func (c *objectViolationsClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	l := list.(*v1alpha1.ObjectViolationsList)
+	l.Items = nil
+	for _, ov := range c.objects {
+		l.Items = append(l.Items, *ov.DeepCopy())
+	}
+	return nil
+}
+
+func (c *objectViolationsClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	ov := obj.(*v1alpha1.ObjectViolations)
+	c.objects[ov.GetName()] = ov.DeepCopy()
+	c.writes++
+	return nil
+}
+
+func (c *objectViolationsClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return c.Create(ctx, obj)
+}
+
+func (c *objectViolationsClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	delete(c.objects, obj.(*v1alpha1.ObjectViolations).GetName())
+	c.writes++
+	return nil
+}
+
+func TestWriteObjectViolations(t *testing.T) {
+	c := &objectViolationsClient{objects: make(map[string]*v1alpha1.ObjectViolations)}
+	am := &Manager{client: c, log: logf.Log}
+	res := []*constraintTypes.Result{
+		newResult("K8sRequiredLabels", "a", "Pod", "default", "foo", "x"),
+		newResult("K8sRequiredLabels", "a", "Pod", "default", "bar", "x"),
+	}
+
+	if err := am.writeObjectViolations(context.Background(), res, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if c.writes != 2 {
+		t.Errorf("got %d writes for the first audit, want 2", c.writes)
+	}
+
+	c.writes = 0
+	if err := am.writeObjectViolations(context.Background(), res, "second"); err != nil {
+		t.Fatal(err)
+	}
+	if c.writes != 0 {
+		t.Errorf("got %d writes for an unchanged audit, want 0", c.writes)
+	}
+
+	c.writes = 0
+	changed := []*constraintTypes.Result{res[0], newResult("K8sRequiredLabels", "a", "Pod", "default", "bar", "y")}
+	if err := am.writeObjectViolations(context.Background(), changed, "third"); err != nil {
+		t.Fatal(err)
+	}
+	if c.writes != 1 {
+		t.Errorf("got %d writes after one object's violations changed, want 1", c.writes)
+	}
+	for _, ov := range c.objects {
+		want := "first"
+		if ov.Spec.Object.Name == "bar" {
+			want = "third"
+		}
+		if ov.Status.AuditTimestamp != want {
+			t.Errorf("%s has audit timestamp %q, want %q", ov.Spec.Object.Name, ov.Status.AuditTimestamp, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package audit
+
+import (
+	"flag"
+	"sync/atomic"
+)
+
+var auditLogSampleRate = flag.Uint("audit-log-sample-rate", 1, "log one in every N of the audit's per-object and per-constraint processing messages. Violations are never sampled. defaulted to 1, which logs every message, if unspecified ")
+
+// logSampler lets through one in every rate messages, starting with the
+// first
+type logSampler struct {
+	rate uint64
+	seen uint64
+}
+
+func newLogSampler(rate uint) *logSampler {
+	return &logSampler{rate: uint64(rate)}
+}
+
+// sample returns whether the next message should be logged. It is safe to
+// call concurrently.
+func (s *logSampler) sample() bool {
+	if s == nil || s.rate <= 1 {
+		return true
+	}
+	return atomic.AddUint64(&s.seen, 1)%s.rate == 1
+}
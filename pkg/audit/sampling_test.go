@@ -0,0 +1,29 @@
+package audit
+
+import "testing"
+
+func TestLogSampler(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Rate     uint
+		Expected int
+	}{
+		{Name: "Disabled", Rate: 0, Expected: 10},
+		{Name: "Every message", Rate: 1, Expected: 10},
+		{Name: "One in three", Rate: 3, Expected: 4},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			s := newLogSampler(tt.Rate)
+			logged := 0
+			for i := 0; i < 10; i++ {
+				if s.sample() {
+					logged++
+				}
+			}
+			if logged != tt.Expected {
+				t.Errorf("logged %d of 10 messages, want %d", logged, tt.Expected)
+			}
+		})
+	}
+}
@@ -12,15 +12,17 @@ import (
 )
 
 const (
-	violationsMetricName    = "violations"
-	auditDurationMetricName = "audit_duration_seconds"
-	lastRunTimeMetricName   = "audit_last_run_time"
+	violationsMetricName           = "violations"
+	auditDurationMetricName        = "audit_duration_seconds"
+	lastRunTimeMetricName          = "audit_last_run_time"
+	statusUpdateFailuresMetricName = "audit_status_update_failures"
 )
 
 var (
-	violationsM    = stats.Int64(violationsMetricName, "Total number of violations per constraint", stats.UnitDimensionless)
-	auditDurationM = stats.Float64(auditDurationMetricName, "Latency of audit operation in seconds", stats.UnitSeconds)
-	lastRunTimeM   = stats.Float64(lastRunTimeMetricName, "Timestamp of last audit run time", stats.UnitSeconds)
+	violationsM           = stats.Int64(violationsMetricName, "Total number of violations per constraint", stats.UnitDimensionless)
+	auditDurationM        = stats.Float64(auditDurationMetricName, "Latency of audit operation in seconds", stats.UnitSeconds)
+	lastRunTimeM          = stats.Float64(lastRunTimeMetricName, "Timestamp of last audit run time", stats.UnitSeconds)
+	statusUpdateFailuresM = stats.Int64(statusUpdateFailuresMetricName, "Total number of constraint status writes abandoned by audit", stats.UnitDimensionless)
 
 	enforcementActionKey = tag.MustNewKey("enforcement_action")
 )
@@ -50,6 +52,12 @@ func register() error {
 			Description: "Timestamp of last audit run time",
 			Aggregation: view.LastValue(),
 		},
+		{
+			Name:        statusUpdateFailuresMetricName,
+			Measure:     statusUpdateFailuresM,
+			Description: "Total number of constraint status writes abandoned by audit",
+			Aggregation: view.Sum(),
+		},
 	}
 	return view.Register(views...)
 }
@@ -79,6 +87,10 @@ func (r *reporter) reportRunStart(t time.Time) error {
 	return metrics.Record(r.ctx, lastRunTimeM.M(val))
 }
 
+func (r *reporter) reportStatusUpdateFailures(count int64) error {
+	return metrics.Record(r.ctx, statusUpdateFailuresM.M(count))
+}
+
 // newStatsReporter creaters a reporter for audit metrics
 func newStatsReporter() (*reporter, error) {
 	ctx, err := tag.New(
@@ -112,3 +112,26 @@ func TestLastRestartCheck(t *testing.T) {
 		t.Errorf("Metric: %v - Expected %v, got %v", lastRunTimeMetricName, expectedTs, value.Value)
 	}
 }
+
+func TestReportStatusUpdateFailures(t *testing.T) {
+	const expectedRowLength = 1
+
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	if err := r.reportStatusUpdateFailures(2); err != nil {
+		t.Errorf("reportStatusUpdateFailures error %v", err)
+	}
+	if err := r.reportStatusUpdateFailures(3); err != nil {
+		t.Errorf("reportStatusUpdateFailures error %v", err)
+	}
+	row := checkData(t, statusUpdateFailuresMetricName, expectedRowLength)
+	value, ok := row.Data.(*view.SumData)
+	if !ok {
+		t.Error("statusUpdateFailuresMetricName should have aggregation Sum()")
+	}
+	if value.Value != 5 {
+		t.Errorf("Metric: %v - Expected %v, got %v", statusUpdateFailuresMetricName, 5, value.Value)
+	}
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"encoding/json"
+	"flag"
+)
+
+var constraintViolationsMaxBytes = flag.Uint("constraint-violations-max-bytes", 256*1024, "maximum size in bytes of the violations, and separately of the findings, listed in a constraint's status. Listing stops at the first entry that would exceed it. defaulted to 256KiB if unspecified, 0 for no limit ")
+
+// statusList builds the violations or findings listed in a constraint's
+// status one entry at a time. It stops accepting entries once it holds limit
+// of them or maxBytes of encoded entries, so its size is bounded however many
+// results the constraint has.
+type statusList struct {
+	items     []interface{}
+	size      uint
+	limit     uint
+	maxBytes  uint
+	truncated bool
+}
+
+func newStatusList(limit, maxBytes uint) *statusList {
+	return &statusList{limit: limit, maxBytes: maxBytes}
+}
+
+// add appends entry unless the list is full, in which case the list is marked
+// as truncated
+func (l *statusList) add(entry map[string]interface{}) error {
+	if l.truncated || uint(len(l.items)) >= l.limit {
+		l.truncated = true
+		return nil
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// one more byte for the separating comma
+	size := uint(len(raw)) + 1
+	if l.maxBytes > 0 && l.size+size > l.maxBytes {
+		l.truncated = true
+		return nil
+	}
+	l.items = append(l.items, entry)
+	l.size += size
+	return nil
+}
+
+// statusEntry returns the status representation of a violation or finding,
+// with its message truncated to msgSize. enforcementAction is left out for
+// findings.
+func statusEntry(ar auditResult) map[string]interface{} {
+	entry := map[string]interface{}{
+		"kind":    ar.rkind,
+		"name":    ar.rname,
+		"message": truncateString(ar.message, msgSize),
+	}
+	if ar.rnamespace != "" {
+		entry["namespace"] = ar.rnamespace
+	}
+	if !ar.finding {
+		entry["enforcementAction"] = ar.enforcementAction
+	}
+	return entry
+}
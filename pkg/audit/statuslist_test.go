@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusList(t *testing.T) {
+	entry := statusEntry(auditResult{rkind: "Pod", rname: "web", rnamespace: "apps", message: "denied", enforcementAction: "deny"})
+	one := newStatusList(1, 0)
+	if err := one.add(entry); err != nil {
+		t.Fatal(err)
+	}
+	size := one.size
+	tc := []struct {
+		Name          string
+		Limit         uint
+		MaxBytes      uint
+		Expected      int
+		ExpectedTrunc bool
+	}{
+		{Name: "within the limits", Limit: 5, MaxBytes: 0, Expected: 3},
+		{Name: "count limit", Limit: 2, MaxBytes: 0, Expected: 2, ExpectedTrunc: true},
+		{Name: "size limit", Limit: 5, MaxBytes: 2*size + 1, Expected: 2, ExpectedTrunc: true},
+		{Name: "no entries", Limit: 0, MaxBytes: 0, Expected: 0, ExpectedTrunc: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			l := newStatusList(tt.Limit, tt.MaxBytes)
+			for i := 0; i < 3; i++ {
+				if err := l.add(entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(l.items) != tt.Expected || l.truncated != tt.ExpectedTrunc {
+				t.Errorf("got %d entries, truncated %v, want %d, truncated %v", len(l.items), l.truncated, tt.Expected, tt.ExpectedTrunc)
+			}
+		})
+	}
+}
+
+func TestStatusEntry(t *testing.T) {
+	violation := statusEntry(auditResult{rkind: "Namespace", rname: "default", message: strings.Repeat("x", 300), enforcementAction: "dryrun"})
+	if _, ok := violation["namespace"]; ok {
+		t.Error("expected no namespace for a cluster-scoped resource")
+	}
+	if violation["enforcementAction"] != "dryrun" || len(violation["message"].(string)) > msgSize {
+		t.Errorf("unexpected violation entry %v", violation)
+	}
+	finding := statusEntry(auditResult{rkind: "Pod", rname: "web", rnamespace: "apps", message: "registry gcr.io", finding: true})
+	if _, ok := finding["enforcementAction"]; ok || finding["namespace"] != "apps" {
+		t.Errorf("unexpected finding entry %v", finding)
+	}
+}
@@ -18,17 +18,22 @@ package config
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"time"
 
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
 	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/syncdata"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -54,6 +59,16 @@ const (
 var CfgKey = types.NamespacedName{Namespace: util.GetNamespace(), Name: "config"}
 var log = logf.Log.WithName("controller").WithValues("kind", "Config")
 
+// namespaceGVK is read through the cache when matching constraints, so its
+// informer must see every namespace
+var namespaceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+
+// fieldSelectorSetter is implemented by caches that can restrict the objects
+// their informers list
+type fieldSelectorSetter interface {
+	SetFieldSelector(gvk schema.GroupVersionKind, selector string)
+}
+
 type Adder struct {
 	Opa              *opa.Client
 	WatchManager     *watch.Manager
@@ -108,8 +123,12 @@ func newReconciler(mgr manager.Manager, opa syncc.OpaDataClient, wm *watch.Manag
 	if err != nil {
 		return nil, err
 	}
+	selectors, _ := mgr.GetCache().(fieldSelectorSetter)
 	return &ReconcileConfig{
 		reader:           mgr.GetCache(),
+		apiReader:        mgr.GetAPIReader(),
+		selectors:        selectors,
+		fieldSelectors:   make(map[schema.GroupVersionKind]string),
 		writer:           mgr.GetClient(),
 		statusClient:     mgr.GetClient(),
 		scheme:           mgr.GetScheme(),
@@ -135,6 +154,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Reconcile once at start even if there is no Config, so synced data is
+	// known to be current
+	start := make(chan event.GenericEvent, 1)
+	start <- event.GenericEvent{Meta: &metav1.ObjectMeta{Namespace: CfgKey.Namespace, Name: CfgKey.Name}, Object: &configv1alpha1.Config{}}
+	err = c.Watch(&source.Channel{Source: start}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -143,8 +171,10 @@ var _ reconcile.Reconciler = &ReconcileConfig{}
 // ReconcileConfig reconciles a Config object
 type ReconcileConfig struct {
 	reader       client.Reader
+	apiReader    client.Reader
 	writer       client.Writer
 	statusClient client.StatusClient
+	selectors    fieldSelectorSetter
 
 	scheme           *runtime.Scheme
 	opa              syncc.OpaDataClient
@@ -152,6 +182,8 @@ type ReconcileConfig struct {
 	cs               *watch.ControllerSwitch
 	watcher          *watch.Registrar
 	watched          *watch.Set
+	// fieldSelectors holds the field selector of every watched kind that has one
+	fieldSelectors map[schema.GroupVersionKind]string
 }
 
 // +kubebuilder:rbac:groups=*,resources=*,verbs=get;list;watch
@@ -201,17 +233,50 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	}
 
 	newSyncOnly := watch.NewSet()
+	newSelectors := make(map[schema.GroupVersionKind]string)
+	var syncErrors []configv1alpha1.SyncError
 	// If the config is being deleted the user is saying they don't want to
 	// sync anything
 	if exists && instance.GetDeletionTimestamp().IsZero() {
 		for _, entry := range instance.Spec.Sync.SyncOnly {
 			gvk := schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind}
+			if entry.FieldSelector != "" {
+				selector, err := r.validateFieldSelector(context.TODO(), gvk, entry.FieldSelector)
+				if err != nil {
+					if !isSelectorError(err) {
+						return reconcile.Result{}, err
+					}
+					log.Error(err, "not syncing kind", "group", gvk.Group, "version", gvk.Version, "kind", gvk.Kind)
+					syncErrors = append(syncErrors, configv1alpha1.SyncError{
+						Kind:    configv1alpha1.GVK{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+						Message: err.Error(),
+					})
+					continue
+				}
+				newSelectors[gvk] = selector
+			}
 			newSyncOnly.Add(gvk)
 		}
 	}
+	if exists && !reflect.DeepEqual(instance.Status.SyncErrors, syncErrors) {
+		instance.Status.SyncErrors = syncErrors
+		if err := r.writer.Update(context.Background(), instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Informers only read their field selector when they are created, so
+	// kinds whose selector changed must be watched anew
+	reselected := watch.NewSet()
+	for _, gvk := range newSyncOnly.Items() {
+		if r.watched.Contains(gvk) && r.fieldSelectors[gvk] != newSelectors[gvk] {
+			reselected.Add(gvk)
+		}
+	}
 
 	// If the watch set has not changed, we're done here.
-	if r.watched.Equals(newSyncOnly) {
+	if r.watched.Equals(newSyncOnly) && reselected.Size() == 0 {
+		syncc.Freshness.MarkLoaded()
 		return reconcile.Result{}, nil
 	}
 
@@ -221,12 +286,17 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	// to drop events from no-longer-watched resources that may be in its queue.
 	needReplay := r.watched.Union(newSyncOnly)
 	r.watched.Replace(newSyncOnly)
+	syncdata.Templates.SetSynced(newSyncOnly.Items())
 
 	// *Note the following steps are not transactional with respect to admission control*
 
-	// Wipe all data to avoid stale state
-	if _, err := r.opa.RemoveData(context.Background(), target.WipeData{}); err != nil {
-		return reconcile.Result{}, err
+	// Wipe all synced data to avoid stale state. Reference data is loaded
+	// from ConfigMaps rather than synced, so it is kept.
+	syncc.Freshness.Replace(newSyncOnly.Items(), time.Now())
+	for _, root := range []string{"cluster", "namespace"} {
+		if _, err := r.opa.RemoveData(context.Background(), target.WipeData{Root: root}); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
 
 	// reset sync cache before sending the metric
@@ -235,9 +305,21 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 
 	// Important: dynamic watches update must happen *after* updating our watchSet.
 	// Otherwise the sync controller will drop events for the newly watched kinds.
+	if reselected.Size() > 0 {
+		// dropping the watch removes the informer
+		if err := r.watcher.ReplaceWatch(newSyncOnly.Difference(reselected).Items()); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if r.selectors != nil {
+		for _, gvk := range needReplay.Items() {
+			r.selectors.SetFieldSelector(gvk, newSelectors[gvk])
+		}
+	}
 	if err := r.watcher.ReplaceWatch(newSyncOnly.Items()); err != nil {
 		return reconcile.Result{}, err
 	}
+	r.fieldSelectors = newSelectors
 
 	// Replay cached data for any resources that were previously watched and still in the watch set.
 	// This is necessary because we wiped their data from Opa above.
@@ -245,10 +327,51 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	if err := r.replayData(context.TODO(), needReplay); err != nil {
 		return reconcile.Result{}, fmt.Errorf("replaying data: %w", err)
 	}
+	syncc.Freshness.MarkLoaded()
 
 	return reconcile.Result{}, nil
 }
 
+// selectorError is a field selector that can't be used for a kind
+type selectorError struct {
+	error
+}
+
+func isSelectorError(err error) bool {
+	_, ok := err.(selectorError)
+	return ok
+}
+
+// validateFieldSelector returns selector in canonical form. Which fields can
+// be selected on depends on the kind, so it is checked by listing the kind
+// with the selector.
+func (r *ReconcileConfig) validateFieldSelector(ctx context.Context, gvk schema.GroupVersionKind, selector string) (string, error) {
+	parsed, err := fields.ParseSelector(selector)
+	if err != nil {
+		return "", selectorError{fmt.Errorf("invalid field selector %q: %w", selector, err)}
+	}
+	if r.selectors == nil {
+		return "", selectorError{fmt.Errorf("field selectors are not supported by the cache")}
+	}
+	if gvk == namespaceGVK {
+		return "", selectorError{fmt.Errorf("field selectors are not supported for Namespaces")}
+	}
+	if r.apiReader == nil {
+		return parsed.String(), nil
+	}
+	u := &unstructured.UnstructuredList{}
+	u.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	err = r.apiReader.List(ctx, u, client.MatchingFieldsSelector{Selector: parsed}, client.Limit(1))
+	if errors.IsBadRequest(err) {
+		return "", selectorError{fmt.Errorf("field selector %q is not supported: %w", selector, err)}
+	}
+	// unknown kinds are left to the watch manager
+	if err != nil && !meta.IsNoMatchError(err) {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
 // replayData replays all watched and cached data into Opa following a config set change.
 // In the future we can rework this to avoid the full opa data cache wipe.
 func (r *ReconcileConfig) replayData(ctx context.Context, w *watch.Set) error {
@@ -282,6 +405,7 @@ func (r *ReconcileConfig) replayData(ctx context.Context, w *watch.Set) error {
 				Status: metrics.ActiveStatus,
 			})
 		}
+		syncc.Freshness.MarkCurrent(gvk)
 	}
 	return nil
 }
@@ -33,6 +33,7 @@ import (
 	"github.com/open-policy-agent/gatekeeper/third_party/sigs.k8s.io/controller-runtime/pkg/dynamiccache"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -393,6 +394,58 @@ func (f *fakeOpa) Len() int {
 }
 
 // configFor returns a config resource that watches the requested set of resources.
+type fakeSelectors map[schema.GroupVersionKind]string
+
+func (f fakeSelectors) SetFieldSelector(gvk schema.GroupVersionKind, selector string) {
+	f[gvk] = selector
+}
+
+// listErrReader fails every List with err
+type listErrReader struct {
+	client.Reader
+	err error
+}
+
+func (r listErrReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return r.err
+}
+
+func TestValidateFieldSelector(t *testing.T) {
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	badRequest := apierrors.NewBadRequest(`field label not supported: spec.foo`)
+	tc := []struct {
+		name        string
+		gvk         schema.GroupVersionKind
+		selector    string
+		listErr     error
+		want        string
+		selectorErr bool
+		transient   bool
+	}{
+		{name: "valid", gvk: pods, selector: "status.phase==Running", want: "status.phase=Running"},
+		{name: "unparseable", gvk: pods, selector: "status.phase", selectorErr: true},
+		{name: "unsupported field", gvk: pods, selector: "spec.foo=bar", listErr: badRequest, selectorErr: true},
+		{name: "namespaces", gvk: namespaceGVK, selector: "metadata.name=default", selectorErr: true},
+		{name: "unknown kind", gvk: pods, selector: "status.phase=Running", listErr: &meta.NoKindMatchError{}, want: "status.phase=Running"},
+		{name: "api unavailable", gvk: pods, selector: "status.phase=Running", listErr: errors.New("connection refused"), transient: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ReconcileConfig{apiReader: listErrReader{err: tt.listErr}, selectors: fakeSelectors{}}
+			got, err := r.validateFieldSelector(context.TODO(), tt.gvk, tt.selector)
+			if (err != nil) != (tt.selectorErr || tt.transient) {
+				t.Fatalf("validateFieldSelector() err = %v", err)
+			}
+			if isSelectorError(err) != tt.selectorErr {
+				t.Errorf("isSelectorError(%v) = %v, want %v", err, !tt.selectorErr, tt.selectorErr)
+			}
+			if got != tt.want {
+				t.Errorf("validateFieldSelector() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func configFor(kinds []schema.GroupVersionKind) *configv1alpha1.Config {
 	entries := make([]configv1alpha1.SyncOnlyEntry, len(kinds))
 	for i := range kinds {
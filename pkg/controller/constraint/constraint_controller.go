@@ -17,12 +17,14 @@ package constraint
 
 import (
 	"context"
+	"flag"
 	"strings"
 	"sync"
 
 	"github.com/go-logr/logr"
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/constraints"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
@@ -42,7 +44,8 @@ import (
 )
 
 var (
-	log = logf.Log.WithName("controller").WithValues(logging.Process, "constraint_controller")
+	log                     = logf.Log.WithName("controller").WithValues(logging.Process, "constraint_controller")
+	maxConcurrentReconciles = flag.Int("max-concurrent-constraint-reconciles", 1, "number of constraints reconciled in parallel. Higher values load many constraints faster at the cost of more API server requests. defaulted to 1 if unspecified ")
 )
 
 const (
@@ -117,7 +120,7 @@ func newReconciler(
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler, events <-chan event.GenericEvent) error {
 	// Create a new controller
-	c, err := controller.New("constraint-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("constraint-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: *maxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
@@ -230,6 +233,28 @@ func (r *ReconcileConstraint) Reconcile(request reconcile.Request) (reconcile.Re
 		if err = csutil.SetHAStatus(instance, status); err != nil {
 			return reconcile.Result{}, err
 		}
+		// retrying an invalid constraint won't help, it is requeued when it
+		// or its template changes
+		if verr := r.validate(instance); verr != nil {
+			enforced, err := r.loadInvalid(instance, verr)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			r.constraintsCache.addConstraintKey(constraintKey, tags{
+				enforcementAction: enforcementAction,
+				status:            metrics.ErrorStatus,
+			})
+			reportMetrics = true
+			status.Errors = append(status.Errors, invalidStatus(verr, enforced))
+			status.Enforced = enforced
+			if err = csutil.SetHAStatus(instance, status); err != nil {
+				return reconcile.Result{}, err
+			}
+			if err = r.statusClient.Status().Update(context.Background(), instance); err != nil {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, nil
+		}
 		if c, err := r.opa.GetConstraint(context.TODO(), instance); err != nil || !constraints.SemanticEqual(instance, c) {
 			if err := r.cacheConstraint(instance); err != nil {
 				r.constraintsCache.addConstraintKey(constraintKey, tags{
@@ -265,7 +290,9 @@ func (r *ReconcileConstraint) Reconcile(request reconcile.Request) (reconcile.Re
 		reportMetrics = true
 	} else {
 		// Handle deletion
-		if _, err := r.opa.RemoveConstraint(context.Background(), instance); err != nil {
+		_, err := r.opa.RemoveConstraint(context.Background(), instance)
+		decisioncache.Invalidate()
+		if err != nil {
 			if _, ok := err.(*opa.UnrecognizedConstraintError); !ok {
 				return reconcile.Result{}, err
 			}
@@ -302,6 +329,7 @@ func (r *ReconcileConstraint) cacheConstraint(instance *unstructured.Unstructure
 	// Remove the status field since we do not need it for OPA
 	unstructured.RemoveNestedField(obj.Object, "status")
 	_, err := r.opa.AddConstraint(context.Background(), obj)
+	decisioncache.Invalidate()
 	return err
 }
 
@@ -0,0 +1,103 @@
+package constraint
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
+	"github.com/open-policy-agent/gatekeeper/pkg/invalidparams"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// invalidParametersCode is the status error code of constraints whose
+// parameters don't match their template's schema
+const invalidParametersCode = "invalid_parameters"
+
+type invalidPolicy string
+
+const (
+	// disableInvalid unloads invalid constraints, as if they didn't exist
+	disableInvalid invalidPolicy = "disable"
+	// failClosedInvalid rejects every request an invalid constraint matches
+	failClosedInvalid invalidPolicy = "fail-closed"
+)
+
+var invalidConstraints = disableInvalid
+
+func init() {
+	flag.Var(&invalidConstraints, "invalid-constraint-policy", "how constraints whose parameters don't match their template's schema are handled: disable (unload them) or fail-closed (reject the requests they match)")
+}
+
+var _ flag.Value = new(invalidPolicy)
+
+func (p *invalidPolicy) String() string {
+	return string(*p)
+}
+
+func (p *invalidPolicy) Set(s string) error {
+	switch invalidPolicy(s) {
+	case disableInvalid, failClosedInvalid:
+		*p = invalidPolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid constraint policy %q, expected one of disable or fail-closed", s)
+}
+
+// validate validates the constraint against its template's current schema.
+// An unrecognized kind is not a validation error, the template may not be
+// loaded yet.
+func (r *ReconcileConstraint) validate(instance *unstructured.Unstructured) error {
+	obj := instance.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	err := r.opa.ValidateConstraint(context.TODO(), obj)
+	if _, ok := err.(*opa.UnrecognizedConstraintError); ok {
+		return nil
+	}
+	return err
+}
+
+// loadInvalid loads an invalid constraint according to the policy and returns
+// whether it is enforced. Under fail-closed it is replaced by a stand-in that
+// rejects the requests it matches, under disable it is removed.
+func (r *ReconcileConstraint) loadInvalid(instance *unstructured.Unstructured, verr error) (bool, error) {
+	r.log.Info(
+		"constraint parameters do not match the template schema",
+		logging.EventType, "constraint_invalid",
+		logging.ConstraintName, instance.GetName(),
+		logging.ConstraintKind, instance.GetKind(),
+		"policy", string(invalidConstraints),
+		"error", verr.Error(),
+	)
+	defer decisioncache.Invalidate()
+	if invalidConstraints == failClosedInvalid {
+		_, err := r.opa.AddConstraint(context.Background(), invalidparams.StandIn(instance, verr.Error()))
+		if err == nil {
+			return true, nil
+		}
+		// a stand-in can still be invalid, e.g. if the schema requires its
+		// parameters, in which case the constraint is disabled
+		r.log.Error(err, "could not load a fail-closed stand-in, disabling the constraint", logging.ConstraintName, instance.GetName(), logging.ConstraintKind, instance.GetKind())
+	}
+	if _, err := r.opa.RemoveConstraint(context.Background(), instance); err != nil {
+		if _, ok := err.(*opa.UnrecognizedConstraintError); !ok {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// invalidStatus is the status error reported for an invalid constraint
+func invalidStatus(verr error, enforced bool) csutil.Error {
+	action := "it is not enforced"
+	if enforced {
+		action = "requests it matches are rejected"
+	}
+	return csutil.Error{
+		Code:    invalidParametersCode,
+		Message: fmt.Sprintf("Parameters do not match the template schema, %s: %v", action, verr),
+	}
+}
@@ -17,6 +17,7 @@ package constrainttemplate
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"reflect"
 	"time"
@@ -25,8 +26,17 @@ import (
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/crdconflict"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
+	"github.com/open-policy-agent/gatekeeper/pkg/detailsschema"
+	"github.com/open-policy-agent/gatekeeper/pkg/deterministic"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/objdiff"
+	"github.com/open-policy-agent/gatekeeper/pkg/paramschema"
+	"github.com/open-policy-agent/gatekeeper/pkg/prune"
+	"github.com/open-policy-agent/gatekeeper/pkg/syncdata"
+	"github.com/open-policy-agent/gatekeeper/pkg/templatelint"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	constraintutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
@@ -54,10 +64,16 @@ import (
 const (
 	finalizerName = "constrainttemplate.finalizers.gatekeeper.sh"
 	ctrlName      = "constrainttemplate-controller"
+
+	// notEstablishedRequeueInterval is how often a template whose constraint
+	// CRD is not served yet retries watching its constraints
+	notEstablishedRequeueInterval = 5 * time.Second
 )
 
 var log = logf.Log.WithName("controller").WithValues("kind", "ConstraintTemplate", logging.Process, "constraint_template_controller")
 
+var maxConcurrentReconciles = flag.Int("max-concurrent-template-reconciles", 1, "number of constraint templates reconciled in parallel. Each reconcile compiles the template's Rego, so higher values load many templates faster at the cost of CPU, memory and API server requests. defaulted to 1 if unspecified ")
+
 type Adder struct {
 	Opa              *opa.Client
 	WatchManager     *watch.Manager
@@ -129,7 +145,7 @@ func newReconciler(mgr manager.Manager, opa *opa.Client, wm *watch.Manager, cs *
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(ctrlName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(ctrlName, mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: *maxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
@@ -152,6 +168,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for templates whose required sync data became (un)available
+	err = c.Watch(&source.Channel{Source: syncdata.Templates.Events()}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -237,6 +259,7 @@ func (r *ReconcileConstraintTemplate) Reconcile(request reconcile.Request) (reco
 
 	status := util.GetCTHAStatus(ct)
 	status.Errors = nil
+	status.Warnings = nil
 	unversionedCT := &templates.ConstraintTemplate{}
 	if err := r.scheme.Convert(ct, unversionedCT, nil); err != nil {
 		r.metrics.registry.add(request.NamespacedName, metrics.ErrorStatus)
@@ -297,6 +320,24 @@ func (r *ReconcileConstraintTemplate) Reconcile(request reconcile.Request) (reco
 		return reconcile.Result{}, err
 	}
 
+	// Conflicts are checked before the template reaches OPA so a losing
+	// template never evaluates constraints for a CRD it doesn't own
+	templateList := &v1beta1.ConstraintTemplateList{}
+	if err := r.List(context.TODO(), templateList); err != nil {
+		logError(request.NamespacedName.Name)
+		r.metrics.registry.add(request.NamespacedName, metrics.ErrorStatus)
+		return reconcile.Result{}, err
+	}
+	if conflictErr := crdconflict.Check(ct, currentCRD, templateList.Items); conflictErr != nil {
+		log.Info("constraint CRD conflict", logging.EventType, "template_crd_conflict", "reason", conflictErr.Error())
+		logError(request.NamespacedName.Name)
+		r.metrics.registry.add(request.NamespacedName, metrics.ErrorStatus)
+		if err := r.reportErrorOnCTStatus("crd_conflict", "Could not take ownership of CRD", ct, conflictErr); err != conflictErr {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: crdconflict.RequeueInterval}, nil
+	}
+
 	result, err := r.handleUpdate(ct, unversionedCT, proposedCRD, currentCRD)
 	if err != nil {
 		logError(request.NamespacedName.Name)
@@ -311,6 +352,7 @@ func (r *ReconcileConstraintTemplate) Reconcile(request reconcile.Request) (reco
 func (r *ReconcileConstraintTemplate) reportErrorOnCTStatus(code, message string, ct *v1beta1.ConstraintTemplate, err error) error {
 	status := util.GetCTHAStatus(ct)
 	status.Errors = []*v1beta1.CreateCRDError{}
+	status.Warnings = nil
 	createErr := &v1beta1.CreateCRDError{
 		Code:    code,
 		Message: fmt.Sprintf("%s: %s", message, err),
@@ -329,6 +371,13 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 	proposedCRD, currentCRD *apiextensionsv1beta1.CustomResourceDefinition) (reconcile.Result, error) {
 	name := proposedCRD.GetName()
 	log := log.WithValues("name", ct.GetName(), "crdName", name)
+	// cached decisions depend on the template and on the registries below
+	defer decisioncache.Invalidate()
+
+	if err := deterministic.Verify(unversionedCT); err != nil {
+		err := r.reportErrorOnCTStatus("determinism_error", "Could not ingest Rego", ct, err)
+		return reconcile.Result{}, err
+	}
 
 	log.Info("loading code into OPA")
 	beginCompile := time.Now()
@@ -348,6 +397,39 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 		log.Error(err, "failed to report constraint template ingestion duration")
 	}
 
+	warnings := templatelint.Warnings(unversionedCT)
+	if len(warnings) > 0 {
+		log.Info("template has lint warnings", logging.EventType, templatelint.Code, logging.TemplateName, ct.GetName(), "warnings", warnings)
+		status := util.GetCTHAStatus(ct)
+		status.Warnings = append(status.Warnings, templatelint.StatusWarnings(warnings)...)
+		util.SetCTHAStatus(ct, status)
+	}
+	r.metrics.registry.setWarnings(types.NamespacedName{Name: ct.GetName()}, len(warnings) > 0)
+
+	if err := detailsschema.Templates.Set(ct.Spec.CRD.Spec.Names.Kind, ct.GetAnnotations()[detailsschema.Annotation]); err != nil {
+		err := r.reportErrorOnCTStatus("details_schema_error", "Could not parse violation details schema", ct, err)
+		return reconcile.Result{}, err
+	}
+	requiredData, err := syncdata.Parse(ct.GetAnnotations()[syncdata.Annotation])
+	if err != nil {
+		err := r.reportErrorOnCTStatus("requires_sync_data_error", "Could not parse required sync data", ct, err)
+		return reconcile.Result{}, err
+	}
+	syncdata.Templates.Set(ct.GetName(), requiredData)
+	if err := paramschema.Templates.Set(ct.Spec.CRD.Spec.Names.Kind, unversionedCT); err != nil {
+		err := r.reportErrorOnCTStatus("parameter_schema_error", "Could not load parameter schema", ct, err)
+		return reconcile.Result{}, err
+	}
+	var modules []string
+	for _, target := range unversionedCT.Spec.Targets {
+		modules = append(modules, target.Rego)
+		modules = append(modules, target.Libs...)
+	}
+	prune.Templates.Set(ct.Spec.CRD.Spec.Names.Kind, modules...)
+	prune.Templates.SetManagedFields(ct.Spec.CRD.Spec.Names.Kind, ct.GetAnnotations()[prune.ManagedFieldsAnnotation] == "true")
+	objdiff.Templates.Set(ct.Spec.CRD.Spec.Names.Kind, ct.GetAnnotations()[objdiff.Annotation] == "true")
+	deterministic.Templates.Set(ct.Spec.CRD.Spec.Names.Kind, deterministic.Declared(unversionedCT))
+
 	var newCRD *apiextensionsv1beta1.CustomResourceDefinition
 	if currentCRD == nil {
 		newCRD = proposedCRD.DeepCopy()
@@ -360,6 +442,7 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 		return reconcile.Result{}, err
 	}
 
+	crdChanged := false
 	if currentCRD == nil {
 		log.Info("creating crd")
 		if err := r.Create(context.TODO(), newCRD); err != nil {
@@ -367,29 +450,61 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 			return reconcile.Result{}, err
 		}
 	} else if !reflect.DeepEqual(newCRD, currentCRD) {
+		crdChanged = true
 		log.Info("updating crd")
 		if err := r.Update(context.Background(), newCRD); err != nil {
 			err := r.reportErrorOnCTStatus("update_error", "Could not update CRD", ct, err)
 			return reconcile.Result{}, err
 		}
 	}
+	result := reconcile.Result{}
 	// This must go after CRD creation/update as otherwise AddWatch will always fail
 	log.Info("making sure constraint is in watcher registry")
 	if err := r.watcher.AddWatch(makeGvk(ct.Spec.CRD.Spec.Names.Kind)); err != nil {
-		log.Error(err, "error adding template to watch registry")
-		return reconcile.Result{}, err
+		if !watch.IsNotEstablished(err) {
+			log.Error(err, "error adding template to watch registry")
+			return reconcile.Result{}, err
+		}
+		// A new CRD takes a moment to be served. Its constraints are loaded
+		// once it is, so this is reported as transient rather than failing.
+		log.Info("constraint CRD is not established yet", logging.EventType, "constraint_crd_not_established", logging.TemplateName, ct.GetName())
+		status := util.GetCTHAStatus(ct)
+		status.Warnings = append(status.Warnings, &v1beta1.CreateCRDError{
+			Code:    "crd_not_established",
+			Message: fmt.Sprintf("CRD %s is not served yet, its constraints will be loaded once it is established", name),
+		})
+		util.SetCTHAStatus(ct, status)
+		result.RequeueAfter = notEstablishedRequeueInterval
+	} else if crdChanged {
+		// existing constraints are revalidated against the changed schema
+		r.watcher.Replay(makeGvk(ct.Spec.CRD.Spec.Names.Kind))
+	}
+	// the template is loaded either way, but won't see the data it needs
+	// until the missing kinds are synced. It is reconciled again when the
+	// synced kinds change.
+	missing := syncdata.Templates.Missing(ct.GetName())
+	r.metrics.registry.setMissingSyncData(types.NamespacedName{Name: ct.GetName()}, len(missing) > 0)
+	if len(missing) > 0 {
+		log.Info("template requires data that is not synced", logging.EventType, "template_missing_sync_data", logging.TemplateName, ct.GetName(), "missing", fmt.Sprintf("%v", missing))
+		status := util.GetCTHAStatus(ct)
+		status.Warnings = append(status.Warnings, &v1beta1.CreateCRDError{
+			Code:    "missing_sync_data",
+			Message: fmt.Sprintf("Required kinds are not synced: %v", missing),
+		})
+		util.SetCTHAStatus(ct, status)
 	}
 	ct.Status.Created = true
 	if err := r.Status().Update(context.Background(), ct); err != nil {
 		log.Error(err, "update error")
 		return reconcile.Result{Requeue: true}, nil
 	}
-	return reconcile.Result{}, nil
+	return result, nil
 }
 
 func (r *ReconcileConstraintTemplate) handleDelete(
 	ct *templates.ConstraintTemplate) (reconcile.Result, error) {
 	log := log.WithValues("name", ct.GetName())
+	defer decisioncache.Invalidate()
 	log.Info("removing from watcher registry")
 	if err := r.watcher.RemoveWatch(makeGvk(ct.Spec.CRD.Spec.Names.Kind)); err != nil {
 		return reconcile.Result{}, err
@@ -399,6 +514,12 @@ func (r *ReconcileConstraintTemplate) handleDelete(
 	if _, err := r.opa.RemoveTemplate(context.Background(), ct); err != nil {
 		return reconcile.Result{}, err
 	}
+	detailsschema.Templates.Remove(ct.Spec.CRD.Spec.Names.Kind)
+	prune.Templates.Remove(ct.Spec.CRD.Spec.Names.Kind)
+	objdiff.Templates.Remove(ct.Spec.CRD.Spec.Names.Kind)
+	deterministic.Templates.Remove(ct.Spec.CRD.Spec.Names.Kind)
+	paramschema.Templates.Remove(ct.Spec.CRD.Spec.Names.Kind)
+	syncdata.Templates.Remove(ct.GetName())
 	return reconcile.Result{}, nil
 }
 
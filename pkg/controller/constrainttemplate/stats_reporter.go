@@ -2,6 +2,7 @@ package constrainttemplate
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
@@ -15,13 +16,19 @@ const (
 	ctMetricName   = "constraint_templates"
 	ingestCount    = "constraint_template_ingestion_count"
 	ingestDuration = "constraint_template_ingestion_duration_seconds"
+	warningsName   = "constraint_templates_with_warnings"
+	missingName    = "constraint_templates_missing_sync_data"
 
-	ctDesc = "Number of observed constraint templates"
+	ctDesc       = "Number of observed constraint templates"
+	warningsDesc = "Number of constraint templates whose Rego has lint warnings"
+	missingDesc  = "Number of constraint templates that require kinds that are not synced"
 )
 
 var (
 	ctM             = stats.Int64(ctMetricName, ctDesc, stats.UnitDimensionless)
 	ingestDurationM = stats.Float64(ingestDuration, "How long it took to ingest a constraint template in seconds", stats.UnitSeconds)
+	warningsM       = stats.Int64(warningsName, warningsDesc, stats.UnitDimensionless)
+	missingM        = stats.Int64(missingName, missingDesc, stats.UnitDimensionless)
 
 	statusKey = tag.MustNewKey("status")
 
@@ -47,6 +54,18 @@ var (
 			Aggregation: view.Distribution(0.01, 0.02, 0.03, 0.04, 0.05, 0.06, 0.07, 0.08, 0.09, 0.1, 0.2, 0.3, 0.4, 0.5, 1, 2, 3, 4, 5),
 			TagKeys:     []tag.Key{statusKey},
 		},
+		{
+			Name:        warningsName,
+			Measure:     warningsM,
+			Description: warningsDesc,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        missingName,
+			Measure:     missingM,
+			Description: missingDesc,
+			Aggregation: view.LastValue(),
+		},
 	}
 )
 
@@ -76,6 +95,14 @@ func (r *reporter) reportCtMetric(status metrics.Status, count int64) error {
 	return metrics.Record(ctx, ctM.M(count))
 }
 
+func (r *reporter) reportWarnings(count int64) error {
+	return metrics.Record(r.ctx, warningsM.M(count))
+}
+
+func (r *reporter) reportMissingSyncData(count int64) error {
+	return metrics.Record(r.ctx, missingM.M(count))
+}
+
 func (r *reporter) reportIngestDuration(status metrics.Status, d time.Duration) error {
 	ctx, err := tag.New(
 		r.ctx,
@@ -96,7 +123,11 @@ func newStatsReporter() (*reporter, error) {
 	if err != nil {
 		return nil, err
 	}
-	reg := &ctRegistry{cache: make(map[types.NamespacedName]metrics.Status)}
+	reg := &ctRegistry{
+		cache:    make(map[types.NamespacedName]metrics.Status),
+		warnings: make(map[types.NamespacedName]bool),
+		missing:  make(map[types.NamespacedName]bool),
+	}
 	return &reporter{ctx: ctx, registry: reg}, nil
 }
 
@@ -105,12 +136,20 @@ type reporter struct {
 	registry *ctRegistry
 }
 
+// ctRegistry is shared by the controller's workers
 type ctRegistry struct {
+	mux   sync.Mutex
 	cache map[types.NamespacedName]metrics.Status
-	dirty bool
+	// warnings holds the templates that have lint warnings
+	warnings map[types.NamespacedName]bool
+	// missing holds the templates that require kinds that are not synced
+	missing map[types.NamespacedName]bool
+	dirty   bool
 }
 
 func (r *ctRegistry) add(key types.NamespacedName, status metrics.Status) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
 	v, ok := r.cache[key]
 	if ok && v == status {
 		return
@@ -119,7 +158,36 @@ func (r *ctRegistry) add(key types.NamespacedName, status metrics.Status) {
 	r.dirty = true
 }
 
+func (r *ctRegistry) setWarnings(key types.NamespacedName, hasWarnings bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.setFlag(r.warnings, key, hasWarnings)
+}
+
+func (r *ctRegistry) setMissingSyncData(key types.NamespacedName, isMissing bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.setFlag(r.missing, key, isMissing)
+}
+
+// setFlag records whether the template is in flags. r.mux must be held.
+func (r *ctRegistry) setFlag(flags map[types.NamespacedName]bool, key types.NamespacedName, value bool) {
+	if flags[key] == value {
+		return
+	}
+	if value {
+		flags[key] = true
+	} else {
+		delete(flags, key)
+	}
+	r.dirty = true
+}
+
 func (r *ctRegistry) remove(key types.NamespacedName) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.setFlag(r.warnings, key, false)
+	r.setFlag(r.missing, key, false)
 	if _, ok := r.cache[key]; !ok {
 		return
 	}
@@ -128,6 +196,8 @@ func (r *ctRegistry) remove(key types.NamespacedName) {
 }
 
 func (r *ctRegistry) report(mReporter *reporter) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
 	if !r.dirty {
 		return
 	}
@@ -142,6 +212,14 @@ func (r *ctRegistry) report(mReporter *reporter) {
 			hadErr = true
 		}
 	}
+	if err := mReporter.reportWarnings(int64(len(r.warnings))); err != nil {
+		log.Error(err, "failed to report constraint templates with warnings")
+		hadErr = true
+	}
+	if err := mReporter.reportMissingSyncData(int64(len(r.missing))); err != nil {
+		log.Error(err, "failed to report constraint templates missing sync data")
+		hadErr = true
+	}
 	if !hadErr {
 		r.dirty = false
 	}
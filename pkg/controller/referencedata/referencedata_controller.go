@@ -0,0 +1,163 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package referencedata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/open-policy-agent/gatekeeper/pkg/watch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const ctrlName = "referencedata-controller"
+
+// Label marks a ConfigMap in the Gatekeeper namespace as reference data
+const Label = "data.gatekeeper.sh/reference"
+
+var log = logf.Log.WithName("controller").WithValues("kind", "ConfigMap")
+
+type Adder struct {
+	Opa              *opa.Client
+	ControllerSwitch *watch.ControllerSwitch
+}
+
+// Add creates a new reference data controller and adds it to the Manager. The
+// ConfigMaps are read from a cache restricted to the Gatekeeper namespace, so
+// ConfigMaps in other namespaces are never cached.
+func (a *Adder) Add(mgr manager.Manager) error {
+	c, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: util.GetNamespace(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(c); err != nil {
+		return err
+	}
+	informer, err := c.GetInformer(&corev1.ConfigMap{})
+	if err != nil {
+		return err
+	}
+	r := &ReconcileReferenceData{reader: c, opa: a.Opa, cs: a.ControllerSwitch}
+	return add(mgr, r, informer)
+}
+
+func (a *Adder) InjectOpa(o *opa.Client) {
+	a.Opa = o
+}
+
+func (a *Adder) InjectWatchManager(wm *watch.Manager) {}
+
+func (a *Adder) InjectControllerSwitch(cs *watch.ControllerSwitch) {
+	a.ControllerSwitch = cs
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler, informer cache.Informer) error {
+	c, err := controller.New(ctrlName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Every ConfigMap is reconciled, so removing the label unloads it
+	return c.Watch(&source.Informer{Informer: informer}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileReferenceData{}
+
+// ReconcileReferenceData loads labeled ConfigMaps into data.inventory.reference
+type ReconcileReferenceData struct {
+	reader client.Reader
+	opa    syncc.OpaDataClient
+	cs     *watch.ControllerSwitch
+}
+
+// Reconcile loads or unloads the reference data of a ConfigMap
+func (r *ReconcileReferenceData) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Short-circuit if shutting down.
+	if r.cs != nil {
+		running := r.cs.Enter()
+		defer r.cs.Exit()
+		if !running {
+			return reconcile.Result{}, nil
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := r.reader.Get(context.TODO(), request.NamespacedName, cm)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	if errors.IsNotFound(err) || cm.GetLabels()[Label] != "true" || !cm.GetDeletionTimestamp().IsZero() {
+		defer decisioncache.Invalidate()
+		if _, err := r.opa.RemoveData(context.Background(), &target.ReferenceData{Name: request.Name}); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	data, err := Parse(cm)
+	if err != nil {
+		// retrying won't help until the ConfigMap is fixed, which requeues it
+		log.Error(err, "invalid reference data, keeping the last valid contents", logging.EventType, "reference_data_error", "name", cm.GetName())
+		return reconcile.Result{}, nil
+	}
+	defer decisioncache.Invalidate()
+	if _, err := r.opa.AddData(context.Background(), &target.ReferenceData{Name: cm.GetName(), Data: data}); err != nil {
+		return reconcile.Result{}, err
+	}
+	log.Info("loaded reference data", logging.EventType, "reference_data_loaded", "name", cm.GetName(), "keys", len(data))
+	return reconcile.Result{}, nil
+}
+
+// Parse parses every value of the ConfigMap as YAML or JSON. A value that is
+// neither is an error, naming its key.
+func Parse(cm *corev1.ConfigMap) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(cm.Data))
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	data := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(cm.Data[k]), &v); err != nil {
+			return nil, fmt.Errorf("parsing key %q: %w", k, err)
+		}
+		data[k] = v
+	}
+	return data, nil
+}
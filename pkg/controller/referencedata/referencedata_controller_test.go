@@ -0,0 +1,115 @@
+package referencedata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeReader struct {
+	client.Reader
+	cm *corev1.ConfigMap
+}
+
+func (f *fakeReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if f.cm == nil {
+		return errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+	}
+	f.cm.DeepCopyInto(obj.(*corev1.ConfigMap))
+	return nil
+}
+
+type fakeOpa struct {
+	data map[string]interface{}
+}
+
+func (f *fakeOpa) AddData(ctx context.Context, data interface{}) (*types.Responses, error) {
+	r := data.(*target.ReferenceData)
+	f.data[r.Name] = r.Data
+	return &types.Responses{}, nil
+}
+
+func (f *fakeOpa) RemoveData(ctx context.Context, data interface{}) (*types.Responses, error) {
+	delete(f.data, data.(*target.ReferenceData).Name)
+	return &types.Responses{}, nil
+}
+
+func TestParse(t *testing.T) {
+	cm := &corev1.ConfigMap{Data: map[string]string{
+		"json":   `{"a": [1, 2]}`,
+		"yaml":   "registries:\n- gcr.io\n- quay.io\n",
+		"scalar": "hello",
+	}}
+	got, err := Parse(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"json":   map[string]interface{}{"a": []interface{}{float64(1), float64(2)}},
+		"yaml":   map[string]interface{}{"registries": []interface{}{"gcr.io", "quay.io"}},
+		"scalar": "hello",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+
+	cm.Data["bad"] = "a: b: c"
+	if _, err := Parse(cm); err == nil {
+		t.Error("expected an error for an unparseable value")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "registries", Labels: map[string]string{Label: "true"}},
+		Data:       map[string]string{"allowed": `["gcr.io"]`},
+	}
+	reader := &fakeReader{cm: cm}
+	opa := &fakeOpa{data: make(map[string]interface{})}
+	r := &ReconcileReferenceData{reader: reader, opa: opa}
+	req := reconcile.Request{NamespacedName: client.ObjectKey{Name: "registries"}}
+
+	reconcileOrFail := func() {
+		t.Helper()
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcileOrFail()
+	want := map[string]interface{}{"allowed": []interface{}{"gcr.io"}}
+	if !reflect.DeepEqual(opa.data["registries"], want) {
+		t.Fatalf("loaded %v, want %v", opa.data["registries"], want)
+	}
+
+	// invalid contents leave the last valid contents loaded
+	cm.Data["allowed"] = "a: b: c"
+	reconcileOrFail()
+	if !reflect.DeepEqual(opa.data["registries"], want) {
+		t.Errorf("loaded %v, want %v", opa.data["registries"], want)
+	}
+
+	cm.Labels = nil
+	reconcileOrFail()
+	if _, ok := opa.data["registries"]; ok {
+		t.Error("expected unlabeled reference data to be unloaded")
+	}
+
+	cm.Labels = map[string]string{Label: "true"}
+	cm.Data["allowed"] = `["quay.io"]`
+	reconcileOrFail()
+	reader.cm = nil
+	reconcileOrFail()
+	if _, ok := opa.data["registries"]; ok {
+		t.Error("expected deleted reference data to be unloaded")
+	}
+}
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Freshness tracks how long the synced data has been incomplete
+var Freshness = NewFreshnessTracker(time.Now())
+
+// FreshnessTracker records, for each synced kind whose data in OPA is
+// incomplete, when it became incomplete. A kind is incomplete from the time
+// it starts being synced, or its data is wiped, until all of its objects
+// have been added.
+type FreshnessTracker struct {
+	mux          sync.RWMutex
+	staleSince   map[schema.GroupVersionKind]time.Time
+	loaded       bool
+	loadingSince time.Time
+}
+
+// NewFreshnessTracker returns a tracker for a process started at start.
+// Nothing is known to be synced until MarkLoaded is called.
+func NewFreshnessTracker(start time.Time) *FreshnessTracker {
+	return &FreshnessTracker{
+		staleSince:   make(map[schema.GroupVersionKind]time.Time),
+		loadingSince: start,
+	}
+}
+
+// Replace sets the synced kinds, all of which are stale as of now. Kinds
+// that were already stale keep their original time.
+func (f *FreshnessTracker) Replace(gvks []schema.GroupVersionKind, now time.Time) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	staleSince := make(map[schema.GroupVersionKind]time.Time, len(gvks))
+	for _, gvk := range gvks {
+		if since, ok := f.staleSince[gvk]; ok {
+			staleSince[gvk] = since
+			continue
+		}
+		staleSince[gvk] = now
+	}
+	f.staleSince = staleSince
+}
+
+// MarkCurrent records that all objects of the kind have been synced
+func (f *FreshnessTracker) MarkCurrent(gvk schema.GroupVersionKind) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	delete(f.staleSince, gvk)
+}
+
+// MarkLoaded records that the sync config has been read, so kinds that are
+// not tracked are not synced
+func (f *FreshnessTracker) MarkLoaded() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.loaded = true
+}
+
+// Staleness returns how long the most out of date synced kind has been
+// incomplete, or 0 if all synced data is current
+func (f *FreshnessTracker) Staleness(now time.Time) time.Duration {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	var staleness time.Duration
+	if !f.loaded {
+		staleness = now.Sub(f.loadingSince)
+	}
+	for _, since := range f.staleSince {
+		if d := now.Sub(since); d > staleness {
+			staleness = d
+		}
+	}
+	return staleness
+}
+
+// Unsynced returns the kinds, out of gvks, whose data is incomplete. All of
+// them are incomplete until the sync config has been read. Kinds that are
+// not synced at all are not returned once it has.
+func (f *FreshnessTracker) Unsynced(gvks []schema.GroupVersionKind) []schema.GroupVersionKind {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	if !f.loaded {
+		return gvks
+	}
+	var unsynced []schema.GroupVersionKind
+	for _, gvk := range gvks {
+		if _, ok := f.staleSince[gvk]; ok {
+			unsynced = append(unsynced, gvk)
+		}
+	}
+	return unsynced
+}
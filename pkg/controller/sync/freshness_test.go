@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFreshnessTracker(t *testing.T) {
+	start := time.Now()
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	namespaces := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	f := NewFreshnessTracker(start)
+
+	if got := f.Staleness(start.Add(time.Minute)); got != time.Minute {
+		t.Errorf("before loading, Staleness() = %v, want %v", got, time.Minute)
+	}
+
+	f.Replace([]schema.GroupVersionKind{pods, namespaces}, start.Add(time.Minute))
+	f.MarkCurrent(namespaces)
+	f.MarkLoaded()
+	if got := f.Staleness(start.Add(2 * time.Minute)); got != time.Minute {
+		t.Errorf("while pods are replayed, Staleness() = %v, want %v", got, time.Minute)
+	}
+
+	// pods keep the time they became stale across a second replace
+	f.Replace([]schema.GroupVersionKind{pods, namespaces}, start.Add(2*time.Minute))
+	if got := f.Staleness(start.Add(3 * time.Minute)); got != 2*time.Minute {
+		t.Errorf("after a second replace, Staleness() = %v, want %v", got, 2*time.Minute)
+	}
+
+	f.MarkCurrent(pods)
+	f.MarkCurrent(namespaces)
+	if got := f.Staleness(start.Add(3 * time.Minute)); got != 0 {
+		t.Errorf("once current, Staleness() = %v, want 0", got)
+	}
+}
+
+func TestUnsynced(t *testing.T) {
+	start := time.Now()
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	namespaces := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	nodes := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	required := []schema.GroupVersionKind{pods, namespaces, nodes}
+	f := NewFreshnessTracker(start)
+
+	if got := f.Unsynced(required); !reflect.DeepEqual(got, required) {
+		t.Errorf("before loading, Unsynced() = %v, want %v", got, required)
+	}
+
+	// nodes are not synced, so they never become current
+	f.Replace([]schema.GroupVersionKind{pods, namespaces}, start)
+	f.MarkCurrent(namespaces)
+	f.MarkLoaded()
+	if got := f.Unsynced(required); !reflect.DeepEqual(got, []schema.GroupVersionKind{pods}) {
+		t.Errorf("while pods are replayed, Unsynced() = %v, want %v", got, []schema.GroupVersionKind{pods})
+	}
+
+	f.MarkCurrent(pods)
+	if got := f.Unsynced(required); len(got) != 0 {
+		t.Errorf("once current, Unsynced() = %v, want none", got)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 
 	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -52,6 +53,7 @@ func (f *FilteredDataClient) AddData(ctx context.Context, data interface{}) (*ty
 		}
 	}
 
+	defer decisioncache.Invalidate()
 	return f.opa.AddData(ctx, data)
 }
 
@@ -65,5 +67,6 @@ func (f *FilteredDataClient) RemoveData(ctx context.Context, data interface{}) (
 		}
 	}
 
+	defer decisioncache.Invalidate()
 	return f.opa.RemoveData(ctx, data)
 }
@@ -0,0 +1,98 @@
+package crdconflict
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// rejectConflicts leaves a conflicting CRD untouched
+	rejectConflicts = "reject"
+	// adoptOrphaned takes over CRDs whose owning template no longer exists
+	adoptOrphaned = "adopt-orphaned"
+
+	// RequeueInterval is how often a template that lost a conflict checks
+	// whether the conflict has been resolved
+	RequeueInterval = time.Minute
+)
+
+var policy = flag.String("crd-conflict-policy", rejectConflicts, fmt.Sprintf("how a constraint template handles a constraint CRD controlled by another owner. %q leaves the CRD alone and reports a crd_conflict error on the template, %q also takes over CRDs whose owning constraint template was deleted", rejectConflicts, adoptOrphaned))
+
+// Check returns an error describing why ct must not take ownership of the CRD
+// it generates. currentCRD is the existing CRD, if any, and templates are all
+// constraint templates in the cluster. When several templates claim the same
+// short name, the oldest one wins.
+func Check(ct *v1beta1.ConstraintTemplate, currentCRD *apiextensionsv1beta1.CustomResourceDefinition, templates []v1beta1.ConstraintTemplate) error {
+	if currentCRD != nil {
+		if owner := metav1.GetControllerOf(currentCRD); owner != nil && owner.UID != ct.GetUID() {
+			if !isOrphanedBy(owner, templates) || *policy != adoptOrphaned {
+				return fmt.Errorf("CRD %s is controlled by %s %s", currentCRD.GetName(), owner.Kind, owner.Name)
+			}
+		}
+	}
+
+	claimed := make(map[string]bool)
+	for _, n := range ct.Spec.CRD.Spec.Names.ShortNames {
+		claimed[n] = true
+	}
+	for _, other := range olderTemplates(ct, templates) {
+		for _, n := range other.Spec.CRD.Spec.Names.ShortNames {
+			if claimed[n] {
+				return fmt.Errorf("short name %q is already used by constraint template %s", n, other.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// generatesCRD returns true if the template can generate a constraint CRD.
+// Templates must be named after their lowercase kind, so two templates that
+// do never generate the same CRD.
+func generatesCRD(ct *v1beta1.ConstraintTemplate) bool {
+	return ct.GetName() == strings.ToLower(ct.Spec.CRD.Spec.Names.Kind)
+}
+
+// isOrphanedBy returns true if owner is a constraint template that no
+// longer exists
+func isOrphanedBy(owner *metav1.OwnerReference, templates []v1beta1.ConstraintTemplate) bool {
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil || gv.Group != v1beta1.SchemeGroupVersion.Group || owner.Kind != "ConstraintTemplate" {
+		return false
+	}
+	for _, t := range templates {
+		if t.GetUID() == owner.UID {
+			return false
+		}
+	}
+	return true
+}
+
+// olderTemplates returns the templates, other than ct, that generate a CRD
+// and were created before ct, ordered oldest first. Templates created at the
+// same time are ordered by name.
+func olderTemplates(ct *v1beta1.ConstraintTemplate, templates []v1beta1.ConstraintTemplate) []v1beta1.ConstraintTemplate {
+	var older []v1beta1.ConstraintTemplate
+	for _, t := range templates {
+		if t.GetName() != ct.GetName() && generatesCRD(&t) && isOlder(&t, ct) {
+			older = append(older, t)
+		}
+	}
+	sort.Slice(older, func(i, j int) bool { return isOlder(&older[i], &older[j]) })
+	return older
+}
+
+func isOlder(a, b *v1beta1.ConstraintTemplate) bool {
+	ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !ta.Equal(&tb) {
+		return ta.Before(&tb)
+	}
+	return a.GetName() < b.GetName()
+}
@@ -0,0 +1,130 @@
+package crdconflict
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTemplate(name string, uid types.UID, created time.Time, shortNames ...string) v1beta1.ConstraintTemplate {
+	return newTemplateWithKind(name, strings.Title(name), uid, created, shortNames...)
+}
+
+func newTemplateWithKind(name, kind string, uid types.UID, created time.Time, shortNames ...string) v1beta1.ConstraintTemplate {
+	ct := v1beta1.ConstraintTemplate{}
+	ct.SetName(name)
+	ct.Spec.CRD.Spec.Names.Kind = kind
+	ct.SetUID(uid)
+	ct.SetCreationTimestamp(metav1.NewTime(created))
+	ct.Spec.CRD.Spec.Names.ShortNames = shortNames
+	return ct
+}
+
+func newCRD(owner *metav1.OwnerReference) *apiextensionsv1beta1.CustomResourceDefinition {
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{}
+	crd.SetName("foo.constraints.gatekeeper.sh")
+	if owner != nil {
+		isController := true
+		owner.Controller = &isController
+		crd.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	return crd
+}
+
+func templateOwner(name string, uid types.UID) *metav1.OwnerReference {
+	return &metav1.OwnerReference{
+		APIVersion: v1beta1.SchemeGroupVersion.String(),
+		Kind:       "ConstraintTemplate",
+		Name:       name,
+		UID:        uid,
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	foo := newTemplate("foo", "foo-uid", now, "fo")
+	tc := []struct {
+		Name      string
+		Policy    string
+		CRD       *apiextensionsv1beta1.CustomResourceDefinition
+		Templates []v1beta1.ConstraintTemplate
+		ExpectErr bool
+	}{
+		{
+			Name: "No CRD",
+		},
+		{
+			Name: "No controller",
+			CRD:  newCRD(nil),
+		},
+		{
+			Name: "Owned by the template",
+			CRD:  newCRD(templateOwner("foo", "foo-uid")),
+		},
+		{
+			Name:      "Owned by another controller",
+			CRD:       newCRD(&metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Operator", Name: "op", UID: "op-uid"}),
+			ExpectErr: true,
+		},
+		{
+			Name:      "Orphaned, rejected",
+			CRD:       newCRD(templateOwner("foo", "old-uid")),
+			ExpectErr: true,
+		},
+		{
+			Name:   "Orphaned, adopted",
+			Policy: adoptOrphaned,
+			CRD:    newCRD(templateOwner("foo", "old-uid")),
+		},
+		{
+			Name:      "Owner still exists",
+			Policy:    adoptOrphaned,
+			CRD:       newCRD(templateOwner("bar", "bar-uid")),
+			Templates: []v1beta1.ConstraintTemplate{newTemplate("bar", "bar-uid", now)},
+			ExpectErr: true,
+		},
+		{
+			Name:      "Short name used by older template",
+			Templates: []v1beta1.ConstraintTemplate{newTemplate("bar", "bar-uid", now.Add(-time.Hour), "x", "fo")},
+			ExpectErr: true,
+		},
+		{
+			Name:      "Short name used by newer template",
+			Templates: []v1beta1.ConstraintTemplate{newTemplate("bar", "bar-uid", now.Add(time.Hour), "fo")},
+		},
+		{
+			Name:      "Same kind as older invalid template",
+			Templates: []v1beta1.ConstraintTemplate{newTemplateWithKind("foo-legacy", "FOO", "legacy-uid", now.Add(-time.Hour))},
+		},
+		{
+			Name:      "Short name used by older invalid template",
+			Templates: []v1beta1.ConstraintTemplate{newTemplateWithKind("bar-legacy", "Bar", "legacy-uid", now.Add(-time.Hour), "fo")},
+		},
+		{
+			Name:      "Same age, ordered by name",
+			Templates: []v1beta1.ConstraintTemplate{newTemplate("aaa", "aaa-uid", now, "fo"), newTemplate("zzz", "zzz-uid", now, "fo")},
+			ExpectErr: true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			p := rejectConflicts
+			if tt.Policy != "" {
+				p = tt.Policy
+			}
+			defer func(old string) { *policy = old }(*policy)
+			*policy = p
+
+			templates := append([]v1beta1.ConstraintTemplate{foo}, tt.Templates...)
+			err := Check(&foo, tt.CRD, templates)
+			if (err != nil) != tt.ExpectErr {
+				t.Errorf("err = %v, expected error: %v", err, tt.ExpectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// defaultImpactLimit is the number of objects listed per constraint unless
+// ?limit= is passed
+const defaultImpactLimit = 100
+
+const constraintsGroup = "constraints.gatekeeper.sh"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	if err := api.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// Reviewer reviews objects against the constraints loaded into OPA
+type Reviewer interface {
+	Review(ctx context.Context, obj interface{}, opts ...opa.QueryOpt) (*types.Responses, error)
+}
+
+// impactHandler reports which cached objects candidate constraints would
+// find violations in, and which of those the loaded constraints admit today.
+// The candidates are POSTed as {"templates": [...], "constraints": [...]},
+// where templates may be left out for kinds whose template is already in the
+// cluster. Candidates are loaded into a separate OPA client, so they never
+// affect enforcement. ?limit= bounds the objects listed per constraint.
+type impactHandler struct {
+	cache Lister
+	gvks  GVKSource
+	opa   Reviewer
+}
+
+type impactRequest struct {
+	Templates   []*templv1beta1.ConstraintTemplate `json:"templates,omitempty"`
+	Constraints []*unstructured.Unstructured       `json:"constraints"`
+}
+
+type objectImpact struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// CurrentlyDenied is true if a loaded constraint already denies the object
+	CurrentlyDenied bool     `json:"currentlyDenied"`
+	Messages        []string `json:"messages"`
+}
+
+type constraintImpact struct {
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	EnforcementAction string `json:"enforcementAction"`
+	// Violations is the number of objects the constraint finds violations in
+	Violations int `json:"violations"`
+	// NewlyDenied is the number of those objects that no loaded constraint
+	// denies today
+	NewlyDenied int            `json:"newlyDenied"`
+	Objects     []objectImpact `json:"objects"`
+	Truncated   bool           `json:"truncated,omitempty"`
+}
+
+type impactReport struct {
+	// Evaluated is the number of cached objects the candidates were evaluated
+	// against
+	Evaluated   int                `json:"evaluated"`
+	Constraints []constraintImpact `json:"constraints"`
+	Errors      []string           `json:"errors,omitempty"`
+}
+
+// badRequestError is an impact request that can't be evaluated
+type badRequestError struct {
+	error
+}
+
+func (h *impactHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := defaultImpactLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", l), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	req := &impactRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Constraints) == 0 {
+		http.Error(w, "invalid request: constraints are required", http.StatusBadRequest)
+		return
+	}
+	report, err := h.evaluate(r.Context(), req, limit)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if _, ok := err.(badRequestError); ok {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Error(err, "unable to write impact report")
+	}
+}
+
+func (h *impactHandler) evaluate(ctx context.Context, req *impactRequest, limit int) (*impactReport, error) {
+	candidates, err := h.sandbox(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	report := &impactReport{Constraints: []constraintImpact{}}
+	byConstraint := make(map[string]*constraintImpact)
+	for _, c := range req.Constraints {
+		action, _, err := unstructured.NestedString(c.Object, "spec", "enforcementAction")
+		if err != nil || action == "" {
+			action = string(util.Deny)
+		}
+		byConstraint[constraintKey(c)] = &constraintImpact{Kind: c.GetKind(), Name: c.GetName(), EnforcementAction: action, Objects: []objectImpact{}}
+	}
+
+	objects, namespaces, errs := h.snapshot(ctx)
+	report.Errors = errs
+	for i := range objects {
+		if _, err := candidates.AddData(ctx, &objects[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range objects {
+		obj := target.AugmentedUnstructured{Object: objects[i], Namespace: namespaces[objects[i].GetNamespace()]}
+		resp, err := candidates.Review(ctx, obj)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("reviewing %s: %v", objectKey(&objects[i]), err))
+			continue
+		}
+		report.Evaluated++
+		found := make(map[string][]string)
+		for _, res := range resp.Results() {
+			if res.Constraint != nil {
+				found[constraintKey(res.Constraint)] = append(found[constraintKey(res.Constraint)], res.Msg)
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		denied, err := h.currentlyDenied(ctx, obj)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("reviewing %s against loaded constraints: %v", objectKey(&objects[i]), err))
+		}
+		gvk := objects[i].GroupVersionKind()
+		for key, msgs := range found {
+			ci, ok := byConstraint[key]
+			if !ok {
+				continue
+			}
+			sort.Strings(msgs)
+			ci.Violations++
+			if !denied {
+				ci.NewlyDenied++
+			}
+			if len(ci.Objects) >= limit {
+				ci.Truncated = true
+				continue
+			}
+			ci.Objects = append(ci.Objects, objectImpact{
+				Group:           gvk.Group,
+				Version:         gvk.Version,
+				Kind:            gvk.Kind,
+				Namespace:       objects[i].GetNamespace(),
+				Name:            objects[i].GetName(),
+				CurrentlyDenied: denied,
+				Messages:        msgs,
+			})
+		}
+	}
+	for _, ci := range byConstraint {
+		report.Constraints = append(report.Constraints, *ci)
+	}
+	sort.Slice(report.Constraints, func(i, j int) bool {
+		a, b := report.Constraints[i], report.Constraints[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// sandbox returns an OPA client holding only the candidate constraints and
+// their templates
+func (h *impactHandler) sandbox(ctx context.Context, req *impactRequest) (*opa.Client, error) {
+	backend, err := opa.NewBackend(opa.Driver(local.New(local.Tracing(false))))
+	if err != nil {
+		return nil, err
+	}
+	client, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		return nil, err
+	}
+	byKind := make(map[string]*templv1beta1.ConstraintTemplate)
+	for _, t := range req.Templates {
+		byKind[t.Spec.CRD.Spec.Names.Kind] = t
+	}
+	loaded := make(map[string]bool)
+	for _, c := range req.Constraints {
+		kind := c.GetKind()
+		if kind == "" || c.GetName() == "" {
+			return nil, badRequestError{fmt.Errorf("constraint %q must have a kind and a name", c.GetName())}
+		}
+		if !loaded[kind] {
+			templ, ok := byKind[kind]
+			if !ok {
+				if templ, err = h.clusterTemplate(ctx, kind); err != nil {
+					return nil, err
+				}
+			}
+			unversioned := &templates.ConstraintTemplate{}
+			if err := scheme.Convert(templ, unversioned, nil); err != nil {
+				return nil, err
+			}
+			if _, err := client.AddTemplate(ctx, unversioned); err != nil {
+				return nil, badRequestError{fmt.Errorf("invalid template %q: %w", templ.GetName(), err)}
+			}
+			loaded[kind] = true
+		}
+		if c.GetAPIVersion() == "" {
+			c.SetAPIVersion(constraintsGroup + "/v1beta1")
+		}
+		if _, err := client.AddConstraint(ctx, c); err != nil {
+			return nil, badRequestError{fmt.Errorf("invalid constraint %s: %w", constraintKey(c), err)}
+		}
+	}
+	return client, nil
+}
+
+// clusterTemplate returns the template in the cluster for a constraint kind
+func (h *impactHandler) clusterTemplate(ctx context.Context, kind string) (*templv1beta1.ConstraintTemplate, error) {
+	list := &templv1beta1.ConstraintTemplateList{}
+	if err := h.cache.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("listing constraint templates: %w", err)
+	}
+	for i := range list.Items {
+		if list.Items[i].Spec.CRD.Spec.Names.Kind == kind {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, badRequestError{fmt.Errorf("no template for constraint kind %q", kind)}
+}
+
+// snapshot returns the cached objects of every watched kind other than
+// constraints, and the cached namespaces by name
+func (h *impactHandler) snapshot(ctx context.Context) ([]unstructured.Unstructured, map[string]*corev1.Namespace, []string) {
+	gvks := h.gvks.GetManagedGVK()
+	sort.Slice(gvks, func(i, j int) bool { return gvks[i].String() < gvks[j].String() })
+	var objects []unstructured.Unstructured
+	namespaces := make(map[string]*corev1.Namespace)
+	var errs []string
+	for _, gvk := range gvks {
+		if gvk.Group == constraintsGroup {
+			continue
+		}
+		u := &unstructured.UnstructuredList{}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind + "List"})
+		if err := h.cache.List(ctx, u); err != nil {
+			errs = append(errs, fmt.Sprintf("listing %s: %v", gvk, err))
+			continue
+		}
+		for i := range u.Items {
+			u.Items[i].SetGroupVersionKind(gvk)
+			if gvk.Group == "" && gvk.Kind == "Namespace" {
+				ns := &corev1.Namespace{}
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Items[i].Object, ns); err == nil {
+					namespaces[ns.GetName()] = ns
+				}
+			}
+		}
+		objects = append(objects, u.Items...)
+	}
+	return objects, namespaces, errs
+}
+
+// currentlyDenied returns whether a loaded constraint denies obj
+func (h *impactHandler) currentlyDenied(ctx context.Context, obj target.AugmentedUnstructured) (bool, error) {
+	resp, err := h.opa.Review(ctx, obj)
+	if err != nil {
+		return false, err
+	}
+	for _, res := range resp.Results() {
+		if res.EnforcementAction == string(util.Deny) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func constraintKey(c *unstructured.Unstructured) string {
+	return c.GetKind() + "/" + c.GetName()
+}
+
+func objectKey(u *unstructured.Unstructured) string {
+	key := u.GetKind() + " " + u.GetName()
+	if ns := u.GetNamespace(); ns != "" {
+		key = u.GetKind() + " " + ns + "/" + u.GetName()
+	}
+	return key
+}
@@ -0,0 +1,163 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const candidateTemplate = `{
+  "apiVersion": "templates.gatekeeper.sh/v1beta1",
+  "kind": "ConstraintTemplate",
+  "metadata": {"name": "k8srequireteam"},
+  "spec": {
+    "crd": {"spec": {"names": {"kind": "K8sRequireTeam"}}},
+    "targets": [{
+      "target": "admission.k8s.gatekeeper.sh",
+      "rego": "package k8srequireteam\nviolation[{\"msg\": \"team label is required\"}] { not input.review.object.metadata.labels.team }"
+    }]
+  }
+}`
+
+func newLabeledPod(name string, labels map[string]string) unstructured.Unstructured {
+	u := newObj("default", name)
+	u.SetLabels(labels)
+	return u
+}
+
+func TestImpact(t *testing.T) {
+	backend, err := opa.NewBackend(opa.Driver(local.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &templates.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8slivedeny"},
+		Spec: templates.ConstraintTemplateSpec{
+			CRD: templates.CRD{Spec: templates.CRDSpec{Names: templates.Names{Kind: "K8sLiveDeny"}}},
+			Targets: []templates.Target{{
+				Target: (&target.K8sValidationTarget{}).GetName(),
+				Rego: `package k8slivedeny
+violation[{"msg": "denied"}] { input.review.object.metadata.labels.denied == "true" }`,
+			}},
+		},
+	}
+	if _, err := live.AddTemplate(context.Background(), templ); err != nil {
+		t.Fatal(err)
+	}
+	liveConstraint := &unstructured.Unstructured{}
+	liveConstraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	liveConstraint.SetKind("K8sLiveDeny")
+	liveConstraint.SetName("live")
+	if _, err := live.AddConstraint(context.Background(), liveConstraint); err != nil {
+		t.Fatal(err)
+	}
+
+	gvks := fakeGVKs{
+		{Version: "v1", Kind: "Pod"},
+		{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: "K8sLiveDeny"},
+	}
+	cache := fakeCache{
+		"PodList": {
+			newLabeledPod("labeled", map[string]string{"team": "a"}),
+			newLabeledPod("unlabeled", nil),
+			newLabeledPod("denied", map[string]string{"denied": "true"}),
+		},
+	}
+	s, err := newServer("127.0.0.1:0", "secret", cache, gvks, nil, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	candidate := `{"kind": "K8sRequireTeam", "metadata": {"name": "%s"}, "spec": {"match": {"kinds": [{"apiGroups": [""], "kinds": ["Pod"]}]}}}`
+	tc := []struct {
+		name       string
+		path       string
+		body       string
+		expectCode int
+		expected   *impactReport
+	}{
+		{
+			name:       "Impact",
+			path:       "/debug/impact",
+			body:       `{"templates": [` + candidateTemplate + `], "constraints": [` + fmt.Sprintf(candidate, "require-team") + `]}`,
+			expectCode: http.StatusOK,
+			expected: &impactReport{Evaluated: 3, Constraints: []constraintImpact{{
+				Kind:              "K8sRequireTeam",
+				Name:              "require-team",
+				EnforcementAction: "deny",
+				Violations:        2,
+				NewlyDenied:       1,
+				Objects: []objectImpact{
+					{Version: "v1", Kind: "Pod", Namespace: "default", Name: "unlabeled", Messages: []string{"team label is required"}},
+					{Version: "v1", Kind: "Pod", Namespace: "default", Name: "denied", CurrentlyDenied: true, Messages: []string{"team label is required"}},
+				},
+			}}},
+		},
+		{
+			name:       "Limit",
+			path:       "/debug/impact?limit=1",
+			body:       `{"templates": [` + candidateTemplate + `], "constraints": [` + fmt.Sprintf(candidate, "require-team") + `]}`,
+			expectCode: http.StatusOK,
+			expected: &impactReport{Evaluated: 3, Constraints: []constraintImpact{{
+				Kind:              "K8sRequireTeam",
+				Name:              "require-team",
+				EnforcementAction: "deny",
+				Violations:        2,
+				NewlyDenied:       1,
+				Objects: []objectImpact{
+					{Version: "v1", Kind: "Pod", Namespace: "default", Name: "unlabeled", Messages: []string{"team label is required"}},
+				},
+				Truncated: true,
+			}}},
+		},
+		{
+			name:       "Missing template",
+			path:       "/debug/impact",
+			body:       `{"constraints": [` + fmt.Sprintf(candidate, "require-team") + `]}`,
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "No constraints",
+			path:       "/debug/impact",
+			body:       `{"templates": [` + candidateTemplate + `]}`,
+			expectCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			s.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.expectCode {
+				t.Fatalf("code = %d, want %d: %s", rec.Code, tt.expectCode, rec.Body.String())
+			}
+			if tt.expected == nil {
+				return
+			}
+			got := &impactReport{}
+			if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// inventoryHandler summarizes the contents of the informer cache for
+// every watched kind. Object keys are included when ?keys=true is passed.
+type inventoryHandler struct {
+	cache Lister
+	gvks  GVKSource
+}
+
+type kindInventory struct {
+	Group   string   `json:"group"`
+	Version string   `json:"version"`
+	Kind    string   `json:"kind"`
+	Count   int      `json:"count"`
+	Keys    []string `json:"keys,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type inventory struct {
+	Kinds []kindInventory `json:"kinds"`
+}
+
+func (h *inventoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	withKeys := r.URL.Query().Get("keys") == "true"
+
+	gvks := h.gvks.GetManagedGVK()
+	sort.Slice(gvks, func(i, j int) bool { return gvks[i].String() < gvks[j].String() })
+
+	inv := inventory{Kinds: []kindInventory{}}
+	for _, gvk := range gvks {
+		inv.Kinds = append(inv.Kinds, h.summarize(r, gvk, withKeys))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(inv); err != nil {
+		log.Error(err, "unable to write inventory")
+	}
+}
+
+func (h *inventoryHandler) summarize(r *http.Request, gvk schema.GroupVersionKind, withKeys bool) kindInventory {
+	ki := kindInventory{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+	u := &unstructured.UnstructuredList{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   gvk.Group,
+		Version: gvk.Version,
+		Kind:    gvk.Kind + "List",
+	})
+	if err := h.cache.List(r.Context(), u); err != nil {
+		ki.Error = err.Error()
+		return ki
+	}
+	ki.Count = len(u.Items)
+	if withKeys {
+		for i := range u.Items {
+			key := u.Items[i].GetName()
+			if ns := u.Items[i].GetNamespace(); ns != "" {
+				key = ns + "/" + key
+			}
+			ki.Keys = append(ki.Keys, key)
+		}
+		sort.Strings(ki.Keys)
+	}
+	return ki
+}
@@ -0,0 +1,128 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// explanationsQuery is the target library rule that explains matches
+var explanationsQuery = fmt.Sprintf(`hooks["%s"].library.match_explanations`, (&target.K8sValidationTarget{}).GetName())
+
+// Querier evaluates a query against the policies loaded into OPA
+type Querier interface {
+	Query(ctx context.Context, path string, input interface{}, opts ...drivers.QueryOpt) (*types.Response, error)
+}
+
+// matchHandler explains which match criteria of each constraint an object
+// passes. The object is POSTed as {"object": ..., "namespace": ...,
+// "operation": ...}, where the namespace and operation are optional.
+// ?kind= and ?name= restrict the explanation to the constraints of a kind, or
+// to a single constraint.
+type matchHandler struct {
+	opa Querier
+}
+
+type matchRequest struct {
+	Object    *unstructured.Unstructured `json:"object"`
+	Namespace *corev1.Namespace          `json:"namespace,omitempty"`
+	// Operation is the admission operation to match, audit is matched if
+	// it is empty
+	Operation admissionv1beta1.Operation `json:"operation,omitempty"`
+}
+
+type constraintMatch struct {
+	Kind     string          `json:"kind"`
+	Name     string          `json:"name"`
+	Matched  bool            `json:"matched"`
+	Criteria map[string]bool `json:"criteria"`
+}
+
+type matchExplanation struct {
+	Constraints []constraintMatch `json:"constraints"`
+}
+
+func (h *matchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req := &matchRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Object == nil {
+		http.Error(w, "invalid request: object is required", http.StatusBadRequest)
+		return
+	}
+	explanation, err := h.explain(r.Context(), req, r.URL.Query().Get("kind"), r.URL.Query().Get("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(explanation); err != nil {
+		log.Error(err, "unable to write match explanation")
+	}
+}
+
+func (h *matchHandler) explain(ctx context.Context, req *matchRequest, kind, name string) (*matchExplanation, error) {
+	raw, err := json.Marshal(req.Object.Object)
+	if err != nil {
+		return nil, err
+	}
+	gvk := req.Object.GroupVersionKind()
+	_, review, err := (&target.K8sValidationTarget{}).HandleReview(&target.AugmentedReview{
+		AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+			Namespace: req.Object.GetNamespace(),
+			Name:      req.Object.GetName(),
+			Operation: req.Operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+		Namespace: req.Namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.opa.Query(ctx, explanationsQuery, map[string]interface{}{"review": review})
+	if err != nil {
+		return nil, err
+	}
+	explanation := &matchExplanation{Constraints: []constraintMatch{}}
+	for _, res := range resp.Results {
+		if res.Constraint == nil {
+			continue
+		}
+		if (kind != "" && res.Constraint.GetKind() != kind) || (name != "" && res.Constraint.GetName() != name) {
+			continue
+		}
+		m := constraintMatch{Kind: res.Constraint.GetKind(), Name: res.Constraint.GetName(), Criteria: map[string]bool{}}
+		m.Matched, _ = res.Metadata["matched"].(bool)
+		criteria, _ := res.Metadata["criteria"].(map[string]interface{})
+		for k, v := range criteria {
+			m.Criteria[k], _ = v.(bool)
+		}
+		explanation.Constraints = append(explanation.Constraints, m)
+	}
+	sort.Slice(explanation.Constraints, func(i, j int) bool {
+		a, b := explanation.Constraints[i], explanation.Constraints[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return explanation, nil
+}
@@ -0,0 +1,106 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newConstraint(name string, match map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"match": match},
+	}}
+	u.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	u.SetKind("K8sDebugMatch")
+	u.SetName(name)
+	return u
+}
+
+func TestMatch(t *testing.T) {
+	driver := local.New()
+	backend, err := opa.NewBackend(opa.Driver(driver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &templates.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8sdebugmatch"},
+		Spec: templates.ConstraintTemplateSpec{
+			CRD: templates.CRD{Spec: templates.CRDSpec{Names: templates.Names{Kind: "K8sDebugMatch"}}},
+			Targets: []templates.Target{{
+				Target: (&target.K8sValidationTarget{}).GetName(),
+				Rego: `package k8sdebugmatch
+violation[{"msg": "denied"}] { true }`,
+			}},
+		},
+	}
+	if _, err := client.AddTemplate(context.Background(), templ); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*unstructured.Unstructured{
+		newConstraint("pods", map[string]interface{}{
+			"kinds": []interface{}{map[string]interface{}{"apiGroups": []interface{}{""}, "kinds": []interface{}{"Pod"}}},
+		}),
+		newConstraint("excluded", map[string]interface{}{
+			"kinds":              []interface{}{map[string]interface{}{"apiGroups": []interface{}{""}, "kinds": []interface{}{"Pod"}}},
+			"excludedNamespaces": []interface{}{"default"},
+		}),
+	} {
+		if _, err := client.AddConstraint(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := newServer("127.0.0.1:0", "secret", nil, nil, driver, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"object": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo", "namespace": "default"}}, "operation": "CREATE"}`)
+	req := httptest.NewRequest(http.MethodPost, "/debug/match?kind=K8sDebugMatch", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	got := &matchExplanation{}
+	if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+		t.Fatal(err)
+	}
+	passing := map[string]bool{
+		"kinds":              true,
+		"operations":         true,
+		"namespaces":         true,
+		"excludedNamespaces": true,
+		"namespaceSelector":  true,
+		"labelSelector":      true,
+		"os":                 true,
+		"namespacePhase":     true,
+	}
+	excluded := map[string]bool{}
+	for k, v := range passing {
+		excluded[k] = v
+	}
+	excluded["excludedNamespaces"] = false
+	expected := &matchExplanation{Constraints: []constraintMatch{
+		{Kind: "K8sDebugMatch", Name: "excluded", Matched: false, Criteria: excluded},
+		{Kind: "K8sDebugMatch", Name: "pods", Matched: true, Criteria: passing},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, want %+v", got, expected)
+	}
+}
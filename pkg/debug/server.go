@@ -0,0 +1,121 @@
+package debug
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("debug")
+
+var (
+	debugAddr      = flag.String("debug-addr", "", "The localhost address the debug endpoints bind to, e.g. 127.0.0.1:9091. Debug endpoints are disabled if unspecified.")
+	debugTokenFile = flag.String("debug-token-file", "", "File containing the bearer token required to access the debug endpoints. Required when --debug-addr is set.")
+)
+
+// Lister lists objects from the informer cache
+type Lister interface {
+	List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error
+}
+
+// GVKSource returns the kinds currently watched by Gatekeeper
+type GVKSource interface {
+	GetManagedGVK() []schema.GroupVersionKind
+}
+
+var _ manager.Runnable = &server{}
+
+type server struct {
+	addr    string
+	token   []byte
+	handler http.Handler
+}
+
+// AddToManager adds the debug server to the manager if --debug-addr is set
+func AddToManager(m manager.Manager, cache Lister, gvks GVKSource, opa Querier, reviewer Reviewer) error {
+	if *debugAddr == "" {
+		return nil
+	}
+	if err := checkLocalhost(*debugAddr); err != nil {
+		return err
+	}
+	if *debugTokenFile == "" {
+		return errors.New("--debug-token-file must be set when --debug-addr is set")
+	}
+	token, err := ioutil.ReadFile(*debugTokenFile)
+	if err != nil {
+		return fmt.Errorf("unable to read debug token: %v", err)
+	}
+	s, err := newServer(*debugAddr, strings.TrimSpace(string(token)), cache, gvks, opa, reviewer)
+	if err != nil {
+		return err
+	}
+	return m.Add(s)
+}
+
+func newServer(addr, token string, cache Lister, gvks GVKSource, opa Querier, reviewer Reviewer) (*server, error) {
+	if token == "" {
+		return nil, errors.New("debug token must not be empty")
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/inventory", &inventoryHandler{cache: cache, gvks: gvks})
+	mux.Handle("/debug/match", &matchHandler{opa: opa})
+	mux.Handle("/debug/impact", &impactHandler{cache: cache, gvks: gvks, opa: reviewer})
+	s := &server{addr: addr, token: []byte(token)}
+	s.handler = s.authenticate(mux)
+	return s, nil
+}
+
+// checkLocalhost ensures the debug endpoints are never exposed outside the pod
+func checkLocalhost(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid debug address %q: %v", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("debug address %q must bind to localhost", addr)
+}
+
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), s.token) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start implements the Runnable interface
+func (s *server) Start(stop <-chan struct{}) error {
+	log.Info("Starting debug server", "addr", s.addr)
+	srv := &http.Server{Addr: s.addr, Handler: s.handler}
+	errCh := make(chan error)
+	go func() { errCh <- srv.ListenAndServe() }()
+	select {
+	case <-stop:
+		log.Info("Stopping debug server")
+		return srv.Shutdown(context.Background())
+	case err := <-errCh:
+		return err
+	}
+}
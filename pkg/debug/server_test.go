@@ -0,0 +1,150 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeGVKs []schema.GroupVersionKind
+
+func (f fakeGVKs) GetManagedGVK() []schema.GroupVersionKind {
+	return f
+}
+
+// fakeCache returns the objects stored for the list's kind, and no objects for
+// typed lists
+type fakeCache map[string][]unstructured.Unstructured
+
+func (f fakeCache) List(_ context.Context, list runtime.Object, _ ...client.ListOption) error {
+	u, ok := list.(*unstructured.UnstructuredList)
+	if !ok {
+		return nil
+	}
+	items, ok := f[u.GetKind()]
+	if !ok {
+		return errors.New("no informer")
+	}
+	u.Items = items
+	return nil
+}
+
+func newObj(namespace, name string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestCheckLocalhost(t *testing.T) {
+	tc := []struct {
+		addr      string
+		expectErr bool
+	}{
+		{addr: "127.0.0.1:9091"},
+		{addr: "localhost:9091"},
+		{addr: "[::1]:9091"},
+		{addr: ":9091", expectErr: true},
+		{addr: "0.0.0.0:9091", expectErr: true},
+		{addr: "10.0.0.1:9091", expectErr: true},
+		{addr: "localhost", expectErr: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := checkLocalhost(tt.addr)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("err = %v, expected error: %v", err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestInventory(t *testing.T) {
+	gvks := fakeGVKs{
+		{Version: "v1", Kind: "Pod"},
+		{Version: "v1", Kind: "Namespace"},
+		{Group: "apps", Version: "v1", Kind: "Missing"},
+	}
+	cache := fakeCache{
+		"PodList":       {newObj("foo", "b"), newObj("bar", "a")},
+		"NamespaceList": {newObj("", "foo"), newObj("", "bar")},
+	}
+	s, err := newServer("127.0.0.1:0", "secret", cache, gvks, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		name       string
+		path       string
+		token      string
+		expectCode int
+		expected   *inventory
+	}{
+		{
+			name:       "No token",
+			path:       "/debug/inventory",
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong token",
+			path:       "/debug/inventory",
+			token:      "guess",
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Counts",
+			path:       "/debug/inventory",
+			token:      "secret",
+			expectCode: http.StatusOK,
+			expected: &inventory{Kinds: []kindInventory{
+				{Version: "v1", Kind: "Namespace", Count: 2},
+				{Version: "v1", Kind: "Pod", Count: 2},
+				{Group: "apps", Version: "v1", Kind: "Missing", Error: "no informer"},
+			}},
+		},
+		{
+			name:       "Keys",
+			path:       "/debug/inventory?keys=true",
+			token:      "secret",
+			expectCode: http.StatusOK,
+			expected: &inventory{Kinds: []kindInventory{
+				{Version: "v1", Kind: "Namespace", Count: 2, Keys: []string{"bar", "foo"}},
+				{Version: "v1", Kind: "Pod", Count: 2, Keys: []string{"bar/a", "foo/b"}},
+				{Group: "apps", Version: "v1", Kind: "Missing", Error: "no informer"},
+			}},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			s.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.expectCode {
+				t.Fatalf("code = %d, want %d", rec.Code, tt.expectCode)
+			}
+			if tt.expected == nil {
+				return
+			}
+			got := &inventory{}
+			if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
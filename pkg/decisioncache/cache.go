@@ -0,0 +1,100 @@
+package decisioncache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// revision is bumped whenever anything that decisions depend on changes:
+// templates, constraints or synced data
+var revision uint64
+
+// Invalidate discards every cached decision. It must be called after any
+// change to the templates, constraints or data loaded into OPA.
+func Invalidate() {
+	atomic.AddUint64(&revision, 1)
+}
+
+// Revision returns the current revision
+func Revision() uint64 {
+	return atomic.LoadUint64(&revision)
+}
+
+type entry struct {
+	key      string
+	revision uint64
+	expires  time.Time
+	value    interface{}
+}
+
+// Cache is a bounded, least recently used cache whose entries expire after a
+// TTL or as soon as the revision changes
+type Cache struct {
+	mux     sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// New returns a cache holding up to size entries for at most ttl
+func New(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached for key at the current revision
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if e.revision != Revision() || !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e.value, true
+}
+
+// Add caches value for key. rev must be the revision read before the value
+// was computed, so a value computed while the revision changed is never
+// served.
+func (c *Cache) Add(key string, rev uint64, value interface{}) {
+	if c.size < 1 {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(&entry{key: key, revision: rev, expires: c.now().Add(c.ttl), value: value})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Len returns the number of cached entries, including stale ones that have
+// not been evicted yet
+func (c *Cache) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
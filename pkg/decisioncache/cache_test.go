@@ -0,0 +1,64 @@
+package decisioncache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := New(2, time.Second)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", Revision(), "A")
+	if v, ok := c.Get("a"); !ok || v != "A" {
+		t.Fatalf("Get(a) = %v, %v; want A, true", v, ok)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("expected a miss for an unknown key")
+	}
+
+	// b is used after a, so a is the least recently used entry
+	c.Add("b", Revision(), "B")
+	c.Get("a")
+	c.Add("c", Revision(), "C")
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to be kept")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to expire")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", c.Len())
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	c := New(10, time.Minute)
+	c.Add("a", Revision(), "A")
+	Invalidate()
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected invalidation to discard a")
+	}
+
+	// a value computed across an invalidation is never served
+	rev := Revision()
+	Invalidate()
+	c.Add("b", rev, "B")
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected the stale value to be discarded")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	c := New(0, time.Minute)
+	c.Add("a", Revision(), "A")
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a zero-size cache to hold nothing")
+	}
+}
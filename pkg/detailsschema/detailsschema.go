@@ -0,0 +1,182 @@
+package detailsschema
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
+	"github.com/go-openapi/validate"
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"github.com/open-policy-agent/gatekeeper/pkg/invalidparams"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/severity"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Annotation holds an OpenAPI v3 schema, in YAML or JSON, that the
+// `details` of every violation produced by the template must satisfy.
+const Annotation = "templates.gatekeeper.sh/details-schema"
+
+// Registry maps constraint kinds to the validator for their details schema
+type Registry struct {
+	mux        sync.RWMutex
+	validators map[string]*validate.SchemaValidator
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{validators: make(map[string]*validate.SchemaValidator)}
+}
+
+// Templates is the registry shared by the template controller, which fills
+// it, and the webhook and audit, which validate results against it.
+var Templates = NewRegistry()
+
+// Parse compiles a details schema. An empty schema returns a nil validator.
+func Parse(src string) (*validate.SchemaValidator, error) {
+	if src == "" {
+		return nil, nil
+	}
+	versioned := &apiextensionsv1beta1.JSONSchemaProps{}
+	if err := yaml.Unmarshal([]byte(src), versioned); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", Annotation, err)
+	}
+	props := &apiextensions.JSONSchemaProps{}
+	if err := apiextensionsv1beta1.Convert_v1beta1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(versioned, props, nil); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", Annotation, err)
+	}
+	validator, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: props})
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", Annotation, err)
+	}
+	return validator, nil
+}
+
+// Set registers the details schema for the constraint kind, replacing any
+// previous one. An empty schema removes it.
+func (r *Registry) Set(kind, src string) error {
+	validator, err := Parse(src)
+	if err != nil {
+		return err
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if validator == nil {
+		delete(r.validators, kind)
+		return nil
+	}
+	r.validators[kind] = validator
+	return nil
+}
+
+// Remove drops the details schema for the constraint kind
+func (r *Registry) Remove(kind string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.validators, kind)
+}
+
+// rejectionKeys flag the details of the rejections the target raises itself
+// with the library's autoreject_review, rather than the template's rules.
+// Their details are not described by the template's schema, and filtering
+// them depends on the flag. Keep in sync with pkg/target/regolib/src.rego.
+var rejectionKeys = []string{
+	invalidparams.DetailsKey,
+	"staleInventory",
+	"unsyncedInventory",
+}
+
+// isRejection returns whether the result is a rejection raised by the target
+func isRejection(result *rtypes.Result) bool {
+	details, ok := result.Metadata["details"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, k := range rejectionKeys {
+		if set, _ := details[k].(bool); set {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the details of the result against the schema of the
+// template that produced it. Results of templates without a schema, and
+// rejections raised by the target, are always valid.
+func (r *Registry) Validate(result *rtypes.Result) error {
+	if result.Constraint == nil || isRejection(result) {
+		return nil
+	}
+	r.mux.RLock()
+	validator, ok := r.validators[result.Constraint.GetKind()]
+	r.mux.RUnlock()
+	if !ok {
+		return nil
+	}
+	details := result.Metadata["details"]
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	if errs := validation.ValidateCustomResource(field.NewPath("details"), details, validator); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
+
+// Sanitize validates the details of each result. Details that do not match
+// their template's schema are logged and removed, except for their control
+// keys, so consumers only ever see details of the declared shape. The
+// violations themselves are kept.
+//
+// Mismatches are a bug in the template rather than in the constraint or the
+// reviewed object, so they are also counted by the template's constraint kind.
+// Sanitize runs on every review and audit, so they are not written to the
+// template's status, which would mean a status update per violation.
+func (r *Registry) Sanitize(results []*rtypes.Result, log logr.Logger) {
+	for _, res := range results {
+		if err := r.Validate(res); err != nil {
+			log.Error(err, "violation details do not match the template's details schema",
+				logging.EventType, "violation_details_schema_error",
+				logging.ConstraintKind, res.Constraint.GetKind(),
+				logging.ConstraintName, res.Constraint.GetName(),
+			)
+			if err := reportSchemaError(res.Constraint.GetKind()); err != nil {
+				log.Error(err, "failed to report violation details schema error")
+			}
+			if kept := controlDetails(res); len(kept) > 0 {
+				res.Metadata["details"] = kept
+			} else {
+				delete(res.Metadata, "details")
+			}
+		}
+	}
+}
+
+// controlKeys are the keys of a result's details that decide how Gatekeeper
+// enforces it. They are kept when the rest of the details are removed, so an
+// informational finding never becomes an enforced violation, and a severity
+// keeps its action, because of a bug in the template's details.
+var controlKeys = []string{
+	findings.DetailsKey,
+	severity.DetailsKey,
+}
+
+// controlDetails returns the control keys of the result's details
+func controlDetails(res *rtypes.Result) map[string]interface{} {
+	details, ok := res.Metadata["details"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	kept := make(map[string]interface{})
+	for _, k := range controlKeys {
+		if v, ok := details[k]; ok {
+			kept[k] = v
+		}
+	}
+	return kept
+}
@@ -0,0 +1,155 @@
+package detailsschema
+
+import (
+	"reflect"
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"github.com/open-policy-agent/gatekeeper/pkg/invalidparams"
+	"github.com/open-policy-agent/gatekeeper/pkg/severity"
+	"go.opencensus.io/stats/view"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const schema = `
+type: object
+required: ["missing"]
+properties:
+  missing:
+    type: array
+    items:
+      type: string
+`
+
+func newResult(kind string, details interface{}) *rtypes.Result {
+	c := &unstructured.Unstructured{}
+	c.SetKind(kind)
+	c.SetName("foo")
+	r := &rtypes.Result{Constraint: c, Metadata: map[string]interface{}{}}
+	if details != nil {
+		r.Metadata["details"] = details
+	}
+	return r
+}
+
+func TestParse(t *testing.T) {
+	tc := []struct {
+		Name      string
+		Schema    string
+		ExpectErr bool
+	}{
+		{Name: "Empty"},
+		{Name: "YAML", Schema: schema},
+		{Name: "JSON", Schema: `{"type": "object", "properties": {"missing": {"type": "array"}}}`},
+		{Name: "Malformed", Schema: "type: [object", ExpectErr: true},
+		{Name: "Wrong field type", Schema: "required: true", ExpectErr: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := Parse(tt.Schema)
+			if (err != nil) != tt.ExpectErr {
+				t.Errorf("err = %v, expected error: %v", err, tt.ExpectErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Set("WithSchema", schema); err != nil {
+		t.Fatal(err)
+	}
+	tc := []struct {
+		Name      string
+		Result    *rtypes.Result
+		ExpectErr bool
+	}{
+		{
+			Name:   "No schema",
+			Result: newResult("NoSchema", "anything"),
+		},
+		{
+			Name:   "Matching details",
+			Result: newResult("WithSchema", map[string]interface{}{"missing": []interface{}{"a", "b"}}),
+		},
+		{
+			Name:      "Wrong type",
+			Result:    newResult("WithSchema", map[string]interface{}{"missing": "a"}),
+			ExpectErr: true,
+		},
+		{
+			Name:      "Missing required field",
+			Result:    newResult("WithSchema", map[string]interface{}{"other": "a"}),
+			ExpectErr: true,
+		},
+		{
+			Name:      "No details",
+			Result:    newResult("WithSchema", nil),
+			ExpectErr: true,
+		},
+		{
+			Name:   "Invalid parameters rejection",
+			Result: newResult("WithSchema", map[string]interface{}{invalidparams.DetailsKey: true}),
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := r.Validate(tt.Result)
+			if (err != nil) != tt.ExpectErr {
+				t.Errorf("err = %v, expected error: %v", err, tt.ExpectErr)
+			}
+		})
+	}
+
+	r.Remove("WithSchema")
+	if err := r.Validate(newResult("WithSchema", "anything")); err != nil {
+		t.Errorf("expected no validation after removal, got %v", err)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Set("WithSchema", schema); err != nil {
+		t.Fatal(err)
+	}
+	good := newResult("WithSchema", map[string]interface{}{"missing": []interface{}{"a"}})
+	bad := newResult("WithSchema", map[string]interface{}{"missing": "a"})
+	r.Sanitize([]*rtypes.Result{good, bad}, logf.Log)
+
+	if _, ok := good.Metadata["details"]; !ok {
+		t.Error("valid details were removed")
+	}
+	if _, ok := bad.Metadata["details"]; ok {
+		t.Error("invalid details were kept")
+	}
+
+	// control keys survive a mismatch, so a finding stays informational and a
+	// severity keeps its action
+	finding := newResult("WithSchema", map[string]interface{}{findings.DetailsKey: true, severity.DetailsKey: "low", "missing": "a"})
+	r.Sanitize([]*rtypes.Result{finding}, logf.Log)
+	if !findings.IsFinding(finding) {
+		t.Error("a finding with invalid details became a violation")
+	}
+	expected := map[string]interface{}{findings.DetailsKey: true, severity.DetailsKey: "low"}
+	if got := finding.Metadata["details"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("details = %v, want %v", got, expected)
+	}
+
+	rows, err := view.RetrieveData(schemaErrorsMetricName)
+	if err != nil {
+		t.Fatalf("Error when retrieving data: %v", err)
+	}
+	got := make(map[string]int64)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key.Name() == "constraint_kind" {
+				got[tag.Value] = row.Data.(*view.CountData).Value
+			}
+		}
+	}
+	if got["WithSchema"] != 2 {
+		t.Errorf("got %d schema errors counted for WithSchema, want 2", got["WithSchema"])
+	}
+}
@@ -0,0 +1,48 @@
+package detailsschema
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+const (
+	schemaErrorsMetricName = "violation_details_schema_errors_total"
+	schemaErrorsDesc       = "Total number of violations whose details did not match their template's details schema"
+)
+
+var (
+	schemaErrorsM = stats.Int64(schemaErrorsMetricName, schemaErrorsDesc, stats.UnitDimensionless)
+
+	constraintKindKey = tag.MustNewKey("constraint_kind")
+)
+
+func init() {
+	if err := register(); err != nil {
+		panic(err)
+	}
+}
+
+func register() error {
+	return view.Register(&view.View{
+		Name:        schemaErrorsMetricName,
+		Measure:     schemaErrorsM,
+		Description: schemaErrorsDesc,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{constraintKindKey},
+	})
+}
+
+// reportSchemaError counts a violation whose details did not match the schema
+// of the template with the constraint kind. Constraint names are left out to
+// bound the number of series.
+func reportSchemaError(kind string) error {
+	ctx, err := tag.New(context.Background(), tag.Insert(constraintKindKey, kind))
+	if err != nil {
+		return err
+	}
+	return metrics.Record(ctx, schemaErrorsM.M(1))
+}
@@ -0,0 +1,142 @@
+package deterministic
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Annotation declares that a template's decisions only depend on the review
+// and the data loaded into OPA, so they can be cached
+const Annotation = "metadata.gatekeeper.sh/deterministic"
+
+var verify = flag.Bool("verify-deterministic-templates", true, "reject templates with the "+Annotation+" annotation whose Rego calls built-ins that are not deterministic, such as http.send or time.now_ns")
+
+// builtins are the built-ins whose results can change between calls with the
+// same arguments
+var builtins = map[string]bool{
+	ast.HTTPSend.Name:   true,
+	ast.NowNanos.Name:   true,
+	ast.OPARuntime.Name: true,
+}
+
+// uidRef is the admission request UID, which differs for every request and is
+// not part of a cached decision's key
+var uidRef = ast.MustParseRef("input.review.uid")
+
+// Declared returns whether the template has the deterministic annotation
+func Declared(ct *templates.ConstraintTemplate) bool {
+	return ct.GetAnnotations()[Annotation] == "true"
+}
+
+// Verify returns an error if the template is declared deterministic but its
+// Rego or libraries call a built-in that is not, or read the request UID.
+// Modules that don't parse are reported by ingestion.
+func Verify(ct *templates.ConstraintTemplate) error {
+	if !*verify || !Declared(ct) {
+		return nil
+	}
+	var problems []string
+	for _, target := range ct.Spec.Targets {
+		problems = append(problems, check("rego", target.Rego)...)
+		for i, lib := range target.Libs {
+			problems = append(problems, check(fmt.Sprintf("libs[%d]", i), lib)...)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("template is declared deterministic with the %s annotation but %s", Annotation, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func check(name, src string) []string {
+	module, err := ast.ParseModule(name, src)
+	if err != nil || module == nil {
+		return nil
+	}
+	found := make(map[string]bool)
+	add := func(loc *ast.Location, format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		if loc != nil {
+			msg = fmt.Sprintf("%s:%d: %s", name, loc.Row, msg)
+		}
+		found[msg] = true
+	}
+	// calls are either whole expressions or terms nested inside one, e.g. the
+	// right hand side of an assignment
+	checkCall := func(loc *ast.Location, op string) {
+		if builtins[op] {
+			add(loc, "calls %s", op)
+		}
+	}
+	ast.NewGenericVisitor(func(x interface{}) bool {
+		switch x := x.(type) {
+		case *ast.Expr:
+			if x.IsCall() {
+				checkCall(x.Location, x.Operator().String())
+			}
+		case *ast.Term:
+			if call, ok := x.Value.(ast.Call); ok && len(call) > 0 {
+				checkCall(x.Location, call[0].String())
+			}
+			if ref, ok := x.Value.(ast.Ref); ok && ref.HasPrefix(uidRef) {
+				add(x.Location, "reads %s", uidRef)
+			}
+		}
+		return false
+	}).Walk(module)
+	var problems []string
+	for msg := range found {
+		problems = append(problems, msg)
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// Registry tracks which templates are deterministic
+type Registry struct {
+	mux   sync.RWMutex
+	kinds map[string]bool
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{kinds: make(map[string]bool)}
+}
+
+// Templates is filled by the template controller and read by the webhook's
+// decision cache
+var Templates = NewRegistry()
+
+// Set records whether the template with the constraint kind is deterministic
+func (r *Registry) Set(kind string, deterministic bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.kinds[kind] = deterministic
+}
+
+// Remove forgets the template
+func (r *Registry) Remove(kind string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.kinds, kind)
+}
+
+// All returns whether every template is deterministic. A review evaluates
+// every template, so a single template that is not makes no decision
+// cacheable.
+func (r *Registry) All() bool {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	for _, deterministic := range r.kinds {
+		if !deterministic {
+			return false
+		}
+	}
+	return true
+}
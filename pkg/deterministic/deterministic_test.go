@@ -0,0 +1,98 @@
+package deterministic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+)
+
+func template(annotations map[string]string, rego string, libs ...string) *templates.ConstraintTemplate {
+	ct := &templates.ConstraintTemplate{}
+	ct.SetAnnotations(annotations)
+	ct.Spec.Targets = []templates.Target{{Target: "admission.k8s.gatekeeper.sh", Rego: rego, Libs: libs}}
+	return ct
+}
+
+func TestVerify(t *testing.T) {
+	declared := map[string]string{Annotation: "true"}
+	tc := []struct {
+		Name        string
+		Annotations map[string]string
+		Rego        string
+		Libs        []string
+		WantErr     []string
+	}{
+		{
+			Name:        "declared and deterministic",
+			Annotations: declared,
+			Rego:        "package foo\nviolation[{\"msg\": msg}] {\n  input.review.object.metadata.name == \"x\"\n  msg := sprintf(\"%v\", [time.parse_rfc3339_ns(\"2020-01-01T00:00:00Z\")])\n}",
+		},
+		{
+			Name: "not declared",
+			Rego: "package foo\nviolation[{\"msg\": msg}] {\n  resp := http.send({\"method\": \"get\", \"url\": \"http://example.com\"})\n  msg := resp.body\n}",
+		},
+		{
+			Name:        "declared but calls http.send",
+			Annotations: declared,
+			Rego:        "package foo\nviolation[{\"msg\": msg}] {\n  resp := http.send({\"method\": \"get\", \"url\": \"http://example.com\"})\n  msg := resp.body\n}",
+			WantErr:     []string{"rego:3: calls http.send"},
+		},
+		{
+			Name:        "declared but a library reads the time",
+			Annotations: declared,
+			Rego:        "package foo\nimport data.lib.clock\nviolation[{\"msg\": \"late\"}] {\n  clock.late\n}",
+			Libs:        []string{"package lib.clock\nlate {\n  time.now_ns() > 0\n}"},
+			WantErr:     []string{"libs[0]:3: calls time.now_ns"},
+		},
+		{
+			Name:        "declared but reads the request UID",
+			Annotations: declared,
+			Rego:        "package foo\nviolation[{\"msg\": input.review.uid}] {\n  opa.runtime()\n}",
+			WantErr:     []string{"rego:2: reads input.review.uid", "rego:3: calls opa.runtime"},
+		},
+		{
+			Name:        "annotation is not true",
+			Annotations: map[string]string{Annotation: "false"},
+			Rego:        "package foo\nviolation[{\"msg\": \"x\"}] {\n  time.now_ns() > 0\n}",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := Verify(template(tt.Annotations, tt.Rego, tt.Libs...))
+			if len(tt.WantErr) == 0 {
+				if err != nil {
+					t.Errorf("Verify() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Verify() = nil, want an error")
+			}
+			for _, want := range tt.WantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Verify() = %v, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryAll(t *testing.T) {
+	r := NewRegistry()
+	if !r.All() {
+		t.Errorf("without templates, All() = false")
+	}
+	r.Set("K8sRequiredLabels", true)
+	if !r.All() {
+		t.Errorf("with a deterministic template, All() = false")
+	}
+	r.Set("K8sExternalData", false)
+	if r.All() {
+		t.Errorf("with a template that is not deterministic, All() = true")
+	}
+	r.Remove("K8sExternalData")
+	if !r.All() {
+		t.Errorf("after removing the template that is not deterministic, All() = false")
+	}
+}
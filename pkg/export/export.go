@@ -0,0 +1,127 @@
+package export
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var (
+	log = logf.Log.WithName("export")
+
+	reporterName    = flag.String("evaluation-reporter", "", "name of the reporter that the results of each audit are exported to, e.g. http. Empty to disable")
+	exportAdmission = flag.Bool("evaluation-reporter-admission", false, "also export admission decisions with violations, if the reporter supports them")
+)
+
+// Violation is a violation found by audit or by the admission webhook
+type Violation struct {
+	ConstraintKind    string `json:"constraintKind"`
+	ConstraintName    string `json:"constraintName"`
+	EnforcementAction string `json:"enforcementAction"`
+	Message           string `json:"message"`
+	// the violating object, only set for audit violations
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Audit is the outcome of an audit
+type Audit struct {
+	Timestamp  string      `json:"timestamp"`
+	Violations []Violation `json:"violations"`
+}
+
+// Decision is the outcome of an admission request with violations
+type Decision struct {
+	UID        string      `json:"uid"`
+	Operation  string      `json:"operation"`
+	Group      string      `json:"group"`
+	Version    string      `json:"version"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	Allowed    bool        `json:"allowed"`
+	Violations []Violation `json:"violations"`
+}
+
+// Reporter exports the results of each audit to an external system. It is
+// called after audit has written the results to the constraints' status.
+type Reporter interface {
+	ReportAudit(ctx context.Context, audit *Audit) error
+}
+
+// DecisionReporter is implemented by reporters that can also export admission
+// decisions. ReportDecision is called outside of the admission request, so a
+// slow reporter doesn't delay admission.
+type DecisionReporter interface {
+	Reporter
+	ReportDecision(ctx context.Context, decision *Decision) error
+}
+
+// Factory creates a reporter from its flags
+type Factory func() (Reporter, error)
+
+var factories = map[string]Factory{}
+
+// Register makes a reporter available by name to --evaluation-reporter. It
+// must be called from an init function.
+func Register(name string, f Factory) {
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("reporter %s is already registered", name))
+	}
+	factories[name] = f
+}
+
+// New returns the configured reporter, or nil if none is configured
+func New() (Reporter, error) {
+	if *reporterName == "" {
+		return nil, nil
+	}
+	f, ok := factories[*reporterName]
+	if !ok {
+		var names []string
+		for name := range factories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown evaluation reporter %q, expected one of %s", *reporterName, strings.Join(names, ", "))
+	}
+	return f()
+}
+
+// ExportAdmission returns whether admission decisions should be exported
+func ExportAdmission() bool {
+	return *exportAdmission
+}
+
+// Violations converts results to violations. Informational findings are not
+// violations and are left out.
+func Violations(res []*rtypes.Result) []Violation {
+	violations := make([]Violation, 0, len(res))
+	for _, r := range findings.Violations(res) {
+		v := Violation{
+			EnforcementAction: r.EnforcementAction,
+			Message:           r.Msg,
+		}
+		if r.Constraint != nil {
+			v.ConstraintKind = r.Constraint.GetKind()
+			v.ConstraintName = r.Constraint.GetName()
+		}
+		if obj, ok := r.Resource.(*unstructured.Unstructured); ok {
+			gvk := obj.GroupVersionKind()
+			v.Group, v.Version, v.Kind = gvk.Group, gvk.Version, gvk.Kind
+			v.Namespace, v.Name = obj.GetNamespace(), obj.GetName()
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
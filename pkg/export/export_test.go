@@ -0,0 +1,108 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestViolations(t *testing.T) {
+	constraint := &unstructured.Unstructured{}
+	constraint.SetKind("K8sRequiredLabels")
+	constraint.SetName("owner")
+	resource := &unstructured.Unstructured{}
+	resource.SetAPIVersion("apps/v1")
+	resource.SetKind("Deployment")
+	resource.SetNamespace("default")
+	resource.SetName("web")
+	res := []*rtypes.Result{
+		{Msg: "missing owner", Constraint: constraint, EnforcementAction: "deny", Resource: resource},
+		{Msg: "a finding", Constraint: constraint, Metadata: map[string]interface{}{"details": map[string]interface{}{findings.DetailsKey: true}}},
+	}
+	want := []Violation{{
+		ConstraintKind:    "K8sRequiredLabels",
+		ConstraintName:    "owner",
+		EnforcementAction: "deny",
+		Message:           "missing owner",
+		Group:             "apps",
+		Version:           "v1",
+		Kind:              "Deployment",
+		Namespace:         "default",
+		Name:              "web",
+	}}
+	if got := Violations(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHTTPReporter(t *testing.T) {
+	var received []envelope
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := envelope{}
+		if err := json.NewDecoder(req.Body).Decode(&e); err != nil {
+			t.Errorf("could not decode body: %v", err)
+		}
+		received = append(received, e)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	r := &httpReporter{url: server.URL, client: server.Client()}
+
+	if err := r.ReportAudit(context.Background(), &Audit{Timestamp: "2020-01-01T00:00:00Z", Violations: []Violation{{Message: "x"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ReportDecision(context.Background(), &Decision{UID: "abc", Allowed: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || received[0].Type != "audit" || received[0].Audit.Violations[0].Message != "x" || received[1].Type != "decision" || received[1].Decision.UID != "abc" {
+		t.Errorf("received %+v", received)
+	}
+
+	status = http.StatusInternalServerError
+	if err := r.ReportAudit(context.Background(), &Audit{}); err == nil {
+		t.Error("expected an error for a failed export")
+	}
+}
+
+type fakeReporter struct {
+	decisions chan *Decision
+}
+
+func (f *fakeReporter) ReportAudit(ctx context.Context, audit *Audit) error {
+	return nil
+}
+
+func (f *fakeReporter) ReportDecision(ctx context.Context, decision *Decision) error {
+	f.decisions <- decision
+	return nil
+}
+
+func TestQueue(t *testing.T) {
+	r := &fakeReporter{decisions: make(chan *Decision)}
+	q := NewQueue(r, 1)
+	q.Add(&Decision{UID: "first"})
+	// the queue is full, so this one is dropped
+	q.Add(&Decision{UID: "second"})
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- q.Start(stop) }()
+	if d := <-r.decisions; d.UID != "first" {
+		t.Errorf("exported %s, want first", d.UID)
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+
+	var nilQueue *Queue
+	nilQueue.Add(&Decision{})
+}
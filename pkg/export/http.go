@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var (
+	httpURL     = flag.String("evaluation-reporter-url", "", "the URL the http evaluation reporter POSTs audit results and admission decisions to")
+	httpTimeout = flag.Duration("evaluation-reporter-timeout", 10*time.Second, "the timeout of each request of the http evaluation reporter")
+)
+
+func init() {
+	Register("http", newHTTPReporter)
+}
+
+// envelope is the body POSTed by the http reporter. Type is "audit" or
+// "decision", and says which of the other fields is set.
+type envelope struct {
+	Type     string    `json:"type"`
+	Audit    *Audit    `json:"audit,omitempty"`
+	Decision *Decision `json:"decision,omitempty"`
+}
+
+// httpReporter POSTs audit results and admission decisions as JSON
+type httpReporter struct {
+	url    string
+	client *http.Client
+}
+
+var _ DecisionReporter = &httpReporter{}
+
+func newHTTPReporter() (Reporter, error) {
+	if *httpURL == "" {
+		return nil, errors.New("the http evaluation reporter requires --evaluation-reporter-url")
+	}
+	return &httpReporter{url: *httpURL, client: &http.Client{Timeout: *httpTimeout}}, nil
+}
+
+func (r *httpReporter) ReportAudit(ctx context.Context, audit *Audit) error {
+	return r.post(ctx, &envelope{Type: "audit", Audit: audit})
+}
+
+func (r *httpReporter) ReportDecision(ctx context.Context, decision *Decision) error {
+	return r.post(ctx, &envelope{Type: "decision", Decision: decision})
+}
+
+func (r *httpReporter) post(ctx context.Context, e *envelope) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// drain the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("exporting %s to %s: unexpected status %s", e.Type, r.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package export
+
+import (
+	"context"
+)
+
+// Queue exports admission decisions in the background. Decisions are dropped
+// while the queue is full, so a slow reporter never delays admission.
+type Queue struct {
+	reporter  DecisionReporter
+	decisions chan *Decision
+}
+
+// NewQueue returns a queue for up to size decisions. The queue must be
+// started to export them.
+func NewQueue(r DecisionReporter, size int) *Queue {
+	return &Queue{reporter: r, decisions: make(chan *Decision, size)}
+}
+
+// Add queues the decision, or drops it if the queue is full. Add is a no-op
+// on a nil queue.
+func (q *Queue) Add(d *Decision) {
+	if q == nil {
+		return
+	}
+	select {
+	case q.decisions <- d:
+	default:
+		log.Info("export queue is full, dropping admission decision", "uid", d.UID)
+	}
+}
+
+// Start exports the queued decisions until stop is closed
+func (q *Queue) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case d := <-q.decisions:
+			if err := q.reporter.ReportDecision(ctx, d); err != nil {
+				log.Error(err, "unable to export admission decision", "uid", d.UID)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package findings
+
+import (
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+// DetailsKey marks a result as an informational finding when it is set to
+// true in the result's details. Findings report facts about an object
+// without being violations: they never deny a request and audit records
+// them apart from violations.
+const DetailsKey = "finding"
+
+// IsFinding returns whether the result is an informational finding
+func IsFinding(r *rtypes.Result) bool {
+	details, ok := r.Metadata["details"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	finding, _ := details[DetailsKey].(bool)
+	return finding
+}
+
+// Violations returns the results that are not findings
+func Violations(results []*rtypes.Result) []*rtypes.Result {
+	var violations []*rtypes.Result
+	for _, r := range results {
+		if !IsFinding(r) {
+			violations = append(violations, r)
+		}
+	}
+	return violations
+}
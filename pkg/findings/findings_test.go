@@ -0,0 +1,38 @@
+package findings
+
+import (
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+)
+
+func TestIsFinding(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Metadata map[string]interface{}
+		Expected bool
+	}{
+		{Name: "No details", Metadata: map[string]interface{}{}},
+		{Name: "Violation details", Metadata: map[string]interface{}{"details": map[string]interface{}{"missing_labels": []interface{}{"owner"}}}},
+		{Name: "Finding", Metadata: map[string]interface{}{"details": map[string]interface{}{"finding": true, "registry": "gcr.io"}}, Expected: true},
+		{Name: "Finding set to false", Metadata: map[string]interface{}{"details": map[string]interface{}{"finding": false}}},
+		{Name: "Finding that is not a bool", Metadata: map[string]interface{}{"details": map[string]interface{}{"finding": "yes"}}},
+		{Name: "Details that are not an object", Metadata: map[string]interface{}{"details": "finding"}},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := IsFinding(&rtypes.Result{Metadata: tt.Metadata}); got != tt.Expected {
+				t.Errorf("IsFinding() = %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestViolations(t *testing.T) {
+	finding := &rtypes.Result{Msg: "finding", Metadata: map[string]interface{}{"details": map[string]interface{}{"finding": true}}}
+	violation := &rtypes.Result{Msg: "violation", Metadata: map[string]interface{}{}}
+	got := Violations([]*rtypes.Result{finding, violation})
+	if len(got) != 1 || got[0] != violation {
+		t.Errorf("Violations() = %v, want only the violation", got)
+	}
+}
@@ -0,0 +1,56 @@
+package invalidparams
+
+import (
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Annotation is set on the stand-in that is loaded into OPA for a constraint
+// whose parameters don't match its template's schema. Its value is the
+// validation error. The target rejects every request the stand-in matches.
+const Annotation = "constraint.gatekeeper.sh/invalid-parameters"
+
+// DetailsKey is set to true in the details of those rejections
+const DetailsKey = "invalidParameters"
+
+// StandIn returns a copy of constraint without its parameters or parameter
+// overrides, annotated with reason, that can be loaded into OPA in place of
+// the invalid constraint
+func StandIn(constraint *unstructured.Unstructured, reason string) *unstructured.Unstructured {
+	obj := constraint.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	unstructured.RemoveNestedField(obj.Object, "spec", "parameters")
+	unstructured.RemoveNestedField(obj.Object, "spec", "parameterOverrides")
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[Annotation] = reason
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+// Filter drops the results of stand-ins other than their rejections. The
+// stand-in's template was evaluated without the constraint's parameters, so
+// its own results are meaningless.
+func Filter(results []*rtypes.Result) []*rtypes.Result {
+	var filtered []*rtypes.Result
+	for _, r := range results {
+		if r.Constraint != nil {
+			if _, ok := r.Constraint.GetAnnotations()[Annotation]; ok && !isRejection(r) {
+				continue
+			}
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+func isRejection(r *rtypes.Result) bool {
+	details, ok := r.Metadata["details"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	rejection, _ := details[DetailsKey].(bool)
+	return rejection
+}
@@ -0,0 +1,65 @@
+package invalidparams
+
+import (
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newConstraint(name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"match":      map[string]interface{}{"kinds": []interface{}{}},
+			"parameters": map[string]interface{}{"labels": "owner"},
+			"parameterOverrides": []interface{}{
+				map[string]interface{}{"namespaces": []interface{}{"team-a"}, "parameters": map[string]interface{}{"labels": "team"}},
+			},
+		},
+		"status": map[string]interface{}{},
+	}}
+	u.SetKind("K8sRequiredLabels")
+	u.SetName(name)
+	return u
+}
+
+func TestStandIn(t *testing.T) {
+	c := newConstraint("owner")
+	s := StandIn(c, "spec.parameters.labels in body must be of type array")
+	if _, found, _ := unstructured.NestedFieldNoCopy(s.Object, "spec", "parameters"); found {
+		t.Error("expected the stand-in not to have parameters")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(s.Object, "spec", "parameterOverrides"); found {
+		t.Error("expected the stand-in not to have parameter overrides")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(s.Object, "status"); found {
+		t.Error("expected the stand-in not to have a status")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(s.Object, "spec", "match"); !found {
+		t.Error("expected the stand-in to keep its match criteria")
+	}
+	if s.GetAnnotations()[Annotation] == "" {
+		t.Errorf("expected the stand-in to be annotated with %s", Annotation)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(c.Object, "spec", "parameters"); !found {
+		t.Error("expected the constraint to be left unmodified")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	valid := newConstraint("valid")
+	standIn := StandIn(newConstraint("invalid"), "invalid")
+	results := []*rtypes.Result{
+		{Msg: "valid", Constraint: valid},
+		{Msg: "template", Constraint: standIn},
+		{Msg: "rejection", Constraint: standIn, Metadata: map[string]interface{}{"details": map[string]interface{}{DetailsKey: true}}},
+	}
+	got := Filter(results)
+	if len(got) != 2 || got[0].Msg != "valid" || got[1].Msg != "rejection" {
+		var msgs []string
+		for _, r := range got {
+			msgs = append(msgs, r.Msg)
+		}
+		t.Errorf("Filter() kept %v, want [valid rejection]", msgs)
+	}
+}
@@ -0,0 +1,99 @@
+package nsenforcement
+
+import (
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/severity"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Modes of combining a namespace's enforcement action with a constraint's
+const (
+	Override = "override"
+	Escalate = "escalate"
+)
+
+// strictness ranks enforcement actions, stricter actions rank higher.
+// Actions that are not listed rank lowest.
+var strictness = map[string]int{
+	string(util.Dryrun): 1,
+	string(util.Deny):   2,
+}
+
+// Action returns the enforcement action the first matching rule of the
+// Config assigns to the namespace. Rules with unsupported actions or invalid
+// selectors are skipped.
+func Action(cfg *v1alpha1.Config, ns *corev1.Namespace) (string, bool) {
+	if cfg == nil || ns == nil {
+		return "", false
+	}
+	for _, rule := range cfg.Spec.NamespaceEnforcement.Rules {
+		if util.ValidateEnforcementAction(util.EnforcementAction(rule.EnforcementAction)) != nil {
+			continue
+		}
+		if matches(rule, ns) {
+			return rule.EnforcementAction, true
+		}
+	}
+	return "", false
+}
+
+func matches(rule v1alpha1.NamespaceEnforcementRule, ns *corev1.Namespace) bool {
+	if len(rule.Namespaces) > 0 {
+		found := false
+		for _, n := range rule.Namespaces {
+			if n == ns.GetName() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if rule.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(rule.NamespaceSelector)
+		if err != nil {
+			return false
+		}
+		if !selector.Matches(labels.Set(ns.GetLabels())) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the results with the enforcement action the Config assigns
+// to the namespace. In escalate mode a result's action is only replaced by a
+// stricter one. Results whose action changes are copied, so results shared
+// with other requests are never modified.
+func Apply(cfg *v1alpha1.Config, ns *corev1.Namespace, results []*rtypes.Result) []*rtypes.Result {
+	action, ok := Action(cfg, ns)
+	if !ok {
+		return results
+	}
+	escalate := cfg.Spec.NamespaceEnforcement.Mode == Escalate
+	applied := make([]*rtypes.Result, 0, len(results))
+	for _, r := range results {
+		if r.EnforcementAction == action || (escalate && strictness[action] <= strictness[r.EnforcementAction]) {
+			applied = append(applied, r)
+			continue
+		}
+		changed := *r
+		changed.EnforcementAction = action
+		applied = append(applied, &changed)
+	}
+	return applied
+}
+
+// Resolve returns the results with their effective enforcement action. The
+// constraints' severityActions are applied first, and the action the Config
+// assigns to the namespace is then combined with theirs, so a namespace rule
+// can override or escalate an action set by a severity. The webhook, cached
+// decisions and audit all resolve actions here. Results are never modified.
+func Resolve(cfg *v1alpha1.Config, ns *corev1.Namespace, results []*rtypes.Result) []*rtypes.Result {
+	return Apply(cfg, ns, severity.Apply(results))
+}
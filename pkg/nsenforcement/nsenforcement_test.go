@@ -0,0 +1,83 @@
+package nsenforcement
+
+import (
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/severity"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestApply(t *testing.T) {
+	prod := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"tier": "production"}}}
+	dev := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox"}}
+	rules := []v1alpha1.NamespaceEnforcementRule{
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "production"}}, EnforcementAction: "deny"},
+		{Namespaces: []string{"sandbox"}, EnforcementAction: "dryrun"},
+	}
+	tc := []struct {
+		Name      string
+		Mode      string
+		Rules     []v1alpha1.NamespaceEnforcementRule
+		Namespace *corev1.Namespace
+		Action    string
+		Expected  string
+	}{
+		{Name: "override escalates", Rules: rules, Namespace: prod, Action: "dryrun", Expected: "deny"},
+		{Name: "override downgrades", Rules: rules, Namespace: dev, Action: "deny", Expected: "dryrun"},
+		{Name: "escalate only escalates", Mode: Escalate, Rules: rules, Namespace: dev, Action: "deny", Expected: "deny"},
+		{Name: "escalate", Mode: Escalate, Rules: rules, Namespace: prod, Action: "dryrun", Expected: "deny"},
+		{Name: "no matching rule", Rules: rules[:1], Namespace: dev, Action: "deny", Expected: "deny"},
+		{Name: "cluster-scoped object", Rules: rules, Action: "dryrun", Expected: "dryrun"},
+		{Name: "catch-all rule", Rules: []v1alpha1.NamespaceEnforcementRule{{EnforcementAction: "dryrun"}}, Namespace: prod, Action: "deny", Expected: "dryrun"},
+		{Name: "unsupported action skipped", Rules: []v1alpha1.NamespaceEnforcementRule{{EnforcementAction: "warn"}}, Namespace: prod, Action: "deny", Expected: "deny"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			cfg := &v1alpha1.Config{Spec: v1alpha1.ConfigSpec{NamespaceEnforcement: v1alpha1.NamespaceEnforcement{Mode: tt.Mode, Rules: tt.Rules}}}
+			original := &rtypes.Result{EnforcementAction: tt.Action}
+			got := Apply(cfg, tt.Namespace, []*rtypes.Result{original})
+			if got[0].EnforcementAction != tt.Expected {
+				t.Errorf("got action %q, want %q", got[0].EnforcementAction, tt.Expected)
+			}
+			if original.EnforcementAction != tt.Action {
+				t.Error("expected the original result to be left unmodified")
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"enforcementAction": "deny",
+			"severityActions":   map[string]interface{}{"low": "dryrun"},
+		},
+	}}
+	prod := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"tier": "production"}}}
+	dev := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox"}}
+	cfg := &v1alpha1.Config{Spec: v1alpha1.ConfigSpec{NamespaceEnforcement: v1alpha1.NamespaceEnforcement{
+		Mode: Escalate,
+		Rules: []v1alpha1.NamespaceEnforcementRule{
+			{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "production"}}, EnforcementAction: "deny"},
+		},
+	}}}
+	original := &rtypes.Result{
+		Constraint:        constraint,
+		EnforcementAction: "deny",
+		Metadata:          map[string]interface{}{"details": map[string]interface{}{severity.DetailsKey: "low"}},
+	}
+	// the severity sets dryrun, which the production namespace escalates
+	if got := Resolve(cfg, prod, []*rtypes.Result{original}); got[0].EnforcementAction != "deny" {
+		t.Errorf("got action %q in an escalated namespace, want deny", got[0].EnforcementAction)
+	}
+	if got := Resolve(cfg, dev, []*rtypes.Result{original}); got[0].EnforcementAction != "dryrun" {
+		t.Errorf("got action %q, want the severity's dryrun", got[0].EnforcementAction)
+	}
+	if original.EnforcementAction != "deny" {
+		t.Error("expected the original result to be left unmodified")
+	}
+}
@@ -0,0 +1,90 @@
+package objdiff
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Types of changes
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference between an old and a new object at one path. Path
+// holds the keys of maps and the indexes of lists leading to the value, e.g.
+// ["spec", "containers", 0, "image"]. Old is unset for added values and New is
+// unset for removed ones.
+type Change struct {
+	Type string        `json:"type"`
+	Path []interface{} `json:"path"`
+	Old  interface{}   `json:"old,omitempty"`
+	New  interface{}   `json:"new,omitempty"`
+}
+
+// Compute returns the changes from old to new, which are decoded JSON
+// values. Maps are compared key by key and lists index by index, so an
+// element inserted into a list changes every element after it. Changes are
+// ordered by path.
+func Compute(old, new interface{}) []Change {
+	var changes []Change
+	compute(nil, old, new, &changes)
+	return changes
+}
+
+func compute(path []interface{}, old, new interface{}, changes *[]Change) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(o)+len(n))
+			for k := range o {
+				keys = append(keys, k)
+			}
+			for k := range n {
+				if _, ok := o[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				ov, inOld := o[k]
+				nv, inNew := n[k]
+				switch {
+				case !inOld:
+					*changes = append(*changes, Change{Type: Added, Path: with(path, k), New: nv})
+				case !inNew:
+					*changes = append(*changes, Change{Type: Removed, Path: with(path, k), Old: ov})
+				default:
+					compute(with(path, k), ov, nv, changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			for i := 0; i < len(o) || i < len(n); i++ {
+				switch {
+				case i >= len(o):
+					*changes = append(*changes, Change{Type: Added, Path: with(path, i), New: n[i]})
+				case i >= len(n):
+					*changes = append(*changes, Change{Type: Removed, Path: with(path, i), Old: o[i]})
+				default:
+					compute(with(path, i), o[i], n[i], changes)
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Type: Changed, Path: with(path), Old: old, New: new})
+	}
+}
+
+// with returns a copy of path with elem appended, so that changes never
+// share their paths
+func with(path []interface{}, elem ...interface{}) []interface{} {
+	p := make([]interface{}, 0, len(path)+len(elem))
+	p = append(p, path...)
+	return append(p, elem...)
+}
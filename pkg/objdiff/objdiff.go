@@ -0,0 +1,110 @@
+package objdiff
+
+import (
+	"encoding/json"
+	"flag"
+	"sync"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+// Annotation declares that a template reads input.review.diff
+const Annotation = "metadata.gatekeeper.sh/requires-diff"
+
+var reviewDiff = flag.Bool("review-diff", false, "compute the changes between the old and new object of UPDATE requests and pass them to OPA as input.review.diff. Otherwise they are only computed while a template has the "+Annotation+" annotation")
+
+// Registry tracks the templates that require a diff
+type Registry struct {
+	mux   sync.RWMutex
+	kinds map[string]bool
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{kinds: make(map[string]bool)}
+}
+
+// Templates is filled by the template controller and read by the webhook
+// and audit
+var Templates = NewRegistry()
+
+// Set records whether the template with the constraint kind requires a diff
+func (r *Registry) Set(kind string, required bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if required {
+		r.kinds[kind] = true
+	} else {
+		delete(r.kinds, kind)
+	}
+}
+
+// Remove forgets the template
+func (r *Registry) Remove(kind string) {
+	r.Set(kind, false)
+}
+
+// Required returns whether the template with the constraint kind requires a
+// diff
+func (r *Registry) Required(kind string) bool {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.kinds[kind]
+}
+
+// Any returns whether any template requires a diff
+func (r *Registry) Any() bool {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return len(r.kinds) > 0
+}
+
+// FilterAudit drops the results of templates that require a diff. Audited
+// objects have no old object to diff against, so these templates can't be
+// evaluated correctly by audit.
+func (r *Registry) FilterAudit(results []*rtypes.Result) []*rtypes.Result {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	if len(r.kinds) == 0 {
+		return results
+	}
+	var filtered []*rtypes.Result
+	for _, res := range results {
+		if res.Constraint != nil && r.kinds[res.Constraint.GetKind()] {
+			continue
+		}
+		filtered = append(filtered, res)
+	}
+	return filtered
+}
+
+// Enabled returns whether diffs are computed. All templates are evaluated
+// against the same input, so one template requiring them computes them for
+// every review.
+func Enabled() bool {
+	return *reviewDiff || Templates.Any()
+}
+
+// Request returns the changes between the old and new object of an UPDATE
+// request. It returns false for other requests, where there is nothing to
+// diff.
+func Request(req *admissionv1beta1.AdmissionRequest) ([]Change, bool, error) {
+	if req.Operation != admissionv1beta1.Update || len(req.Object.Raw) == 0 || len(req.OldObject.Raw) == 0 {
+		return nil, false, nil
+	}
+	var old, new interface{}
+	if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(req.Object.Raw, &new); err != nil {
+		return nil, false, err
+	}
+	// an empty list rather than none, so templates can tell unchanged
+	// objects from requests without a diff
+	changes := Compute(old, new)
+	if changes == nil {
+		changes = []Change{}
+	}
+	return changes, true, nil
+}
@@ -0,0 +1,112 @@
+package objdiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestCompute(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Old      string
+		New      string
+		Expected []Change
+	}{
+		{
+			Name: "unchanged",
+			Old:  `{"spec": {"replicas": 1}}`,
+			New:  `{"spec": {"replicas": 1}}`,
+		},
+		{
+			Name:     "changed value",
+			Old:      `{"spec": {"replicas": 1}}`,
+			New:      `{"spec": {"replicas": 3}}`,
+			Expected: []Change{{Type: Changed, Path: []interface{}{"spec", "replicas"}, Old: float64(1), New: float64(3)}},
+		},
+		{
+			Name: "added and removed keys",
+			Old:  `{"metadata": {"labels": {"a": "1"}}}`,
+			New:  `{"metadata": {"labels": {"b": "2"}}}`,
+			Expected: []Change{
+				{Type: Removed, Path: []interface{}{"metadata", "labels", "a"}, Old: "1"},
+				{Type: Added, Path: []interface{}{"metadata", "labels", "b"}, New: "2"},
+			},
+		},
+		{
+			Name: "list elements",
+			Old:  `{"spec": {"containers": [{"image": "nginx:1"}]}}`,
+			New:  `{"spec": {"containers": [{"image": "nginx:2"}, {"image": "sidecar"}]}}`,
+			Expected: []Change{
+				{Type: Changed, Path: []interface{}{"spec", "containers", 0, "image"}, Old: "nginx:1", New: "nginx:2"},
+				{Type: Added, Path: []interface{}{"spec", "containers", 1}, New: map[string]interface{}{"image": "sidecar"}},
+			},
+		},
+		{
+			Name:     "changed type",
+			Old:      `{"data": {"a": "b"}}`,
+			New:      `{"data": ["a"]}`,
+			Expected: []Change{{Type: Changed, Path: []interface{}{"data"}, Old: map[string]interface{}{"a": "b"}, New: []interface{}{"a"}}},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			got := Compute(decode(t, tt.Old), decode(t, tt.New))
+			if !reflect.DeepEqual(got, tt.Expected) {
+				t.Errorf("Compute() = %#v, want %#v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	req := &admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Update,
+		OldObject: runtime.RawExtension{Raw: []byte(`{"spec": {"replicas": 1}}`)},
+		Object:    runtime.RawExtension{Raw: []byte(`{"spec": {"replicas": 1}}`)},
+	}
+	changes, ok, err := Request(req)
+	if err != nil || !ok || changes == nil || len(changes) != 0 {
+		t.Errorf("got %v, %v, %v, want an empty diff for an unchanged object", changes, ok, err)
+	}
+
+	req.Operation = admissionv1beta1.Create
+	if _, ok, _ := Request(req); ok {
+		t.Error("expected no diff for a CREATE request")
+	}
+}
+
+func TestFilterAudit(t *testing.T) {
+	r := NewRegistry()
+	diffed := &unstructured.Unstructured{}
+	diffed.SetKind("K8sImmutableField")
+	other := &unstructured.Unstructured{}
+	other.SetKind("K8sRequiredLabels")
+	results := []*rtypes.Result{{Constraint: diffed}, {Constraint: other}}
+	if got := r.FilterAudit(results); len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	r.Set("K8sImmutableField", true)
+	got := r.FilterAudit(results)
+	if len(got) != 1 || got[0].Constraint != other {
+		t.Errorf("expected only the results of templates without diffs to be audited, got %v", got)
+	}
+	r.Remove("K8sImmutableField")
+	if r.Any() {
+		t.Error("expected no templates to require diffs")
+	}
+}
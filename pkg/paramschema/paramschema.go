@@ -0,0 +1,67 @@
+package paramschema
+
+import (
+	"sync"
+
+	"github.com/go-openapi/validate"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Registry holds the parameter schema of each template, so parameters that
+// the constraint CRD doesn't validate, such as those of parameterOverrides,
+// can be checked against it
+type Registry struct {
+	mux   sync.RWMutex
+	kinds map[string]*validate.SchemaValidator
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{kinds: make(map[string]*validate.SchemaValidator)}
+}
+
+// Templates is filled by the template controller and read by the target when
+// it validates constraints
+var Templates = NewRegistry()
+
+// Set records the parameter schema of the template with the constraint kind.
+// A template without a schema accepts any parameters.
+func (r *Registry) Set(kind string, ct *templates.ConstraintTemplate) error {
+	if ct.Spec.CRD.Spec.Validation == nil || ct.Spec.CRD.Spec.Validation.OpenAPIV3Schema == nil {
+		r.Remove(kind)
+		return nil
+	}
+	validator, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: ct.Spec.CRD.Spec.Validation.OpenAPIV3Schema})
+	if err != nil {
+		return err
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.kinds[kind] = validator
+	return nil
+}
+
+// Remove forgets the template
+func (r *Registry) Remove(kind string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.kinds, kind)
+}
+
+// Validate checks the parameters against the schema of the template with the
+// constraint kind. Kinds without a schema are not checked.
+func (r *Registry) Validate(kind string, fldPath *field.Path, parameters map[string]interface{}) error {
+	r.mux.RLock()
+	validator, ok := r.kinds[kind]
+	r.mux.RUnlock()
+	if !ok {
+		return nil
+	}
+	if errs := validation.ValidateCustomResource(fldPath, parameters, validator); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package paramschema
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	path := field.NewPath("spec", "parameterOverrides").Index(0).Child("parameters")
+	params := map[string]interface{}{"labels": "owner"}
+	if err := r.Validate("K8sRequiredLabels", path, params); err != nil {
+		t.Errorf("unexpected error for a kind without a schema: %v", err)
+	}
+
+	ct := &templates.ConstraintTemplate{}
+	ct.Spec.CRD.Spec.Validation = &templates.Validation{OpenAPIV3Schema: &apiextensions.JSONSchemaProps{
+		Properties: map[string]apiextensions.JSONSchemaProps{
+			"labels": {Type: "array", Items: &apiextensions.JSONSchemaPropsOrArray{Schema: &apiextensions.JSONSchemaProps{Type: "string"}}},
+		},
+	}}
+	if err := r.Set("K8sRequiredLabels", ct); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Validate("K8sRequiredLabels", path, params); err == nil {
+		t.Error("expected parameters of the wrong type to be rejected")
+	}
+	if err := r.Validate("K8sRequiredLabels", path, map[string]interface{}{"labels": []interface{}{"owner"}}); err != nil {
+		t.Errorf("unexpected error for matching parameters: %v", err)
+	}
+
+	if err := r.Set("K8sRequiredLabels", &templates.ConstraintTemplate{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Validate("K8sRequiredLabels", path, params); err != nil {
+		t.Errorf("unexpected error after the schema was dropped: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package policyset
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Label assigns a constraint to a named policy set. Constraints without
+// this label are not part of any set and are always enforced.
+const Label = "policyset.gatekeeper.sh/name"
+
+var defaultActiveSets = newSetList()
+
+func init() {
+	flag.Var(defaultActiveSets, "active-policy-set", "policy set to enforce when the Config resource does not list any active policy sets. To activate multiple sets, this flag can be declared more than once.")
+}
+
+type setList map[string]bool
+
+var _ flag.Value = setList{}
+
+func newSetList() setList {
+	return make(map[string]bool)
+}
+
+func (l setList) String() string {
+	contents := make([]string, 0, len(l))
+	for k := range l {
+		contents = append(contents, k)
+	}
+	sort.Strings(contents)
+	return fmt.Sprintf("%s", contents)
+}
+
+func (l setList) Set(s string) error {
+	l[s] = true
+	return nil
+}
+
+// Active is the set of policy sets currently being enforced
+type Active map[string]bool
+
+// ActiveSets returns the active policy sets. The list on the Config
+// resource takes precedence over the --active-policy-set flag so that a
+// single Config update switches every set at once.
+func ActiveSets(cfg *v1alpha1.Config) Active {
+	active := make(Active)
+	if cfg != nil && len(cfg.Spec.PolicySets.Active) > 0 {
+		for _, s := range cfg.Spec.PolicySets.Active {
+			active[s] = true
+		}
+		return active
+	}
+	for s := range defaultActiveSets {
+		active[s] = true
+	}
+	return active
+}
+
+// IsActive returns true if the constraint should be enforced
+func (a Active) IsActive(constraint *unstructured.Unstructured) bool {
+	if constraint == nil {
+		return true
+	}
+	set, ok := constraint.GetLabels()[Label]
+	if !ok {
+		return true
+	}
+	return a[set]
+}
+
+// Filter drops results produced by constraints whose policy set is inactive
+func (a Active) Filter(results []*rtypes.Result) []*rtypes.Result {
+	var filtered []*rtypes.Result
+	for _, r := range results {
+		if a.IsActive(r.Constraint) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
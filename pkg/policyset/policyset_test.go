@@ -0,0 +1,109 @@
+package policyset
+
+import (
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newConstraint(name, set string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetName(name)
+	if set != "" {
+		u.SetLabels(map[string]string{Label: set})
+	}
+	return u
+}
+
+func TestActiveSets(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Flag     []string
+		Config   *v1alpha1.Config
+		Expected Active
+	}{
+		{
+			Name:     "No config, no flag",
+			Expected: Active{},
+		},
+		{
+			Name:     "Flag default",
+			Flag:     []string{"normal"},
+			Config:   &v1alpha1.Config{},
+			Expected: Active{"normal": true},
+		},
+		{
+			Name: "Config overrides flag",
+			Flag: []string{"normal"},
+			Config: &v1alpha1.Config{
+				Spec: v1alpha1.ConfigSpec{PolicySets: v1alpha1.PolicySets{Active: []string{"lockdown", "baseline"}}},
+			},
+			Expected: Active{"lockdown": true, "baseline": true},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			defaultActiveSets = newSetList()
+			for _, s := range tt.Flag {
+				if err := defaultActiveSets.Set(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			defer func() { defaultActiveSets = newSetList() }()
+
+			active := ActiveSets(tt.Config)
+			if len(active) != len(tt.Expected) {
+				t.Fatalf("got %v, want %v", active, tt.Expected)
+			}
+			for k := range tt.Expected {
+				if !active[k] {
+					t.Errorf("expected set %s to be active, got %v", k, active)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	results := []*rtypes.Result{
+		{Constraint: newConstraint("unlabeled", "")},
+		{Constraint: newConstraint("normal", "normal")},
+		{Constraint: newConstraint("lockdown", "lockdown")},
+	}
+	tc := []struct {
+		Name     string
+		Active   Active
+		Expected []string
+	}{
+		{
+			Name:     "No active sets",
+			Active:   Active{},
+			Expected: []string{"unlabeled"},
+		},
+		{
+			Name:     "Normal",
+			Active:   Active{"normal": true},
+			Expected: []string{"unlabeled", "normal"},
+		},
+		{
+			Name:     "Lockdown",
+			Active:   Active{"lockdown": true},
+			Expected: []string{"unlabeled", "lockdown"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			filtered := tt.Active.Filter(results)
+			if len(filtered) != len(tt.Expected) {
+				t.Fatalf("got %d results, want %d", len(filtered), len(tt.Expected))
+			}
+			for i, r := range filtered {
+				if r.Constraint.GetName() != tt.Expected[i] {
+					t.Errorf("result %d: got %s, want %s", i, r.Constraint.GetName(), tt.Expected[i])
+				}
+			}
+		})
+	}
+}
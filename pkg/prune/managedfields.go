@@ -0,0 +1,32 @@
+package prune
+
+import (
+	"flag"
+)
+
+// ManagedFieldsAnnotation declares that a template reads
+// metadata.managedFields, which is otherwise removed from reviewed objects
+const ManagedFieldsAnnotation = "metadata.gatekeeper.sh/requires-managed-fields"
+
+var includeManagedFields = flag.Bool("include-managed-fields", false, "keep metadata.managedFields in the objects passed to OPA for review. Otherwise they are only kept while a template has the "+ManagedFieldsAnnotation+" annotation")
+
+// KeepManagedFields returns whether reviewed objects should keep their
+// managedFields. All templates are evaluated against the same input, so one
+// template requiring them keeps them for every review.
+func KeepManagedFields() bool {
+	return *includeManagedFields || Templates.ManagedFieldsRequired()
+}
+
+// StripManagedFields removes metadata.managedFields from obj and returns
+// whether it was present
+func StripManagedFields(obj map[string]interface{}) bool {
+	meta, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, ok := meta["managedFields"]; !ok {
+		return false
+	}
+	delete(meta, "managedFields")
+	return true
+}
@@ -0,0 +1,256 @@
+package prune
+
+import (
+	"sync"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// alwaysKept holds the fields of the reviewed object that the target library
+// needs for matching, regardless of what templates reference
+var alwaysKept = append([]Path{{"apiVersion"}, {"kind"}, {"metadata"}}, osPaths()...)
+
+// osPaths are the pod spec fields match.os reads to detect the OS of a Pod,
+// a pod template or a CronJob's pod template. Keep in sync with pod_spec_os
+// in pkg/target/regolib/src.rego.
+func osPaths() []Path {
+	specs := []Path{{"spec"}, {"spec", "template", "spec"}, {"spec", "jobTemplate", "spec", "template", "spec"}}
+	fields := []string{"os", "nodeSelector", "affinity", "tolerations"}
+	var paths []Path
+	for _, spec := range specs {
+		for _, f := range fields {
+			paths = append(paths, append(append(Path{}, spec...), f))
+		}
+	}
+	return paths
+}
+
+// Path is a sequence of object keys
+type Path []string
+
+// References extracts the paths of input.review.object and
+// input.review.oldObject that the modules reference. It returns false
+// when a module uses the object in a way that can't be determined
+// statically, e.g. by binding the whole object to a variable or by
+// indexing it with a variable.
+func References(modules ...string) ([]Path, bool, error) {
+	var paths []Path
+	known := true
+	for _, src := range modules {
+		module, err := ast.ParseModule("", src)
+		if err != nil {
+			return nil, false, err
+		}
+		if module == nil {
+			continue
+		}
+		inputRefs, inputTerms := 0, 0
+		ast.WalkRefs(module, func(ref ast.Ref) bool {
+			if ref[0].Equal(ast.InputRootDocument) {
+				inputRefs++
+			}
+			path, ok := objectPath(ref)
+			if !ok {
+				known = false
+			} else if path != nil {
+				paths = append(paths, path)
+			}
+			return false
+		})
+		ast.WalkTerms(module, func(t *ast.Term) bool {
+			if t.Equal(ast.InputRootDocument) {
+				inputTerms++
+			}
+			return false
+		})
+		// input used on its own, e.g. `x := input`
+		if inputTerms != inputRefs {
+			known = false
+		}
+	}
+	if !known {
+		return nil, false, nil
+	}
+	return paths, true, nil
+}
+
+// objectPath returns the static object path referenced by ref, nil if ref
+// does not reference the reviewed object, and false if the path can't be
+// determined.
+func objectPath(ref ast.Ref) (Path, bool) {
+	if !ref[0].Equal(ast.InputRootDocument) {
+		return nil, true
+	}
+	keys := stringPrefix(ref[1:])
+	if len(keys) == 0 {
+		// all of input is referenced
+		return nil, false
+	}
+	if keys[0] != "review" {
+		return nil, true
+	}
+	if len(keys) == 1 {
+		// all of input.review is referenced
+		return nil, false
+	}
+	if keys[1] != "object" && keys[1] != "oldObject" {
+		return nil, true
+	}
+	if len(keys) == 2 {
+		return nil, false
+	}
+	return Path(keys[2:]), true
+}
+
+// stringPrefix returns the leading string terms of ref
+func stringPrefix(ref ast.Ref) []string {
+	var keys []string
+	for _, t := range ref {
+		s, ok := t.Value.(ast.String)
+		if !ok {
+			break
+		}
+		keys = append(keys, string(s))
+	}
+	return keys
+}
+
+// Object returns a copy of obj holding only the given paths. The value at
+// the end of a path is shared with obj, not copied.
+func Object(obj map[string]interface{}, paths []Path) map[string]interface{} {
+	all := make([]Path, 0, len(paths)+len(alwaysKept))
+	all = append(all, paths...)
+	all = append(all, alwaysKept...)
+	return newTrie(all).prune(obj)
+}
+
+type trie struct {
+	children map[string]*trie
+	// leaf means the whole subtree is kept
+	leaf bool
+}
+
+func newTrie(paths []Path) *trie {
+	root := &trie{children: make(map[string]*trie)}
+	for _, p := range paths {
+		node := root
+		for _, k := range p {
+			if node.leaf {
+				break
+			}
+			child, ok := node.children[k]
+			if !ok {
+				child = &trie{children: make(map[string]*trie)}
+				node.children[k] = child
+			}
+			node = child
+		}
+		node.leaf = true
+		node.children = nil
+	}
+	return root
+}
+
+func (t *trie) prune(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	for k, child := range t.children {
+		v, ok := obj[k]
+		if !ok {
+			continue
+		}
+		if child.leaf {
+			out[k] = v
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			// not an object, so the path ends here
+			out[k] = v
+			continue
+		}
+		// objects without any of the kept fields are left out, so that paths
+		// kept for matching don't add empty objects to every review
+		if pruned := child.prune(m); len(pruned) > 0 {
+			out[k] = pruned
+		}
+	}
+	return out
+}
+
+// Registry tracks the object paths referenced by each template
+type Registry struct {
+	mux   sync.RWMutex
+	paths map[string][]Path
+	// unknown holds the templates whose references can't be determined
+	unknown map[string]bool
+	// managedFields holds the templates that require managedFields
+	managedFields map[string]bool
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		paths:         make(map[string][]Path),
+		unknown:       make(map[string]bool),
+		managedFields: make(map[string]bool),
+	}
+}
+
+// Templates is filled by the template controller and read by the webhook
+var Templates = NewRegistry()
+
+// Set records the references of the template's Rego and libraries
+func (r *Registry) Set(kind string, modules ...string) {
+	paths, known, err := References(modules...)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if err != nil || !known {
+		delete(r.paths, kind)
+		r.unknown[kind] = true
+		return
+	}
+	delete(r.unknown, kind)
+	r.paths[kind] = paths
+}
+
+// Remove forgets the template
+func (r *Registry) Remove(kind string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.paths, kind)
+	delete(r.unknown, kind)
+	delete(r.managedFields, kind)
+}
+
+// SetManagedFields records whether the template requires managedFields
+func (r *Registry) SetManagedFields(kind string, required bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if required {
+		r.managedFields[kind] = true
+	} else {
+		delete(r.managedFields, kind)
+	}
+}
+
+// ManagedFieldsRequired returns whether any template requires managedFields
+func (r *Registry) ManagedFieldsRequired() bool {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return len(r.managedFields) > 0
+}
+
+// Paths returns the union of the paths referenced by all templates, or
+// false if any template's references are unknown
+func (r *Registry) Paths() ([]Path, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	if len(r.unknown) > 0 {
+		return nil, false
+	}
+	var all []Path
+	for _, p := range r.paths {
+		all = append(all, p...)
+	}
+	return all, true
+}
@@ -0,0 +1,238 @@
+package prune
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	tc := []struct {
+		Name          string
+		Modules       []string
+		ExpectedPaths []Path
+		ExpectKnown   bool
+		ExpectErr     bool
+	}{
+		{
+			Name: "Static paths",
+			Modules: []string{`package foo
+violation[{"msg": msg}] {
+  container := input.review.object.spec.containers[_]
+  not startswith(container.image, input.parameters.repo)
+  input.review.oldObject.metadata.labels["x"]
+  input.review.kind.kind == "Pod"
+  msg := "bad"
+}`},
+			ExpectedPaths: []Path{{"spec", "containers"}, {"metadata", "labels", "x"}},
+			ExpectKnown:   true,
+		},
+		{
+			Name: "Library references",
+			Modules: []string{`package foo
+import data.lib.helpers
+violation[{"msg": "bad"}] { helpers.bad }`, `package lib.helpers
+bad { input.review.object.spec.hostNetwork }`},
+			ExpectedPaths: []Path{{"spec", "hostNetwork"}},
+			ExpectKnown:   true,
+		},
+		{
+			Name: "Whole object",
+			Modules: []string{`package foo
+violation[{"msg": "bad"}] { obj := input.review.object; obj.spec.x }`},
+		},
+		{
+			Name: "Variable key",
+			Modules: []string{`package foo
+violation[{"msg": "bad"}] { input.review.object[k]; k == "spec" }`},
+		},
+		{
+			Name: "Whole review",
+			Modules: []string{`package foo
+violation[{"msg": "bad"}] { r := input.review; r.object.spec.x }`},
+		},
+		{
+			Name: "Bare input",
+			Modules: []string{`package foo
+violation[{"msg": "bad"}] { i := input; i.review.object.spec.x }`},
+		},
+		{
+			Name: "Import of object",
+			Modules: []string{`package foo
+import input.review.object as obj
+violation[{"msg": "bad"}] { obj.spec.x }`},
+		},
+		{
+			Name: "Builtin over object",
+			Modules: []string{`package foo
+violation[{"msg": "bad"}] { walk(input.review.object, [p, v]); v == "x" }`},
+		},
+		{
+			Name:      "Parse error",
+			Modules:   []string{`package foo violation[`},
+			ExpectErr: true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			paths, known, err := References(tt.Modules...)
+			if (err != nil) != tt.ExpectErr {
+				t.Fatalf("err = %v, expected error: %v", err, tt.ExpectErr)
+			}
+			if known != tt.ExpectKnown {
+				t.Fatalf("known = %v, want %v", known, tt.ExpectKnown)
+			}
+			if !reflect.DeepEqual(paths, tt.ExpectedPaths) {
+				t.Errorf("paths = %v, want %v", paths, tt.ExpectedPaths)
+			}
+		})
+	}
+}
+
+func TestObject(t *testing.T) {
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "foo", "labels": map[string]interface{}{"a": "b"}},
+		"spec": map[string]interface{}{
+			"containers":  []interface{}{map[string]interface{}{"image": "nginx"}},
+			"hostNetwork": true,
+			"volumes":     []interface{}{"big"},
+			"nodeName":    "node",
+		},
+		"status": map[string]interface{}{"phase": "Running"},
+		"data":   "a lot of data",
+	}
+	tc := []struct {
+		Name     string
+		Paths    []Path
+		Expected map[string]interface{}
+	}{
+		{
+			Name: "Metadata is always kept",
+			Expected: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]interface{}{"name": "foo", "labels": map[string]interface{}{"a": "b"}},
+			},
+		},
+		{
+			Name:  "Nested paths",
+			Paths: []Path{{"spec", "containers"}, {"spec", "hostNetwork"}, {"spec", "missing"}, {"status", "phase", "deeper"}},
+			Expected: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]interface{}{"name": "foo", "labels": map[string]interface{}{"a": "b"}},
+				"spec": map[string]interface{}{
+					"containers":  []interface{}{map[string]interface{}{"image": "nginx"}},
+					"hostNetwork": true,
+				},
+				"status": map[string]interface{}{"phase": "Running"},
+			},
+		},
+		{
+			Name:  "Shorter path wins",
+			Paths: []Path{{"spec", "nodeName"}, {"spec"}, {"spec", "volumes"}},
+			Expected: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata":   map[string]interface{}{"name": "foo", "labels": map[string]interface{}{"a": "b"}},
+				"spec":       obj["spec"],
+			},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			got := Object(obj, tt.Paths)
+			if !reflect.DeepEqual(got, tt.Expected) {
+				t.Errorf("got %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestObjectKeepsOSFields(t *testing.T) {
+	windows := map[string]interface{}{
+		"nodeSelector": map[string]interface{}{"kubernetes.io/os": "windows"},
+		"tolerations":  []interface{}{map[string]interface{}{"key": "os", "value": "windows"}},
+		"containers":   []interface{}{"big"},
+	}
+	kept := map[string]interface{}{
+		"nodeSelector": windows["nodeSelector"],
+		"tolerations":  windows["tolerations"],
+	}
+	tc := []struct {
+		Name     string
+		Obj      map[string]interface{}
+		Expected map[string]interface{}
+	}{
+		{
+			Name:     "Pod",
+			Obj:      map[string]interface{}{"kind": "Pod", "spec": windows},
+			Expected: map[string]interface{}{"kind": "Pod", "spec": kept},
+		},
+		{
+			Name:     "Pod template",
+			Obj:      map[string]interface{}{"kind": "Deployment", "spec": map[string]interface{}{"replicas": 2, "template": map[string]interface{}{"spec": windows}}},
+			Expected: map[string]interface{}{"kind": "Deployment", "spec": map[string]interface{}{"template": map[string]interface{}{"spec": kept}}},
+		},
+		{
+			Name:     "CronJob",
+			Obj:      map[string]interface{}{"kind": "CronJob", "spec": map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": windows}}}}},
+			Expected: map[string]interface{}{"kind": "CronJob", "spec": map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": kept}}}}},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			got := Object(tt.Obj, nil)
+			if !reflect.DeepEqual(got, tt.Expected) {
+				t.Errorf("got %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if _, ok := r.Paths(); !ok {
+		t.Error("empty registry should have known paths")
+	}
+	r.Set("Static", `package a
+violation[{"msg": "x"}] { input.review.object.spec.x }`)
+	r.Set("Dynamic", `package b
+violation[{"msg": "x"}] { o := input.review.object; o.spec.y }`)
+	if _, ok := r.Paths(); ok {
+		t.Error("expected unknown paths while Dynamic is registered")
+	}
+	r.Remove("Dynamic")
+	paths, ok := r.Paths()
+	if !ok {
+		t.Fatal("expected known paths after removing Dynamic")
+	}
+	if !reflect.DeepEqual(paths, []Path{{"spec", "x"}}) {
+		t.Errorf("paths = %v", paths)
+	}
+}
+
+func TestManagedFields(t *testing.T) {
+	r := NewRegistry()
+	r.SetManagedFields("FieldOwners", true)
+	r.SetManagedFields("Labels", false)
+	if !r.ManagedFieldsRequired() {
+		t.Error("expected managedFields to be required while FieldOwners is registered")
+	}
+	r.Remove("FieldOwners")
+	if r.ManagedFieldsRequired() {
+		t.Error("expected managedFields not to be required after removing FieldOwners")
+	}
+
+	obj := map[string]interface{}{"metadata": map[string]interface{}{"name": "foo", "managedFields": []interface{}{}}}
+	if !StripManagedFields(obj) {
+		t.Error("expected managedFields to be stripped")
+	}
+	if !reflect.DeepEqual(obj, map[string]interface{}{"metadata": map[string]interface{}{"name": "foo"}}) {
+		t.Errorf("stripped object = %v", obj)
+	}
+	if StripManagedFields(obj) {
+		t.Error("expected nothing to strip")
+	}
+}
@@ -0,0 +1,56 @@
+package severity
+
+import (
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DetailsKey is the key of a violation's severity in its details
+const DetailsKey = "severity"
+
+// Actions returns the constraint's spec.severityActions, which map the
+// severities its template emits to enforcement actions
+func Actions(constraint *unstructured.Unstructured) (map[string]string, bool, error) {
+	return unstructured.NestedStringMap(constraint.Object, "spec", "severityActions")
+}
+
+// Apply returns the results with the enforcement action their constraint's
+// severityActions maps their severity to. Results without a severity, or with
+// one the constraint doesn't map, keep the constraint's enforcementAction.
+// Results whose action changes are copied, so results shared with other
+// requests are never modified.
+func Apply(results []*rtypes.Result) []*rtypes.Result {
+	applied := make([]*rtypes.Result, 0, len(results))
+	for _, r := range results {
+		action, ok := mappedAction(r)
+		if !ok || r.EnforcementAction == action {
+			applied = append(applied, r)
+			continue
+		}
+		changed := *r
+		changed.EnforcementAction = action
+		applied = append(applied, &changed)
+	}
+	return applied
+}
+
+// mappedAction returns the action the result's constraint maps its severity to
+func mappedAction(r *rtypes.Result) (string, bool) {
+	if r.Constraint == nil {
+		return "", false
+	}
+	details, ok := r.Metadata["details"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	severity, ok := details[DetailsKey].(string)
+	if !ok {
+		return "", false
+	}
+	actions, _, err := Actions(r.Constraint)
+	if err != nil {
+		return "", false
+	}
+	action, ok := actions[severity]
+	return action, ok
+}
@@ -0,0 +1,58 @@
+package severity
+
+import (
+	"testing"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestApply(t *testing.T) {
+	constraint := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"enforcementAction": "dryrun",
+			"severityActions":   map[string]interface{}{"critical": "deny"},
+		},
+	}}
+	withSeverity := func(s string) map[string]interface{} {
+		return map[string]interface{}{"details": map[string]interface{}{DetailsKey: s}}
+	}
+	tc := []struct {
+		Name     string
+		Result   *rtypes.Result
+		Expected string
+	}{
+		{
+			Name:     "mapped severity",
+			Result:   &rtypes.Result{Constraint: constraint, EnforcementAction: "dryrun", Metadata: withSeverity("critical")},
+			Expected: "deny",
+		},
+		{
+			Name:     "unmapped severity",
+			Result:   &rtypes.Result{Constraint: constraint, EnforcementAction: "dryrun", Metadata: withSeverity("low")},
+			Expected: "dryrun",
+		},
+		{
+			Name:     "no severity",
+			Result:   &rtypes.Result{Constraint: constraint, EnforcementAction: "dryrun"},
+			Expected: "dryrun",
+		},
+		{
+			Name:     "constraint without severityActions",
+			Result:   &rtypes.Result{Constraint: &unstructured.Unstructured{Object: map[string]interface{}{}}, EnforcementAction: "deny", Metadata: withSeverity("low")},
+			Expected: "deny",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			original := tt.Result.EnforcementAction
+			got := Apply([]*rtypes.Result{tt.Result})
+			if got[0].EnforcementAction != tt.Expected {
+				t.Errorf("enforcementAction = %s, want %s", got[0].EnforcementAction, tt.Expected)
+			}
+			if tt.Result.EnforcementAction != original {
+				t.Error("expected the original result to be left unmodified")
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package statusreaper
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("controller").WithValues(logging.Process, "status_reaper")
+
+var (
+	reapInterval    = flag.Duration("stale-status-interval", 10*time.Minute, "interval at which byPod statuses of Gatekeeper pods that no longer exist are removed from templates and constraints, 0 to disable")
+	reapGracePeriod = flag.Duration("stale-status-grace-period", 10*time.Minute, "how long a Gatekeeper pod must be missing before its byPod statuses are removed")
+)
+
+// Manager periodically removes byPod statuses written by Gatekeeper pods
+// that no longer exist
+type Manager struct {
+	mgr     manager.Manager
+	ctx     context.Context
+	tracker *tracker
+}
+
+// New creates a new status reaper
+func New(ctx context.Context, mgr manager.Manager) (*Manager, error) {
+	return &Manager{
+		mgr:     mgr,
+		ctx:     ctx,
+		tracker: newTracker(*reapGracePeriod),
+	}, nil
+}
+
+// Start implements the Runnable interface
+func (rm *Manager) Start(stop <-chan struct{}) error {
+	log.Info("Starting Status Reaper")
+	ctx, cancel := context.WithCancel(rm.ctx)
+	defer cancel()
+	ticker := time.NewTicker(*reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			log.Info("Stopping status reaper")
+			return nil
+		case <-ticker.C:
+			if err := rm.reap(ctx); err != nil {
+				log.Error(err, "status reaper reap() failed")
+			}
+		}
+	}
+}
+
+func (rm *Manager) reap(ctx context.Context) error {
+	// new client to get updated restmapper, and to avoid caching every kind
+	c, err := client.New(rm.mgr.GetConfig(), client.Options{Scheme: rm.mgr.GetScheme(), Mapper: nil})
+	if err != nil {
+		return err
+	}
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(util.GetNamespace())); err != nil {
+		return err
+	}
+	live := make(map[string]bool, len(pods.Items))
+	for _, p := range pods.Items {
+		live[p.GetName()] = true
+	}
+	// a list that doesn't include this pod can't be trusted
+	if id := util.GetID(); id == "" || !live[id] {
+		log.Info("skipping status reaping, this pod is missing from the pod list", "pod", id)
+		return nil
+	}
+
+	templates := &v1beta1.ConstraintTemplateList{}
+	if err := c.List(ctx, templates); err != nil {
+		return err
+	}
+	var constraints []unstructured.Unstructured
+	for _, t := range templates.Items {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   "constraints.gatekeeper.sh",
+			Version: "v1beta1",
+			Kind:    t.Spec.CRD.Spec.Names.Kind + "List",
+		})
+		if err := c.List(ctx, list); err != nil {
+			// the constraint CRD may not have been created yet
+			log.Error(err, "unable to list constraints", logging.ConstraintKind, t.Spec.CRD.Spec.Names.Kind)
+			continue
+		}
+		constraints = append(constraints, list.Items...)
+	}
+
+	ids := make(map[string]bool)
+	for _, t := range templates.Items {
+		for _, s := range t.Status.ByPod {
+			ids[s.ID] = true
+		}
+	}
+	for i := range constraints {
+		constraintIDs, err := constraintStatusIDs(&constraints[i])
+		if err != nil {
+			log.Error(err, "unable to read constraint status", logging.ConstraintName, constraints[i].GetName())
+			continue
+		}
+		for _, id := range constraintIDs {
+			ids[id] = true
+		}
+	}
+	stale := rm.tracker.stale(ids, live, time.Now())
+	if len(stale) == 0 {
+		return nil
+	}
+
+	var errs int
+	for i := range templates.Items {
+		t := &templates.Items[i]
+		if !pruneTemplateStatus(t, stale) {
+			continue
+		}
+		if err := c.Status().Update(ctx, t); err != nil {
+			log.Error(err, "unable to remove stale statuses", logging.TemplateName, t.GetName())
+			errs++
+			continue
+		}
+		log.Info("removed stale statuses", logging.EventType, "stale_status_removed", logging.TemplateName, t.GetName())
+	}
+	for i := range constraints {
+		obj := &constraints[i]
+		pruned, err := pruneConstraintStatus(obj, stale)
+		if err != nil || !pruned {
+			continue
+		}
+		if err := c.Status().Update(ctx, obj); err != nil {
+			log.Error(err, "unable to remove stale statuses", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+			errs++
+			continue
+		}
+		log.Info("removed stale statuses", logging.EventType, "stale_status_removed", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+	}
+	if errs > 0 {
+		return fmt.Errorf("%d status updates failed, they will be retried next interval", errs)
+	}
+	return nil
+}
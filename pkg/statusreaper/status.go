@@ -0,0 +1,101 @@
+package statusreaper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// tracker remembers when each status ID was first seen without a live pod,
+// so that a pod missing from a single list isn't mistaken for a deleted one
+type tracker struct {
+	grace        time.Duration
+	missingSince map[string]time.Time
+}
+
+func newTracker(grace time.Duration) *tracker {
+	return &tracker{grace: grace, missingSince: make(map[string]time.Time)}
+}
+
+// stale returns the status IDs that have had no live pod for at least the
+// grace period
+func (t *tracker) stale(ids map[string]bool, live map[string]bool, now time.Time) map[string]bool {
+	stale := make(map[string]bool)
+	for id := range t.missingSince {
+		if !ids[id] || live[id] {
+			delete(t.missingSince, id)
+		}
+	}
+	for id := range ids {
+		if live[id] {
+			continue
+		}
+		since, ok := t.missingSince[id]
+		if !ok {
+			t.missingSince[id] = now
+			since = now
+		}
+		if now.Sub(since) >= t.grace {
+			stale[id] = true
+		}
+	}
+	return stale
+}
+
+// pruneTemplateStatus removes the stale byPod statuses from the template and
+// returns whether any were removed
+func pruneTemplateStatus(t *v1beta1.ConstraintTemplate, stale map[string]bool) bool {
+	var kept []*v1beta1.ByPodStatus
+	for _, s := range t.Status.ByPod {
+		if !stale[s.ID] {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == len(t.Status.ByPod) {
+		return false
+	}
+	t.Status.ByPod = kept
+	return true
+}
+
+func constraintStatusIDs(obj *unstructured.Unstructured) ([]string, error) {
+	statuses, _, err := unstructured.NestedSlice(obj.Object, "status", "byPod")
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for i, s := range statuses {
+		status, ok := s.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("element %d in byPod status is malformed", i)
+		}
+		id, ok := status["id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("element %d in byPod status has no string `id` field", i)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// pruneConstraintStatus removes the stale byPod statuses from the constraint
+// and returns whether any were removed
+func pruneConstraintStatus(obj *unstructured.Unstructured, stale map[string]bool) (bool, error) {
+	ids, err := constraintStatusIDs(obj)
+	if err != nil {
+		return false, err
+	}
+	statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", "byPod")
+	kept := make([]interface{}, 0, len(statuses))
+	for i, s := range statuses {
+		if !stale[ids[i]] {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == len(statuses) {
+		return false, nil
+	}
+	return true, unstructured.SetNestedSlice(obj.Object, kept, "status", "byPod")
+}
@@ -0,0 +1,78 @@
+package statusreaper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTracker(t *testing.T) {
+	tr := newTracker(time.Minute)
+	start := time.Now()
+	ids := map[string]bool{"live": true, "gone": true}
+	live := map[string]bool{"live": true}
+
+	if got := tr.stale(ids, live, start); len(got) != 0 {
+		t.Errorf("stale() = %v before the grace period, want none", got)
+	}
+	if got := tr.stale(ids, live, start.Add(time.Minute)); !reflect.DeepEqual(got, map[string]bool{"gone": true}) {
+		t.Errorf("stale() = %v after the grace period, want gone", got)
+	}
+
+	// a pod that shows up again starts over
+	live["gone"] = true
+	tr.stale(ids, live, start.Add(2*time.Minute))
+	delete(live, "gone")
+	if got := tr.stale(ids, live, start.Add(2*time.Minute)); len(got) != 0 {
+		t.Errorf("stale() = %v for a pod that came back, want none", got)
+	}
+
+	// IDs that no longer have statuses are forgotten
+	tr.stale(map[string]bool{"live": true}, live, start.Add(3*time.Minute))
+	if len(tr.missingSince) != 0 {
+		t.Errorf("missingSince = %v, want it empty", tr.missingSince)
+	}
+}
+
+func TestPruneTemplateStatus(t *testing.T) {
+	tmpl := &v1beta1.ConstraintTemplate{}
+	tmpl.Status.ByPod = []*v1beta1.ByPodStatus{{ID: "a"}, {ID: "b"}}
+	if pruneTemplateStatus(tmpl, map[string]bool{"c": true}) {
+		t.Error("expected nothing to be pruned")
+	}
+	if !pruneTemplateStatus(tmpl, map[string]bool{"a": true}) {
+		t.Error("expected a to be pruned")
+	}
+	if len(tmpl.Status.ByPod) != 1 || tmpl.Status.ByPod[0].ID != "b" {
+		t.Errorf("byPod = %v, want only b", tmpl.Status.ByPod)
+	}
+}
+
+func TestPruneConstraintStatus(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if err := unstructured.SetNestedSlice(obj.Object, []interface{}{
+		map[string]interface{}{"id": "a", "enforced": true},
+		map[string]interface{}{"id": "b", "enforced": true},
+	}, "status", "byPod"); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := pruneConstraintStatus(obj, map[string]bool{"b": true})
+	if err != nil || !pruned {
+		t.Fatalf("pruneConstraintStatus() = %v, %v; want true, nil", pruned, err)
+	}
+	ids, err := constraintStatusIDs(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []string{"a"}) {
+		t.Errorf("ids = %v, want [a]", ids)
+	}
+
+	bad := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"byPod": []interface{}{"x"}}}}
+	if _, err := pruneConstraintStatus(bad, map[string]bool{"x": true}); err == nil {
+		t.Error("expected an error for a malformed status")
+	}
+}
@@ -0,0 +1,150 @@
+package syncdata
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// Annotation lists, in YAML or JSON, the kinds a template reads from
+// data.inventory, e.g. `[{"group": "", "version": "v1", "kind": "Namespace"}]`
+const Annotation = "metadata.gatekeeper.sh/requires-sync-data"
+
+type gvk struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// Parse returns the kinds listed in a required sync data annotation
+func Parse(src string) ([]schema.GroupVersionKind, error) {
+	if src == "" {
+		return nil, nil
+	}
+	var entries []gvk
+	if err := yaml.Unmarshal([]byte(src), &entries); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", Annotation, err)
+	}
+	var gvks []schema.GroupVersionKind
+	for i, e := range entries {
+		if e.Version == "" || e.Kind == "" {
+			return nil, fmt.Errorf("invalid %s annotation: entry %d must set a version and kind", Annotation, i)
+		}
+		gvks = append(gvks, schema.GroupVersionKind{Group: e.Group, Version: e.Version, Kind: e.Kind})
+	}
+	return gvks, nil
+}
+
+// Registry tracks the kinds each template requires and the kinds that are
+// synced
+type Registry struct {
+	mux      sync.RWMutex
+	required map[string][]schema.GroupVersionKind
+	synced   map[schema.GroupVersionKind]bool
+	events   chan event.GenericEvent
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		required: make(map[string][]schema.GroupVersionKind),
+		synced:   make(map[schema.GroupVersionKind]bool),
+		events:   make(chan event.GenericEvent, 1024),
+	}
+}
+
+// Templates is the registry shared by the template controller, which sets
+// the required kinds, and the config controller, which sets the synced kinds.
+var Templates = NewRegistry()
+
+// Set records the kinds the template requires, replacing any previous ones
+func (r *Registry) Set(template string, gvks []schema.GroupVersionKind) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if len(gvks) == 0 {
+		delete(r.required, template)
+		return
+	}
+	r.required[template] = gvks
+}
+
+// Remove drops the kinds required by the template
+func (r *Registry) Remove(template string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.required, template)
+}
+
+// Events receives an event for every template whose missing kinds change
+// when the synced kinds are replaced, so the template controller can update
+// its status without polling
+func (r *Registry) Events() <-chan event.GenericEvent {
+	return r.events
+}
+
+// SetSynced replaces the synced kinds and sends an event for every template
+// whose missing kinds change
+func (r *Registry) SetSynced(gvks []schema.GroupVersionKind) {
+	// events are sent without holding the lock, as the template controller
+	// reads the registry while handling them
+	for _, template := range r.setSynced(gvks) {
+		r.events <- event.GenericEvent{
+			Meta:   &metav1.ObjectMeta{Name: template},
+			Object: &v1beta1.ConstraintTemplate{},
+		}
+	}
+}
+
+// setSynced replaces the synced kinds and returns the templates whose missing
+// kinds changed
+func (r *Registry) setSynced(gvks []schema.GroupVersionKind) []string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	synced := make(map[schema.GroupVersionKind]bool, len(gvks))
+	for _, gvk := range gvks {
+		synced[gvk] = true
+	}
+	var changed []string
+	for template, required := range r.required {
+		for _, gvk := range required {
+			if r.synced[gvk] != synced[gvk] {
+				changed = append(changed, template)
+				break
+			}
+		}
+	}
+	r.synced = synced
+	sort.Strings(changed)
+	return changed
+}
+
+// Required returns the kinds each template requires, by template name
+func (r *Registry) Required() map[string][]schema.GroupVersionKind {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	required := make(map[string][]schema.GroupVersionKind, len(r.required))
+	for template, gvks := range r.required {
+		required[template] = gvks
+	}
+	return required
+}
+
+// Missing returns the kinds the template requires that are not synced
+func (r *Registry) Missing(template string) []schema.GroupVersionKind {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	var missing []schema.GroupVersionKind
+	for _, gvk := range r.required[template] {
+		if !r.synced[gvk] {
+			missing = append(missing, gvk)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].String() < missing[j].String() })
+	return missing
+}
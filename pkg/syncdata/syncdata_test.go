@@ -0,0 +1,96 @@
+package syncdata
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	namespaces  = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	deployments = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+)
+
+func TestParse(t *testing.T) {
+	tc := []struct {
+		Name      string
+		Src       string
+		Expected  []schema.GroupVersionKind
+		ExpectErr bool
+	}{
+		{Name: "Empty"},
+		{Name: "JSON", Src: `[{"group": "", "version": "v1", "kind": "Namespace"}]`, Expected: []schema.GroupVersionKind{namespaces}},
+		{Name: "YAML", Src: "- group: apps\n  version: v1\n  kind: Deployment\n", Expected: []schema.GroupVersionKind{deployments}},
+		{Name: "Missing kind", Src: `[{"version": "v1"}]`, ExpectErr: true},
+		{Name: "Not a list", Src: `{"kind": "Namespace"}`, ExpectErr: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			gvks, err := Parse(tt.Src)
+			if (err != nil) != tt.ExpectErr {
+				t.Fatalf("err = %v, want error: %v", err, tt.ExpectErr)
+			}
+			if !reflect.DeepEqual(gvks, tt.Expected) {
+				t.Errorf("Parse() = %v, want %v", gvks, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	r := NewRegistry()
+	r.Set("uniqueingresshost", []schema.GroupVersionKind{namespaces, deployments})
+	if got := r.Missing("uniqueingresshost"); !reflect.DeepEqual(got, []schema.GroupVersionKind{namespaces, deployments}) {
+		t.Errorf("with nothing synced, Missing() = %v", got)
+	}
+	r.SetSynced([]schema.GroupVersionKind{namespaces})
+	if got := r.Missing("uniqueingresshost"); !reflect.DeepEqual(got, []schema.GroupVersionKind{deployments}) {
+		t.Errorf("with namespaces synced, Missing() = %v", got)
+	}
+	r.Remove("uniqueingresshost")
+	if got := r.Missing("uniqueingresshost"); len(got) != 0 {
+		t.Errorf("after removal, Missing() = %v", got)
+	}
+}
+
+func TestSetSyncedEvents(t *testing.T) {
+	r := NewRegistry()
+	r.Set("uniqueingresshost", []schema.GroupVersionKind{namespaces, deployments})
+	r.Set("uniqueserviceselector", []schema.GroupVersionKind{deployments})
+	r.Set("nsonly", []schema.GroupVersionKind{namespaces})
+
+	tcs := []struct {
+		name   string
+		synced []schema.GroupVersionKind
+		want   []string
+	}{
+		{
+			name:   "namespaces synced",
+			synced: []schema.GroupVersionKind{namespaces},
+			want:   []string{"nsonly", "uniqueingresshost"},
+		},
+		{
+			name:   "unchanged",
+			synced: []schema.GroupVersionKind{namespaces},
+		},
+		{
+			name:   "namespaces replaced by deployments",
+			synced: []schema.GroupVersionKind{deployments},
+			want:   []string{"nsonly", "uniqueingresshost", "uniqueserviceselector"},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r.SetSynced(tc.synced)
+			var got []string
+			for len(r.Events()) > 0 {
+				e := <-r.Events()
+				got = append(got, e.Meta.GetName())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SetSynced() sent events for %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -50,3 +50,82 @@ test_with_sideloaded_ns {
   count(res) == 0
 }
 
+
+test_stale_inventory {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].a.b as {"metadata": {"annotations": {"inventory.gatekeeper.sh/max-staleness": "30s"}}}
+    with input.review as {"kind": {"kind": "Pod"}, "namespace": "testns", "_unstable": {"inventoryStaleness": 60000000000}}
+
+  count(res) == 1
+  res[r]
+  r.details.staleInventory
+}
+
+test_fresh_inventory {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].a.b as {"metadata": {"annotations": {"inventory.gatekeeper.sh/max-staleness": "30s"}}}
+    with input.review as {"kind": {"kind": "Pod"}, "namespace": "testns", "_unstable": {"inventoryStaleness": 10000000000}}
+
+  count(res) == 0
+}
+
+test_current_inventory {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].a.b as {"metadata": {"annotations": {"inventory.gatekeeper.sh/max-staleness": "30s"}}}
+    with input.review as {"kind": {"kind": "Pod"}, "namespace": "testns"}
+
+  count(res) == 0
+}
+
+test_stale_inventory_no_max_staleness {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].a.b as {"metadata": {}}
+    with input.review as {"kind": {"kind": "Pod"}, "namespace": "testns", "_unstable": {"inventoryStaleness": 60000000000}}
+
+  count(res) == 0
+}
+
+test_invalid_parameters {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].a.b as {"metadata": {"annotations": {"constraint.gatekeeper.sh/invalid-parameters": "labels must be of type array"}}}
+    with input.review as {"kind": {"kind": "Pod"}, "namespace": "testns"}
+
+  count(res) == 1
+  res[r]
+  r.details.invalidParameters
+}
+
+test_invalid_parameters_no_match {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].a.b as {"metadata": {"annotations": {"constraint.gatekeeper.sh/invalid-parameters": "labels must be of type array"}}, "spec": {"match": {"kinds": [{"apiGroups": [""], "kinds": ["Namespace"]}]}}}
+    with input.review as {"kind": {"kind": "Pod"}, "namespace": "testns"}
+
+  count(res) == 0
+}
+
+test_unsynced_inventory {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].K8sRequiredPodDisruptionBudget.b as {"kind": "K8sRequiredPodDisruptionBudget", "metadata": {}}
+    with input.review as {"kind": {"kind": "Deployment"}, "namespace": "testns", "_unstable": {"unsyncedData": {"k8srequiredpoddisruptionbudget": ["policy/v1beta1/PodDisruptionBudget"]}}}
+
+  count(res) == 1
+  res[r]
+  r.details.unsyncedInventory
+  r.details.unsyncedKinds == ["policy/v1beta1/PodDisruptionBudget"]
+}
+
+test_unsynced_inventory_other_template {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].K8sRequiredLabels.b as {"kind": "K8sRequiredLabels", "metadata": {}}
+    with input.review as {"kind": {"kind": "Deployment"}, "namespace": "testns", "_unstable": {"unsyncedData": {"k8srequiredpoddisruptionbudget": ["policy/v1beta1/PodDisruptionBudget"]}}}
+
+  count(res) == 0
+}
+
+test_unsynced_inventory_no_match {
+  res := autoreject_review
+    with data["{{.ConstraintsRoot}}"].K8sRequiredPodDisruptionBudget.b as {"kind": "K8sRequiredPodDisruptionBudget", "metadata": {}, "spec": {"match": {"kinds": [{"apiGroups": ["apps"], "kinds": ["StatefulSet"]}]}}}
+    with input.review as {"kind": {"group": "apps", "kind": "Deployment"}, "namespace": "testns", "_unstable": {"unsyncedData": {"k8srequiredpoddisruptionbudget": ["policy/v1beta1/PodDisruptionBudget"]}}}
+
+  count(res) == 0
+}
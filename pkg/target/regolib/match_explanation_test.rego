@@ -0,0 +1,23 @@
+package target
+
+test_match_explanation_matched {
+  res := match_explanations
+    with data["{{.ConstraintsRoot}}"].K.c as {"spec": {"match": {"kinds": [{"apiGroups": [""], "kinds": ["Pod"]}]}}}
+    with input.review as {"kind": {"group": "", "kind": "Pod"}, "namespace": "testns", "object": {"metadata": {}}}
+
+  count(res) == 1
+  res[r]
+  r.metadata.matched
+}
+
+test_match_explanation_failed_criteria {
+  res := match_explanations
+    with data["{{.ConstraintsRoot}}"].K.c as {"spec": {"match": {"kinds": [{"apiGroups": [""], "kinds": ["Namespace"]}], "excludedNamespaces": ["testns"]}}}
+    with input.review as {"kind": {"group": "", "kind": "Pod"}, "namespace": "testns", "object": {"metadata": {}}}
+
+  res[r]
+  not r.metadata.matched
+  r.metadata.criteria.kinds == false
+  r.metadata.criteria.excludedNamespaces == false
+  r.metadata.criteria.namespaces == true
+}
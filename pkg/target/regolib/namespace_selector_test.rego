@@ -102,3 +102,45 @@ test_direct_no_negative_match_oldobject {
   	with input.review.kind as ns_kind
   	with input.review.oldObject as ns_no_match_obj
 }
+
+test_terminating_namespace_no_match {
+  not matches_namespace_phase({})
+    with input.review.kind as pod_kind
+    with input.review.namespace as "my_namespace"
+    with input.review._unstable.namespace as terminating_ns
+}
+
+test_terminating_namespace_opt_in {
+  matches_namespace_phase({"includeTerminatingNamespaces": true})
+    with input.review.kind as pod_kind
+    with input.review.namespace as "my_namespace"
+    with input.review._unstable.namespace as terminating_ns
+}
+
+test_terminating_namespace_from_cache {
+  not matches_namespace_phase({})
+    with data["{{.DataRoot}}"].cluster["v1"]["Namespace"]["my_namespace"] as terminating_ns
+    with input.review.kind as pod_kind
+    with input.review.namespace as "my_namespace"
+}
+
+test_active_namespace_match {
+  matches_namespace_phase({})
+    with input.review.kind as pod_kind
+    with input.review.namespace as "my_namespace"
+    with input.review._unstable.namespace as {"status": {"phase": "Active"}}
+}
+
+test_uncached_namespace_match {
+  matches_namespace_phase({})
+    with input.review.kind as pod_kind
+    with input.review.namespace as "my_namespace"
+}
+
+test_terminating_namespace_object_match {
+  matches_namespace_phase({})
+    with input.review.kind as ns_kind
+    with input.review.object as terminating_ns
+}
+
+terminating_ns = {"metadata": {"name": "my_namespace"}, "status": {"phase": "Terminating"}}
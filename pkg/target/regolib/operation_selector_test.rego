@@ -0,0 +1,37 @@
+package target
+
+test_no_operations_matches_crud {
+  matches_operations({}) with input.review.operation as "DELETE"
+}
+
+test_no_operations_skips_connect {
+  not matches_operations({}) with input.review.operation as "CONNECT"
+}
+
+test_no_operations_matches_audit {
+  matches_operations({}) with input.review as {}
+}
+
+test_operations_match {
+  matches_operations({"operations": ["CONNECT"]}) with input.review.operation as "CONNECT"
+}
+
+test_operations_no_match {
+  not matches_operations({"operations": ["CREATE", "UPDATE"]}) with input.review.operation as "CONNECT"
+}
+
+test_operations_wildcard {
+  matches_operations({"operations": ["*"]}) with input.review.operation as "CONNECT"
+}
+
+test_operations_audit {
+  matches_operations({"operations": ["UPDATE"]}) with input.review as {}
+}
+
+test_connect_operations_audit {
+  not matches_operations({"operations": ["CONNECT", "DELETE"]}) with input.review as {}
+}
+
+test_operations_discovery_audit {
+  matches_operations({"operations": ["CREATE"]}) with input.review.operation as ""
+}
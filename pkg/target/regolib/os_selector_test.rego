@@ -0,0 +1,44 @@
+package target
+
+test_no_os_matches {
+  matches_os({}) with input.review as {"kind": {"kind": "ConfigMap"}, "object": {"data": {}}}
+}
+
+test_os_default_linux {
+  matches_os({"os": ["linux"]}) with input.review as os_pod_review({})
+}
+
+test_os_windows_no_match {
+  not matches_os({"os": ["windows"]}) with input.review as os_pod_review({})
+}
+
+test_os_name {
+  matches_os({"os": ["windows"]}) with input.review as os_pod_review({"os": {"name": "windows"}})
+}
+
+test_os_node_selector {
+  matches_os({"os": ["windows"]}) with input.review as os_pod_review({"nodeSelector": {"kubernetes.io/os": "windows"}})
+}
+
+test_os_toleration {
+  matches_os({"os": ["windows"]}) with input.review as os_pod_review({"tolerations": [{"key": "os", "value": "windows", "effect": "NoSchedule"}]})
+}
+
+test_os_affinity {
+  matches_os({"os": ["windows"]}) with input.review as os_pod_review({"affinity": {"nodeAffinity": {"requiredDuringSchedulingIgnoredDuringExecution": {"nodeSelectorTerms": [
+    {"matchExpressions": [{"key": "kubernetes.io/os", "operator": "In", "values": ["windows"]}]}
+  ]}}}})
+}
+
+test_os_template {
+  matches_os({"os": ["windows"]}) with input.review as {
+    "kind": {"kind": "Deployment"},
+    "object": {"spec": {"template": {"spec": {"os": {"name": "windows"}}}}}
+  }
+}
+
+test_os_no_pod_spec {
+  not matches_os({"os": ["linux", "windows"]}) with input.review as {"kind": {"kind": "ConfigMap"}, "object": {"data": {}}}
+}
+
+os_pod_review(spec) = {"kind": {"kind": "Pod"}, "object": {"spec": spec}}
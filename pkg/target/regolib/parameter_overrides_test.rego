@@ -0,0 +1,83 @@
+package target
+
+override_constraint = {
+  "kind": "K8sAllowedRepos",
+  "metadata": {"name": "repos"},
+  "spec": {
+    "parameters": {"repos": ["gcr.io/base"], "strict": true},
+    "parameterOverrides": [
+      {"namespaces": ["team-a"], "parameters": {"repos": ["gcr.io/team-a"]}},
+      {"namespaceSelector": {"matchLabels": {"tier": "sandbox"}}, "parameters": {"strict": false}},
+      {"namespaces": ["team-a", "team-b"], "parameters": {"repos": ["gcr.io/team-b"]}},
+      {"parameters": {"repos": ["gcr.io/everywhere"]}}
+    ]
+  }
+}
+
+test_no_overrides {
+  c := {"kind": "Foo", "spec": {"parameters": {"a": 1}}}
+  effective_constraint(c) == c
+    with input.review.kind as pod_kind
+    with input.review.namespace as "team-a"
+}
+
+test_override_no_match {
+  effective_constraint(override_constraint) == override_constraint
+    with input.review.kind as pod_kind
+    with input.review.namespace as "other"
+    with input.review._unstable.namespace as {"metadata": {"labels": {}}}
+}
+
+test_override_by_name {
+  c := effective_constraint(override_constraint)
+    with input.review.kind as pod_kind
+    with input.review.namespace as "team-b"
+    with input.review._unstable.namespace as {"metadata": {"labels": {}}}
+  c.spec.parameters == {"repos": ["gcr.io/team-b"], "strict": true}
+  c.metadata == override_constraint.metadata
+  c.spec.parameterOverrides == override_constraint.spec.parameterOverrides
+}
+
+test_override_first_match_wins {
+  c := effective_constraint(override_constraint)
+    with input.review.kind as pod_kind
+    with input.review.namespace as "team-a"
+    with input.review._unstable.namespace as {"metadata": {"labels": {"tier": "sandbox"}}}
+  c.spec.parameters == {"repos": ["gcr.io/team-a"], "strict": true}
+}
+
+test_override_by_selector_keeps_false {
+  c := effective_constraint(override_constraint)
+    with input.review.kind as pod_kind
+    with input.review.namespace as "play"
+    with input.review._unstable.namespace as {"metadata": {"labels": {"tier": "sandbox"}}}
+  c.spec.parameters == {"repos": ["gcr.io/base"], "strict": false}
+}
+
+test_override_namespace_object {
+  c := effective_constraint(override_constraint)
+    with input.review.kind as ns_kind
+    with input.review.object.metadata.name as "team-a"
+  c.spec.parameters.repos == ["gcr.io/team-a"]
+}
+
+test_override_without_base_parameters {
+  c := effective_constraint({"spec": {"parameterOverrides": [{"namespaces": ["a"], "parameters": {"x": 1}}]}})
+    with input.review.kind as pod_kind
+    with input.review.namespace as "a"
+  c.spec.parameters == {"x": 1}
+}
+
+test_matching_constraints_returns_effective {
+  matching_constraints[c]
+    with input.review.kind as pod_kind
+    with input.review.namespace as "team-a"
+    with input.review.object as {"metadata": {"name": "p"}}
+    with input.review._unstable.namespace as {"metadata": {"labels": {}}}
+    with data["{{.ConstraintsRoot}}"]["K8sAllowedRepos"]["repos"] as override_constraint
+  c.spec.parameters.repos == ["gcr.io/team-a"]
+}
+
+test_merge_objects {
+  merge_objects({"a": 1, "b": 2}, {"b": 3, "c": false}) == {"a": 1, "b": 3, "c": false}
+}
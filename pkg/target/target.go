@@ -6,9 +6,12 @@ import (
 	"net/url"
 	"path"
 	"text/template"
+	"time"
 
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/objdiff"
+	"github.com/open-policy-agent/gatekeeper/pkg/paramschema"
 	"github.com/pkg/errors"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -34,20 +37,51 @@ func (h *K8sValidationTarget) Library() *template.Template {
 	return libTempl
 }
 
-type WipeData struct{}
+type WipeData struct {
+	// Root limits the wipe to one top-level key of the inventory, e.g.
+	// "cluster". Everything is wiped if it is empty.
+	Root string
+}
+
+func processWipeData(w *WipeData) (bool, string, interface{}, error) {
+	return true, w.Root, nil, nil
+}
 
-func processWipeData() (bool, string, interface{}, error) {
-	return true, "", nil, nil
+// ReferenceData is the parsed contents of a reference data ConfigMap, stored
+// at data.inventory.reference[<name>]
+type ReferenceData struct {
+	Name string
+	Data map[string]interface{}
+}
+
+func processReferenceData(r *ReferenceData) (bool, string, interface{}, error) {
+	if r.Name == "" {
+		return true, "", nil, fmt.Errorf("reference data has no name")
+	}
+	return true, path.Join("reference", r.Name), r.Data, nil
 }
 
 type AugmentedReview struct {
 	AdmissionRequest *admissionv1beta1.AdmissionRequest
 	Namespace        *corev1.Namespace
+	// InventoryStaleness is how long the synced data has been incomplete
+	InventoryStaleness time.Duration
+	// UnsyncedData lists, by template name, the kinds the template requires
+	// that are not synced yet
+	UnsyncedData map[string][]string
+	// GenerateName is the generateName of a created object that has no
+	// name yet
+	GenerateName string
+	// Diff holds the changes made by an UPDATE request, if they were
+	// computed
+	Diff []objdiff.Change
 }
 
 type gkReview struct {
 	*admissionv1beta1.AdmissionRequest
-	Unstable *unstable `json:"_unstable,omitempty"`
+	GenerateName string            `json:"generateName,omitempty"`
+	Diff         *[]objdiff.Change `json:"diff,omitempty"`
+	Unstable     *unstable         `json:"_unstable,omitempty"`
 }
 
 type AugmentedUnstructured struct {
@@ -56,7 +90,9 @@ type AugmentedUnstructured struct {
 }
 
 type unstable struct {
-	Namespace *corev1.Namespace `json:"namespace,omitempty"`
+	Namespace          *corev1.Namespace   `json:"namespace,omitempty"`
+	InventoryStaleness int64               `json:"inventoryStaleness,omitempty"`
+	UnsyncedData       map[string][]string `json:"unsyncedData,omitempty"`
 }
 
 func processUnstructured(o *unstructured.Unstructured) (bool, string, interface{}, error) {
@@ -81,13 +117,29 @@ func (h *K8sValidationTarget) ProcessData(obj interface{}) (bool, string, interf
 		return processUnstructured(&data)
 	case *unstructured.Unstructured:
 		return processUnstructured(data)
-	case WipeData, *WipeData:
-		return processWipeData()
+	case WipeData:
+		return processWipeData(&data)
+	case *WipeData:
+		return processWipeData(data)
+	case ReferenceData:
+		return processReferenceData(&data)
+	case *ReferenceData:
+		return processReferenceData(data)
 	default:
 		return false, "", nil, nil
 	}
 }
 
+func augmentedReviewToGkReview(data *AugmentedReview) *gkReview {
+	review := &gkReview{AdmissionRequest: data.AdmissionRequest, GenerateName: data.GenerateName, Unstable: &unstable{Namespace: data.Namespace, InventoryStaleness: int64(data.InventoryStaleness), UnsyncedData: data.UnsyncedData}}
+	// an empty diff is kept, it means the object didn't change
+	if data.Diff != nil {
+		diff := data.Diff
+		review.Diff = &diff
+	}
+	return review
+}
+
 func (h *K8sValidationTarget) HandleReview(obj interface{}) (bool, interface{}, error) {
 	switch data := obj.(type) {
 	case admissionv1beta1.AdmissionRequest:
@@ -95,9 +147,9 @@ func (h *K8sValidationTarget) HandleReview(obj interface{}) (bool, interface{},
 	case *admissionv1beta1.AdmissionRequest:
 		return true, data, nil
 	case AugmentedReview:
-		return true, &gkReview{AdmissionRequest: data.AdmissionRequest, Unstable: &unstable{Namespace: data.Namespace}}, nil
+		return true, augmentedReviewToGkReview(&data), nil
 	case *AugmentedReview:
-		return true, &gkReview{AdmissionRequest: data.AdmissionRequest, Unstable: &unstable{Namespace: data.Namespace}}, nil
+		return true, augmentedReviewToGkReview(data), nil
 	case AugmentedUnstructured:
 		admissionRequest, err := augmentedUnstructuredToAdmissionRequest(data)
 		if err != nil {
@@ -141,7 +193,13 @@ func augmentedUnstructuredToAdmissionRequest(obj AugmentedUnstructured) (gkRevie
 	return review, nil
 }
 
+// unstructuredToAdmissionRequest reviews any object, including custom
+// resources that aren't in any scheme, by its apiVersion and kind alone
 func unstructuredToAdmissionRequest(obj unstructured.Unstructured) (admissionv1beta1.AdmissionRequest, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Kind == "" || gvk.Version == "" {
+		return admissionv1beta1.AdmissionRequest{}, fmt.Errorf("cannot review object %q: apiVersion and kind are required", obj.GetName())
+	}
 	resourceJSON, err := json.Marshal(obj.Object)
 	if err != nil {
 		return admissionv1beta1.AdmissionRequest{}, errors.New("Unable to marshal JSON encoding of object")
@@ -149,9 +207,9 @@ func unstructuredToAdmissionRequest(obj unstructured.Unstructured) (admissionv1b
 
 	req := admissionv1beta1.AdmissionRequest{
 		Kind: metav1.GroupVersionKind{
-			Group:   obj.GetObjectKind().GroupVersionKind().Group,
-			Version: obj.GetObjectKind().GroupVersionKind().Version,
-			Kind:    obj.GetObjectKind().GroupVersionKind().Kind,
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			Kind:    gvk.Kind,
 		},
 		Object: runtime.RawExtension{
 			Raw: resourceJSON,
@@ -303,8 +361,36 @@ func (h *K8sValidationTarget) MatchSchema() apiextensions.JSONSchemaProps {
 				Type: "array",
 				Items: &apiextensions.JSONSchemaPropsOrArray{
 					Schema: &apiextensions.JSONSchemaProps{Type: "string"}}},
-			"labelSelector":     labelSelectorSchema,
-			"namespaceSelector": labelSelectorSchema,
+			"labelSelector":                labelSelectorSchema,
+			"namespaceSelector":            labelSelectorSchema,
+			"includeTerminatingNamespaces": apiextensions.JSONSchemaProps{Type: "boolean"},
+			"operations": apiextensions.JSONSchemaProps{
+				Type: "array",
+				Items: &apiextensions.JSONSchemaPropsOrArray{
+					Schema: &apiextensions.JSONSchemaProps{
+						Type: "string",
+						Enum: []apiextensions.JSON{
+							"CREATE",
+							"UPDATE",
+							"DELETE",
+							"CONNECT",
+							"*",
+						},
+					},
+				},
+			},
+			"os": apiextensions.JSONSchemaProps{
+				Type: "array",
+				Items: &apiextensions.JSONSchemaPropsOrArray{
+					Schema: &apiextensions.JSONSchemaProps{
+						Type: "string",
+						Enum: []apiextensions.JSON{
+							"linux",
+							"windows",
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -342,9 +428,75 @@ func (h *K8sValidationTarget) ValidateConstraint(u *unstructured.Unstructured) e
 		}
 	}
 
+	return validateParameterOverrides(u)
+}
+
+// validateParameterOverrides checks that every entry of spec.parameterOverrides
+// selects namespaces and has valid selectors, and that its parameters merged
+// over the base parameters match the template's parameter schema
+func validateParameterOverrides(u *unstructured.Unstructured) error {
+	overrides, found, err := unstructured.NestedSlice(u.Object, "spec", "parameterOverrides")
+	if err != nil || !found {
+		return err
+	}
+	base, _, err := unstructured.NestedMap(u.Object, "spec", "parameters")
+	if err != nil {
+		return err
+	}
+	for i, o := range overrides {
+		path := field.NewPath("spec", "parameterOverrides").Index(i)
+		override, ok := o.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", path)
+		}
+		namespaces, hasNamespaces, err := unstructured.NestedStringSlice(override, "namespaces")
+		if err != nil {
+			return fmt.Errorf("%s: %v", path.Child("namespaces"), err)
+		}
+		selector, hasSelector, err := unstructured.NestedMap(override, "namespaceSelector")
+		if err != nil {
+			return fmt.Errorf("%s: %v", path.Child("namespaceSelector"), err)
+		}
+		if len(namespaces) == 0 && !hasSelector {
+			return fmt.Errorf("%s must set namespaces or namespaceSelector", path)
+		}
+		if hasNamespaces && len(namespaces) == 0 {
+			return fmt.Errorf("%s must not be empty", path.Child("namespaces"))
+		}
+		if hasSelector {
+			selectorObj, err := convertToLabelSelector(selector)
+			if err != nil {
+				return err
+			}
+			errorList := validation.ValidateLabelSelector(selectorObj, path.Child("namespaceSelector"))
+			if len(errorList) > 0 {
+				return errorList.ToAggregate()
+			}
+		}
+		parameters, _, err := unstructured.NestedMap(override, "parameters")
+		if err != nil {
+			return fmt.Errorf("%s: %v", path.Child("parameters"), err)
+		}
+		if err := paramschema.Templates.Validate(u.GetKind(), path.Child("parameters"), mergeParameters(base, parameters)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// mergeParameters returns a shallow merge of the override's parameters over
+// the base parameters, as the library merges them when evaluating a request
+func mergeParameters(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
 func convertToLabelSelector(object map[string]interface{}) (*metav1.LabelSelector, error) {
 	j, err := json.Marshal(object)
 	if err != nil {
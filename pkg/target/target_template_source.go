@@ -24,21 +24,94 @@ autoreject_review[rejection] {
   }
 }
 
-matching_constraints[constraint] {
-  constraint := {{.ConstraintsRoot}}[_][_]
-  spec := get_default(constraint, "spec", {})
+# Constraints can require the synced data they read to be complete, to within
+# a max staleness, when a request is reviewed.
+autoreject_review[rejection] {
+  constraint := matching_constraints[_]
+  max_staleness := constraint.metadata.annotations["inventory.gatekeeper.sh/max-staleness"]
+  staleness := input.review._unstable.inventoryStaleness
+  staleness > time.parse_duration_ns(max_staleness)
+  rejection := {
+    "msg": sprintf("Synced data has been incomplete for %vs, longer than the max staleness of %v.", [round(staleness / 1000000000), max_staleness]),
+    "details": {"staleInventory": true, "inventoryStaleness": staleness, "maxStaleness": max_staleness},
+    "constraint": constraint,
+  }
+}
+
+# Constraints whose template requires synced data, with the
+# metadata.gatekeeper.sh/requires-sync-data annotation, can't be evaluated
+# until that data is synced. Templates are named after their constraint kind.
+autoreject_review[rejection] {
+  constraint := matching_constraints[_]
+  kinds := input.review._unstable.unsyncedData[lower(constraint.kind)]
+  rejection := {
+    "msg": sprintf("Synced data required by the constraint is not synced yet: %v.", [concat(", ", kinds)]),
+    "details": {"unsyncedInventory": true, "unsyncedKinds": kinds},
+    "constraint": constraint,
+  }
+}
+
+# Constraints whose parameters don't match their template's schema are loaded
+# without their parameters to reject the requests they match, when the
+# controller's --invalid-constraint-policy is fail-closed.
+autoreject_review[rejection] {
+  constraint := matching_constraints[_]
+  reason := constraint.metadata.annotations["constraint.gatekeeper.sh/invalid-parameters"]
+  rejection := {
+    "msg": sprintf("Constraint parameters do not match the template schema: %v", [reason]),
+    "details": {"invalidParameters": true},
+    "constraint": constraint,
+  }
+}
+
+matching_constraints[effective] {
+  c := {{.ConstraintsRoot}}[_][_]
+  spec := get_default(c, "spec", {})
   match := get_default(spec, "match", {})
 
   any_kind_selector_matches(match)
 
+  matches_operations(match)
+
   matches_namespaces(match)
 
   does_not_match_excludednamespaces(match)
 
   matches_nsselector(match)
 
+  matches_namespace_phase(match)
+
+  matches_os(match)
+
   label_selector := get_default(match, "labelSelector", {})
   any_labelselector_match(label_selector)
+
+  effective := effective_constraint(c)
+}
+
+# match_explanations reports, for every constraint, which of its match
+# criteria the review passes. Only the debug server queries it, matching
+# itself doesn't compute explanations.
+match_explanations[explanation] {
+  c := {{.ConstraintsRoot}}[_][_]
+  spec := get_default(c, "spec", {})
+  match := get_default(spec, "match", {})
+  label_selector := get_default(match, "labelSelector", {})
+  criteria := {
+    "kinds": count({1 | any_kind_selector_matches(match)}) > 0,
+    "operations": count({1 | matches_operations(match)}) > 0,
+    "namespaces": count({1 | matches_namespaces(match)}) > 0,
+    "excludedNamespaces": count({1 | does_not_match_excludednamespaces(match)}) > 0,
+    "namespaceSelector": count({1 | matches_nsselector(match)}) > 0,
+    "namespacePhase": count({1 | matches_namespace_phase(match)}) > 0,
+    "os": count({1 | matches_os(match)}) > 0,
+    "labelSelector": count({1 | any_labelselector_match(label_selector)}) > 0,
+  }
+  failed := {k | criteria[k] == false}
+  explanation := {
+    "metadata": {"criteria": criteria, "matched": count(failed) == 0},
+    "constraint": c,
+  }
 }
 
 # Namespace-scoped objects
@@ -65,6 +138,60 @@ make_review(obj, api_version, kind, name) = review {
   }
 }
 
+############################
+# Parameter Override Logic #
+############################
+
+# effective_constraint returns the constraint with the parameters of the first
+# entry of spec.parameterOverrides matching the review merged over
+# spec.parameters. Keys set by the override replace the base value.
+effective_constraint(constraint) = out {
+  spec := get_default(constraint, "spec", {})
+  overrides := get_default(spec, "parameterOverrides", [])
+  matched := {i | overrides[i]; override_matches(overrides[i])}
+  count(matched) > 0
+  override := overrides[min(matched)]
+  params := merge_objects(get_default(spec, "parameters", {}), get_default(override, "parameters", {}))
+  out := merge_objects(constraint, {"spec": merge_objects(spec, {"parameters": params})})
+}
+
+effective_constraint(constraint) = constraint {
+  spec := get_default(constraint, "spec", {})
+  overrides := get_default(spec, "parameterOverrides", [])
+  matched := {i | overrides[i]; override_matches(overrides[i])}
+  count(matched) == 0
+}
+
+# an override must select namespaces, otherwise it would replace the base
+# parameters everywhere
+override_matches(override) {
+  has_field(override, "namespaces")
+  matches_namespaces(override)
+  matches_nsselector(override)
+}
+
+override_matches(override) {
+  has_field(override, "namespaceSelector")
+  matches_namespaces(override)
+  matches_nsselector(override)
+}
+
+# merge_objects returns a shallow merge of a and b, preferring the values of b
+merge_objects(a, b) = merged {
+  keys := {k | _ = a[k]} | {k | _ = b[k]}
+  merged := {k: v | keys[k]; v := pick_value(a, b, k)}
+}
+
+pick_value(a, b, k) = v {
+  has_field(b, k)
+  v := b[k]
+}
+
+pick_value(a, b, k) = v {
+  not has_field(b, k)
+  v := a[k]
+}
+
 ########
 # Util #
 ########
@@ -153,6 +280,114 @@ kind_matches(ks) {
   ks.kinds[_] == input.review.kind.kind
 }
 
+############################
+# Operation Selector Logic #
+############################
+
+# Constraints without operations match every operation except CONNECT, which
+# has to be opted into.
+matches_operations(match) {
+  not has_field(match, "operations")
+  not input.review.operation == "CONNECT"
+}
+
+matches_operations(match) {
+  match.operations[_] == "*"
+}
+
+matches_operations(match) {
+  match.operations[_] == input.review.operation
+}
+
+# Audit reviews have no operation. They are existing objects, so they match
+# constraints for creates or updates.
+matches_operations(match) {
+  audit_review
+  op := match.operations[_]
+  audited_operations[op]
+}
+
+audited_operations = {"CREATE", "UPDATE"}
+
+# objects audited via the discovery client are reviewed as admission requests
+# with an empty operation
+audit_review {
+  not input.review.operation
+}
+
+audit_review {
+  input.review.operation == ""
+}
+
+#####################
+# OS Selector Logic #
+#####################
+
+# Constraints with os only match objects with a pod spec whose OS, as
+# detected by review_os, is listed.
+matches_os(match) {
+  not has_field(match, "os")
+}
+
+matches_os(match) {
+  match.os[_] == review_os
+}
+
+review_os = os {
+  os := pod_spec_os(review_pod_spec)
+}
+
+review_pod_spec = spec {
+  input.review.kind.kind == "Pod"
+  spec := input.review.object.spec
+} else = spec {
+  spec := input.review.object.spec.jobTemplate.spec.template.spec
+} else = spec {
+  spec := input.review.object.spec.template.spec
+}
+
+os_labels = ["kubernetes.io/os", "beta.kubernetes.io/os"]
+
+windows_taint_keys = {"os", "kubernetes.io/os", "node.kubernetes.io/os"}
+
+# The first OS indication decides, pods without one run on linux. Keep in
+# sync with library/lib/os, and with the fields pkg/prune always keeps.
+pod_spec_os(spec) = os {
+  os := lower(spec.os.name)
+} else = os {
+  os := lower(spec.nodeSelector[os_labels[0]])
+} else = os {
+  os := lower(spec.nodeSelector[os_labels[1]])
+} else = os {
+  os := affinity_os(spec)
+} else = "windows" {
+  t := spec.tolerations[_]
+  windows_taint_keys[t.key]
+  lower(t.value) == "windows"
+} else = "linux" {
+  true
+}
+
+# Node selector terms are ORed, so only an OS every term agrees on counts.
+affinity_os(spec) = os {
+  terms := spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms
+  count(terms) > 0
+  restricting := [t | t := terms[_]; count(os_term_values(t)) > 0]
+  count(restricting) == count(terms)
+  values := {v | v := os_term_values(terms[_])[_]}
+  count(values) == 1
+  os := values[_]
+}
+
+os_term_values(term) = values {
+  values := {lower(v) |
+    e := term.matchExpressions[_]
+    e.key == os_labels[_]
+    e.operator == "In"
+    v := e.values[_]
+  }
+}
+
 ########################
 # Label Selector Logic #
 ########################
@@ -317,6 +552,26 @@ matches_nsselector(match) {
   matches_namespace_selector(match, ns)
 }
 
+# Objects in a namespace that is being deleted are only matched by constraints
+# that opt in, so cleaning up the namespace isn't denied and its leftovers
+# aren't audited. Cluster-scoped objects, and objects whose namespace isn't
+# known, are always matched.
+matches_namespace_phase(match) {
+  match.includeTerminatingNamespaces == true
+}
+
+matches_namespace_phase(match) {
+  not match.includeTerminatingNamespaces == true
+  not terminating_namespace
+}
+
+terminating_namespace {
+  not is_ns(input.review.kind)
+  input.review.namespace != ""
+  get_ns[ns]
+  ns.status.phase == "Terminating"
+}
+
 # if we are matching against a namespace, match against either the old or new object
 matches_nsselector(match) {
   is_ns(input.review.kind)
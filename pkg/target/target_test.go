@@ -9,7 +9,12 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/paramschema"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -246,6 +251,131 @@ func TestValidateConstraint(t *testing.T) {
 `,
 			ErrorExpected: false,
 		},
+		{
+			Name: "Valid ParameterOverrides",
+			Constraint: `
+{
+	"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+	"kind": "K8sAllowedRepos",
+	"metadata": {
+		"name": "repos"
+	},
+	"spec": {
+		"parameters": {
+			"repos": ["openpolicyagent"]
+		},
+		"parameterOverrides": [
+			{"namespaces": ["team-a"], "parameters": {"repos": ["team-a"]}},
+			{"namespaceSelector": {"matchLabels": {"tier": "sandbox"}}, "parameters": {"repos": ["sandbox"]}}
+		]
+	}
+}
+`,
+			ErrorExpected: false,
+		},
+		{
+			Name: "ParameterOverride Without Namespaces",
+			Constraint: `
+{
+	"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+	"kind": "K8sAllowedRepos",
+	"metadata": {
+		"name": "repos"
+	},
+	"spec": {
+		"parameters": {
+			"repos": ["openpolicyagent"]
+		},
+		"parameterOverrides": [
+			{"parameters": {"repos": ["everywhere"]}}
+		]
+	}
+}
+`,
+			ErrorExpected: true,
+		},
+		{
+			Name: "ParameterOverride With Empty Namespaces",
+			Constraint: `
+{
+	"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+	"kind": "K8sAllowedRepos",
+	"metadata": {
+		"name": "repos"
+	},
+	"spec": {
+		"parameters": {
+			"repos": ["openpolicyagent"]
+		},
+		"parameterOverrides": [
+			{"namespaces": [], "namespaceSelector": {"matchLabels": {"tier": "sandbox"}}, "parameters": {"repos": ["sandbox"]}}
+		]
+	}
+}
+`,
+			ErrorExpected: true,
+		},
+		{
+			Name: "ParameterOverride With Invalid NamespaceSelector",
+			Constraint: `
+{
+	"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+	"kind": "K8sAllowedRepos",
+	"metadata": {
+		"name": "repos"
+	},
+	"spec": {
+		"parameters": {
+			"repos": ["openpolicyagent"]
+		},
+		"parameterOverrides": [
+			{"namespaceSelector": {"matchExpressions": [{"key": "tier", "operator": "Blah", "values": ["x"]}]}, "parameters": {}}
+		]
+	}
+}
+`,
+			ErrorExpected: true,
+		},
+		{
+			Name: "ParameterOverride With Non-Object Parameters",
+			Constraint: `
+{
+	"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+	"kind": "K8sAllowedRepos",
+	"metadata": {
+		"name": "repos"
+	},
+	"spec": {
+		"parameters": {
+			"repos": ["openpolicyagent"]
+		},
+		"parameterOverrides": [
+			{"namespaces": ["team-a"], "parameters": ["team-a"]}
+		]
+	}
+}
+`,
+			ErrorExpected: true,
+		},
+		{
+			Name: "ParameterOverrides Not A List",
+			Constraint: `
+{
+	"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+	"kind": "K8sAllowedRepos",
+	"metadata": {
+		"name": "repos"
+	},
+	"spec": {
+		"parameters": {
+			"repos": ["openpolicyagent"]
+		},
+		"parameterOverrides": {"namespaces": ["team-a"]}
+	}
+}
+`,
+			ErrorExpected: true,
+		},
 	}
 	for _, tt := range tc {
 		t.Run(tt.Name, func(t *testing.T) {
@@ -266,6 +396,48 @@ func TestValidateConstraint(t *testing.T) {
 	}
 }
 
+func TestValidateConstraintOverrideSchema(t *testing.T) {
+	ct := &templates.ConstraintTemplate{}
+	ct.Spec.CRD.Spec.Validation = &templates.Validation{OpenAPIV3Schema: &apiextensions.JSONSchemaProps{
+		Properties: map[string]apiextensions.JSONSchemaProps{
+			"repos":  {Type: "array", Items: &apiextensions.JSONSchemaPropsOrArray{Schema: &apiextensions.JSONSchemaProps{Type: "string"}}},
+			"strict": {Type: "boolean"},
+		},
+	}}
+	if err := paramschema.Templates.Set("K8sAllowedRepos", ct); err != nil {
+		t.Fatal(err)
+	}
+	defer paramschema.Templates.Remove("K8sAllowedRepos")
+
+	tc := []struct {
+		Name          string
+		Override      string
+		ErrorExpected bool
+	}{
+		{Name: "matching override", Override: `{"repos": ["team-a"]}`},
+		{Name: "override keeping base parameters", Override: `{"strict": true}`},
+		{Name: "override of the wrong type", Override: `{"repos": "team-a"}`, ErrorExpected: true},
+		{Name: "nested value of the wrong type", Override: `{"repos": [1]}`, ErrorExpected: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			constraint := `{"apiVersion": "constraints.gatekeeper.sh/v1beta1", "kind": "K8sAllowedRepos", "metadata": {"name": "repos"},
+				"spec": {"parameters": {"repos": ["openpolicyagent"]}, "parameterOverrides": [{"namespaces": ["team-a"], "parameters": ` + tt.Override + `}]}}`
+			if err := json.Unmarshal([]byte(constraint), u); err != nil {
+				t.Fatalf("Unable to parse constraint JSON: %s", err)
+			}
+			err := (&K8sValidationTarget{}).ValidateConstraint(u)
+			if err != nil && !tt.ErrorExpected {
+				t.Errorf("err = %s; want nil", err)
+			}
+			if err == nil && tt.ErrorExpected {
+				t.Error("err = nil; want non-nil")
+			}
+		})
+	}
+}
+
 func TestHandleViolation(t *testing.T) {
 	tc := []struct {
 		Name          string
@@ -426,3 +598,96 @@ func TestProcessData(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessReferenceData(t *testing.T) {
+	h := &K8sValidationTarget{}
+	data := map[string]interface{}{"allowed": []interface{}{"a", "b"}}
+	handled, path, got, err := h.ProcessData(&ReferenceData{Name: "registries", Data: data})
+	if !handled || err != nil {
+		t.Fatalf("ProcessData() = %v, %v; want true, nil", handled, err)
+	}
+	if path != "reference/registries" {
+		t.Errorf("path = %s; want reference/registries", path)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Errorf(cmp.Diff(got, data))
+	}
+
+	if _, _, _, err := h.ProcessData(ReferenceData{}); err == nil {
+		t.Errorf("err = nil; want an error for reference data without a name")
+	}
+
+	if _, path, _, _ := h.ProcessData(WipeData{Root: "cluster"}); path != "cluster" {
+		t.Errorf("path = %s; want cluster", path)
+	}
+}
+
+func TestHandleReviewUnstructured(t *testing.T) {
+	tc := []struct {
+		Name          string
+		JSON          string
+		ErrorExpected bool
+		ExpectedKind  metav1.GroupVersionKind
+	}{
+		{
+			Name:         "Custom Resource",
+			JSON:         `{"apiVersion": "stable.example.com/v1", "kind": "CronTab", "metadata": {"name": "mytab"}, "spec": {"cronSpec": "* * * * */5"}}`,
+			ExpectedKind: metav1.GroupVersionKind{Group: "stable.example.com", Version: "v1", Kind: "CronTab"},
+		},
+		{
+			Name:         "Core Object",
+			JSON:         `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "mypod"}}`,
+			ExpectedKind: metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		},
+		{
+			Name:          "No Kind",
+			JSON:          `{"apiVersion": "stable.example.com/v1", "metadata": {"name": "mytab"}}`,
+			ErrorExpected: true,
+		},
+		{
+			Name:          "No Version",
+			JSON:          `{"kind": "CronTab", "metadata": {"name": "mytab"}}`,
+			ErrorExpected: true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			h := &K8sValidationTarget{}
+			o := &unstructured.Unstructured{}
+			if err := json.Unmarshal([]byte(tt.JSON), &o.Object); err != nil {
+				t.Fatalf("Error parsing JSON: %s", err)
+			}
+			for _, obj := range []interface{}{o, AugmentedUnstructured{Object: *o}} {
+				handled, review, err := h.HandleReview(obj)
+				if tt.ErrorExpected {
+					if err == nil {
+						t.Errorf("err = nil; want non-nil for %T", obj)
+					}
+					continue
+				}
+				if !handled || err != nil {
+					t.Fatalf("HandleReview(%T) = %v, %v; want true, nil", obj, handled, err)
+				}
+				var req admissionv1beta1.AdmissionRequest
+				switch r := review.(type) {
+				case admissionv1beta1.AdmissionRequest:
+					req = r
+				case gkReview:
+					req = *r.AdmissionRequest
+				default:
+					t.Fatalf("unexpected review type %T", review)
+				}
+				if req.Kind != tt.ExpectedKind {
+					t.Errorf("kind = %v; want %v", req.Kind, tt.ExpectedKind)
+				}
+				raw := map[string]interface{}{}
+				if err := json.Unmarshal(req.Object.Raw, &raw); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(raw, o.Object) {
+					t.Errorf(cmp.Diff(raw, o.Object))
+				}
+			}
+		})
+	}
+}
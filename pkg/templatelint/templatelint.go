@@ -0,0 +1,132 @@
+package templatelint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Code is the status warning code of a template's lint warnings
+const Code = "template_warning"
+
+// deprecatedBuiltins maps the deprecated built-ins to their replacements
+var deprecatedBuiltins = map[string]string{
+	ast.SetDiff.Name:        "the minus operator",
+	ast.NetCIDROverlap.Name: ast.NetCIDRContains.Name,
+	ast.CastArray.Name:      "a comprehension",
+	ast.CastSet.Name:        "a comprehension",
+	ast.CastString.Name:     ast.IsString.Name,
+	ast.CastBoolean.Name:    ast.IsBoolean.Name,
+	ast.CastNull.Name:       ast.IsNull.Name,
+	ast.CastObject.Name:     ast.IsObject.Name,
+}
+
+// Warnings returns advisory findings for the template's Rego. They never
+// block loading; modules that don't parse are reported by ingestion.
+func Warnings(ct *templates.ConstraintTemplate) []string {
+	var warnings []string
+	for _, target := range ct.Spec.Targets {
+		warnings = append(warnings, lintModule("rego", target.Rego)...)
+		for i, lib := range target.Libs {
+			warnings = append(warnings, lintModule(fmt.Sprintf("libs[%d]", i), lib)...)
+		}
+	}
+	return warnings
+}
+
+// StatusWarnings returns the warnings for the template's status. They are
+// kept apart from its errors, so a template with only warnings is still
+// reported as loaded.
+func StatusWarnings(warnings []string) []*v1beta1.CreateCRDError {
+	var out []*v1beta1.CreateCRDError
+	for _, w := range warnings {
+		out = append(out, &v1beta1.CreateCRDError{Code: Code, Message: w})
+	}
+	return out
+}
+
+func lintModule(name, src string) []string {
+	module, err := ast.ParseModule(name, src)
+	if err != nil || module == nil {
+		return nil
+	}
+	var warnings []string
+	warn := func(loc *ast.Location, format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		if loc != nil {
+			msg = fmt.Sprintf("%s:%d: %s", name, loc.Row, msg)
+		}
+		warnings = append(warnings, msg)
+	}
+
+	// calls are either whole expressions or terms nested inside one, e.g. the
+	// right hand side of an assignment
+	checkCall := func(loc *ast.Location, op string) {
+		if replacement, ok := deprecatedBuiltins[op]; ok {
+			warn(loc, "%s is deprecated, use %s instead", op, replacement)
+		}
+	}
+	ast.NewGenericVisitor(func(x interface{}) bool {
+		switch x := x.(type) {
+		case *ast.Expr:
+			if x.IsCall() {
+				checkCall(x.Location, x.Operator().String())
+			}
+		case *ast.Term:
+			if call, ok := x.Value.(ast.Call); ok && len(call) > 0 {
+				checkCall(x.Location, call[0].String())
+			}
+		}
+		return false
+	}).Walk(module)
+
+	used := make(map[ast.Var]bool)
+	for _, rule := range module.Rules {
+		ast.WalkRefs(rule, func(ref ast.Ref) bool {
+			if v, ok := ref[0].Value.(ast.Var); ok {
+				used[v] = true
+			}
+			return false
+		})
+		ast.WalkVars(rule, func(v ast.Var) bool {
+			used[v] = true
+			return false
+		})
+	}
+	var unused []*ast.Import
+	for _, imp := range module.Imports {
+		if !used[importName(imp)] {
+			unused = append(unused, imp)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool { return unused[i].Location.Row < unused[j].Location.Row })
+	for _, imp := range unused {
+		warn(imp.Location, "import %s is unused", imp.Path)
+	}
+	return warnings
+}
+
+// importName returns the variable an import is referenced by
+func importName(imp *ast.Import) ast.Var {
+	if imp.Alias != "" {
+		return imp.Alias
+	}
+	ref, ok := imp.Path.Value.(ast.Ref)
+	if !ok {
+		if v, ok := imp.Path.Value.(ast.Var); ok {
+			return v
+		}
+		return ""
+	}
+	last := ref[len(ref)-1]
+	switch v := last.Value.(type) {
+	case ast.String:
+		return ast.Var(v)
+	case ast.Var:
+		return v
+	}
+	return ""
+}
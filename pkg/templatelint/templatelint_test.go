@@ -0,0 +1,90 @@
+package templatelint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+)
+
+func TestWarnings(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Rego     string
+		Libs     []string
+		Expected []string
+	}{
+		{
+			Name: "Clean",
+			Rego: `package foo
+import data.lib.helpers
+violation[{"msg": "bad"}] { helpers.bad }`,
+		},
+		{
+			Name: "Deprecated built-in",
+			Rego: `package foo
+violation[{"msg": "bad"}] {
+  x := set_diff({1}, {2})
+  net.cidr_overlap("10.0.0.0/8", "10.0.0.1")
+}`,
+			Expected: []string{
+				"rego:3: set_diff is deprecated, use the minus operator instead",
+				"rego:4: net.cidr_overlap is deprecated, use net.cidr_contains instead",
+			},
+		},
+		{
+			Name: "Unused imports",
+			Rego: `package foo
+import data.lib.helpers
+import input.review.object as obj
+import data.lib.used
+violation[{"msg": "bad"}] { x := used; x.bad }`,
+			Expected: []string{
+				"rego:2: import data.lib.helpers is unused",
+				"rego:3: import input.review.object is unused",
+			},
+		},
+		{
+			Name: "Library warnings",
+			Rego: `package foo
+violation[{"msg": "bad"}] { true }`,
+			Libs: []string{`package lib.a
+import data.lib.b
+x { cast_string("a") }`},
+			Expected: []string{
+				"libs[0]:3: cast_string is deprecated, use is_string instead",
+				"libs[0]:2: import data.lib.b is unused",
+			},
+		},
+		{
+			Name: "Parse errors are not warnings",
+			Rego: `package foo violation[`,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			ct := &templates.ConstraintTemplate{}
+			ct.Spec.Targets = []templates.Target{{Rego: tt.Rego, Libs: tt.Libs}}
+			got := Warnings(ct)
+			if !reflect.DeepEqual(got, tt.Expected) {
+				t.Errorf("got %q, want %q", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestStatusWarnings(t *testing.T) {
+	if got := StatusWarnings(nil); len(got) != 0 {
+		t.Errorf("got %d status warnings without warnings, want 0", len(got))
+	}
+	warnings := []string{"rego:2: import data.lib.helpers is unused", "rego:3: set_diff is deprecated, use the minus operator instead"}
+	got := StatusWarnings(warnings)
+	if len(got) != len(warnings) {
+		t.Fatalf("got %d status warnings, want %d", len(got), len(warnings))
+	}
+	for i, w := range got {
+		if w.Code != Code || w.Message != warnings[i] {
+			t.Errorf("warnings[%d] = %+v, want code %s and message %q", i, w, Code, warnings[i])
+		}
+	}
+}
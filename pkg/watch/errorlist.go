@@ -15,7 +15,12 @@ limitations under the License.
 
 package watch
 
-import "strings"
+import (
+	"errors"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
 
 // errorList is an error that aggregates multiple errors.
 type errorList []error
@@ -34,3 +39,21 @@ func (e errorList) Error() string {
 	}
 	return builder.String()
 }
+
+// IsNotEstablished returns true if err is caused by kinds the API server does
+// not serve yet, such as the kind of a CRD that was just created. An
+// errorList is only not established if all of its errors are.
+func IsNotEstablished(err error) bool {
+	var list errorList
+	if errors.As(err, &list) {
+		for _, e := range list {
+			if !IsNotEstablished(e) {
+				return false
+			}
+		}
+		return len(list) > 0
+	}
+	var noKind *meta.NoKindMatchError
+	var noResource *meta.NoResourceMatchError
+	return errors.As(err, &noKind) || errors.As(err, &noResource)
+}
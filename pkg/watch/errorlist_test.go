@@ -0,0 +1,51 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsNotEstablished(t *testing.T) {
+	noKind := &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "constraints.gatekeeper.sh", Kind: "K8sRequiredLabels"}}
+	noResource := &meta.NoResourceMatchError{PartialResource: schema.GroupVersionResource{Resource: "crontabs"}}
+	tc := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "no kind match", err: noKind, want: true},
+		{name: "no resource match", err: noResource, want: true},
+		{name: "wrapped", err: fmt.Errorf("getting informer for kind: %w", noKind), want: true},
+		{name: "other error", err: errors.New("connection refused"), want: false},
+		{name: "all not established", err: errorList{noKind, fmt.Errorf("adding watch: %w", noResource)}, want: true},
+		{name: "some not established", err: errorList{noKind, errors.New("connection refused")}, want: false},
+		{name: "empty list", err: errorList{}, want: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotEstablished(tt.err); got != tt.want {
+				t.Errorf("IsNotEstablished(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -179,6 +179,14 @@ func (r *Registrar) ReplaceWatch(gvks []schema.GroupVersionKind) error {
 	return r.mgr.replaceWatches(r)
 }
 
+// Replay sends the cached resources of the given watched kind to the
+// registrar's events again, so they are reconciled against a changed
+// definition of the kind. A replay already in progress is restarted.
+func (r *Registrar) Replay(gvk schema.GroupVersionKind) {
+	r.mgr.cancelReplay(r, gvk)
+	r.mgr.requestReplay(r, gvk)
+}
+
 // RemoveWatch removes a watch for the given kind.
 // Ignores the request if the kind was not previously watched.
 func (r *Registrar) RemoveWatch(gvk schema.GroupVersionKind) error {
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"flag"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// broadMatchAnnotation allows a constraint to match more kinds than
+// --max-match-kinds
+const broadMatchAnnotation = "constraint.gatekeeper.sh/allow-broad-match"
+
+var maxMatchKinds = flag.Int("max-match-kinds", 0, "maximum number of group/kind pairs a constraint can match. Wildcards and constraints without match.kinds match every kind and always exceed the limit. Constraints with the constraint.gatekeeper.sh/allow-broad-match annotation set to true are exempt. defaulted to 0, which doesn't limit them, if unspecified ")
+
+// matchedKinds returns the number of group/kind pairs the kinds of a
+// constraint's match select, or -1 if they select every group or every kind
+func matchedKinds(obj *unstructured.Unstructured) (int, error) {
+	selectors, found, err := unstructured.NestedSlice(obj.Object, "spec", "match", "kinds")
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return -1, nil
+	}
+	count := 0
+	for _, s := range selectors {
+		ks, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		groups, _, err := unstructured.NestedStringSlice(ks, "apiGroups")
+		if err != nil {
+			return 0, err
+		}
+		kinds, _, err := unstructured.NestedStringSlice(ks, "kinds")
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range append(groups, kinds...) {
+			if v == "*" {
+				return -1, nil
+			}
+		}
+		count += len(groups) * len(kinds)
+	}
+	return count, nil
+}
+
+// validateMatchBreadth rejects constraints that match more kinds than
+// --max-match-kinds, unless they have the broad match annotation. Broad
+// matches allowed by the annotation are logged.
+func validateMatchBreadth(obj *unstructured.Unstructured, req admission.Request) error {
+	if *maxMatchKinds <= 0 {
+		return nil
+	}
+	n, err := matchedKinds(obj)
+	if err != nil {
+		return err
+	}
+	if n >= 0 && n <= *maxMatchKinds {
+		return nil
+	}
+	if obj.GetAnnotations()[broadMatchAnnotation] != "true" {
+		matched := fmt.Sprintf("%d kinds", n)
+		if n < 0 {
+			matched = "every kind"
+		}
+		return fmt.Errorf("constraint %s matches %s, more than the limit of %d. Set the %s annotation to true to allow it",
+			obj.GetName(), matched, *maxMatchKinds, broadMatchAnnotation)
+	}
+	log.Info("broad constraint match allowed by annotation",
+		"process", "admission",
+		"event_type", "broad_match_override",
+		"constraint_name", obj.GetName(),
+		"constraint_kind", obj.GetKind(),
+		"matched_kinds", n,
+		"request_username", req.AdmissionRequest.UserInfo.Username,
+	)
+	return nil
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateMatchBreadth(t *testing.T) {
+	kinds := func(groups, kinds []interface{}) map[string]interface{} {
+		return map[string]interface{}{"apiGroups": groups, "kinds": kinds}
+	}
+	tc := []struct {
+		Name          string
+		Match         map[string]interface{}
+		Annotations   map[string]string
+		ErrorExpected bool
+	}{
+		{Name: "within the limit", Match: map[string]interface{}{"kinds": []interface{}{kinds([]interface{}{""}, []interface{}{"Pod"}), kinds([]interface{}{"apps"}, []interface{}{"Deployment"})}}},
+		{Name: "over the limit", Match: map[string]interface{}{"kinds": []interface{}{kinds([]interface{}{"", "apps"}, []interface{}{"Pod", "Deployment"})}}, ErrorExpected: true},
+		{Name: "wildcard kind", Match: map[string]interface{}{"kinds": []interface{}{kinds([]interface{}{""}, []interface{}{"*"})}}, ErrorExpected: true},
+		{Name: "no kinds", Match: map[string]interface{}{}, ErrorExpected: true},
+		{
+			Name:        "broad match allowed",
+			Match:       map[string]interface{}{"kinds": []interface{}{kinds([]interface{}{"*"}, []interface{}{"*"})}},
+			Annotations: map[string]string{broadMatchAnnotation: "true"},
+		},
+	}
+	limit := *maxMatchKinds
+	*maxMatchKinds = 3
+	defer func() { *maxMatchKinds = limit }()
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"match": tt.Match}}}
+			obj.SetAnnotations(tt.Annotations)
+			if err := validateMatchBreadth(obj, atypes.Request{}); (err != nil) != tt.ErrorExpected {
+				t.Errorf("err = %v, want error: %v", err, tt.ErrorExpected)
+			}
+		})
+	}
+}
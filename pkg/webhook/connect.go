@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"flag"
+	"fmt"
+)
+
+type connectPolicy string
+
+const (
+	// skipConnect admits CONNECT requests, such as pods/exec, without
+	// evaluating them
+	skipConnect connectPolicy = "skip"
+	// evaluateConnect evaluates CONNECT requests against the constraints
+	// that list CONNECT in their operations
+	evaluateConnect connectPolicy = "evaluate"
+)
+
+var connectRequests = skipConnect
+
+func init() {
+	flag.Var(&connectRequests, "connect-policy", "how CONNECT requests, e.g. pods/exec, are handled: skip (admit without evaluation) or evaluate (review them against constraints that match the CONNECT operation)")
+}
+
+var _ flag.Value = new(connectPolicy)
+
+func (p *connectPolicy) String() string {
+	return string(*p)
+}
+
+func (p *connectPolicy) Set(s string) error {
+	switch connectPolicy(s) {
+	case skipConnect, evaluateConnect:
+		*p = connectPolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid connect policy %q, expected one of skip or evaluate", s)
+}
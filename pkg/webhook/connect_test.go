@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestConnectSkipped(t *testing.T) {
+	handler := validationHandler{}
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:        metav1.GroupVersionKind{Version: "v1", Kind: "PodExecOptions"},
+			Operation:   admissionv1beta1.Connect,
+			SubResource: "exec",
+		},
+	}
+	if resp := handler.Handle(context.Background(), req); !resp.Allowed {
+		t.Errorf("expected CONNECT requests to be allowed without evaluation, got %v", resp.Result)
+	}
+}
+
+func TestConnectOperations(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(goodRegoTemplate), cstr); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	crud := newConstraint("K8sGoodRego", "crud", "deny", t)
+	connect := newConstraint("K8sGoodRego", "connect", "deny", t)
+	if err := unstructured.SetNestedStringSlice(connect.Object, []string{"CONNECT"}, "spec", "match", "operations"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*unstructured.Unstructured{crud, connect} {
+		c.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+		if _, err := opa.AddConstraint(context.Background(), c); err != nil {
+			t.Fatalf("Could not add constraint: %s", err)
+		}
+	}
+	connectRequests = evaluateConnect
+	defer func() { connectRequests = skipConnect }()
+	handler := validationHandler{opa: opa, injectedConfig: &v1alpha1.Config{}}
+
+	tc := []struct {
+		Name      string
+		Operation admissionv1beta1.Operation
+		Object    []byte
+		Expected  string
+	}{
+		{
+			Name:      "create",
+			Operation: admissionv1beta1.Create,
+			Object:    []byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo"}}`),
+			Expected:  "crud",
+		},
+		{
+			Name:      "connect",
+			Operation: admissionv1beta1.Connect,
+			Object:    []byte(`{"apiVersion": "v1", "kind": "PodExecOptions", "command": ["sh"]}`),
+			Expected:  "connect",
+		},
+		{
+			Name:      "connect without an object",
+			Operation: admissionv1beta1.Connect,
+			Expected:  "connect",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := atypes.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+					Operation: tt.Operation,
+					Object:    runtime.RawExtension{Raw: tt.Object},
+				},
+			}
+			resp := handler.Handle(context.Background(), req)
+			if resp.Allowed {
+				t.Fatalf("expected the request to be denied")
+			}
+			if msg := string(resp.Result.Reason); msg != fmt.Sprintf("[denied by %s] Maybe this will work?", tt.Expected) {
+				t.Errorf("got %q, want only a denial by %s", msg, tt.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	decisionCacheTTL  = flag.Duration("decision-cache-ttl", 0, "how long the result of evaluating an admission request is reused for identical requests, 0 to disable the decision cache")
+	decisionCacheSize = flag.Int("decision-cache-size", 10000, "maximum number of cached admission decisions")
+)
+
+// decisionKey identifies everything about a review that its result depends
+// on, other than the templates, constraints and data loaded into OPA, which
+// the cache revision tracks
+func decisionKey(review *target.AugmentedReview) (string, error) {
+	req := *review.AdmissionRequest
+	// the UID is unique to each request
+	req.UID = ""
+	// matching reads the namespace's labels, for namespace selectors, and its
+	// phase, to skip Terminating namespaces
+	key := struct {
+		Request         *admissionv1beta1.AdmissionRequest `json:"request"`
+		NamespaceLabels map[string]string                  `json:"namespaceLabels,omitempty"`
+		NamespacePhase  corev1.NamespacePhase              `json:"namespacePhase,omitempty"`
+	}{Request: &req}
+	if review.Namespace != nil {
+		key.NamespaceLabels = review.Namespace.GetLabels()
+		key.NamespacePhase = review.Namespace.Status.Phase
+	}
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDecisionKey(t *testing.T) {
+	newReview := func(uid, name string, labels map[string]string) *target.AugmentedReview {
+		review := &target.AugmentedReview{AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+			UID:    k8stypes.UID("uid-" + uid),
+			Object: runtime.RawExtension{Raw: []byte(`{"kind": "Pod", "metadata": {"name": "` + name + `"}}`)},
+		}}
+		if labels != nil {
+			review.Namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+		}
+		return review
+	}
+	key := func(review *target.AugmentedReview) string {
+		k, err := decisionKey(review)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	base := key(newReview("1", "foo", map[string]string{"env": "prod"}))
+	if key(newReview("2", "foo", map[string]string{"env": "prod"})) != base {
+		t.Error("the request UID should not change the key")
+	}
+	if key(newReview("1", "bar", map[string]string{"env": "prod"})) == base {
+		t.Error("the object should change the key")
+	}
+	if key(newReview("1", "foo", map[string]string{"env": "dev"})) == base {
+		t.Error("the namespace labels should change the key")
+	}
+	terminating := newReview("1", "foo", map[string]string{"env": "prod"})
+	terminating.Namespace.Status.Phase = corev1.NamespaceTerminating
+	if key(terminating) == base {
+		t.Error("the namespace phase should change the key")
+	}
+}
+
+func TestDecisionCache(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(goodRegoTemplate), cstr); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	constraint := newConstraint("K8sGoodRego", "foo", "deny", t)
+	constraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	if _, err := opa.AddConstraint(context.Background(), constraint); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+
+	// decisions are only cached once synced data is current
+	syncc.Freshness.MarkLoaded()
+	handler := validationHandler{
+		opa:            opa,
+		injectedConfig: &v1alpha1.Config{},
+		decisions:      decisioncache.New(10, time.Minute),
+	}
+	review := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Object: runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace"}`)},
+		},
+	}
+	count := func() int {
+		resp, err := handler.reviewRequest(context.Background(), review)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return len(resp.Results())
+	}
+
+	if n := count(); n != 1 {
+		t.Fatalf("got %d results, want 1", n)
+	}
+	// the controllers invalidate the cache on every change, so a change that
+	// bypasses them is only seen once the cache is invalidated
+	if _, err := opa.RemoveConstraint(context.Background(), constraint); err != nil {
+		t.Fatalf("Could not remove constraint: %s", err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("got %d results from the cache, want 1", n)
+	}
+	decisioncache.Invalidate()
+	if n := count(); n != 0 {
+		t.Errorf("got %d results after invalidation, want 0", n)
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/objdiff"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const diffTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8simmutablereplicas
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sImmutableReplicas
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package immutablereplicas
+
+        violation[{"msg": msg}] {
+          change := input.review.diff[_]
+          change.path == ["spec", "replicas"]
+          msg := sprintf("replicas changed from %v to %v", [change.old, change.new])
+        }
+`
+
+func TestReviewDiff(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(diffTemplate), cstr); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	c := newConstraint("K8sImmutableReplicas", "replicas", "deny", t)
+	c.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	if _, err := opa.AddConstraint(context.Background(), c); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+	objdiff.Templates.Set("K8sImmutableReplicas", true)
+	defer objdiff.Templates.Remove("K8sImmutableReplicas")
+	handler := validationHandler{opa: opa, injectedConfig: &v1alpha1.Config{}}
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Operation: admissionv1beta1.Update,
+			OldObject: runtime.RawExtension{Raw: []byte(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "foo"}, "spec": {"replicas": 1}}`)},
+			Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "foo"}, "spec": {"replicas": 3}}`)},
+		},
+	}
+	resp := handler.Handle(context.Background(), req)
+	if resp.Allowed {
+		t.Fatal("expected the request to be denied")
+	}
+	if msg, want := string(resp.Result.Reason), "[denied by replicas] replicas changed from 1 to 3"; msg != want {
+		t.Errorf("got %q, want %q", msg, want)
+	}
+
+	req.Object = req.OldObject
+	if resp := handler.Handle(context.Background(), req); !resp.Allowed {
+		t.Errorf("expected an unchanged object to be allowed, got %v", resp.Result)
+	}
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+type generateNamePolicy string
+
+const (
+	// emptyName leaves the name of objects created with generateName empty
+	emptyName generateNamePolicy = "empty"
+	// prefixName uses the generateName prefix as the name of those objects
+	prefixName generateNamePolicy = "prefix"
+)
+
+var generateNames = emptyName
+
+func init() {
+	flag.Var(&generateNames, "generate-name-policy", "the name reviewed for objects created with generateName, which have no name until the API server assigns one: empty (leave the name empty) or prefix (use the generateName prefix as the name). Either way the prefix is set as input.review.generateName")
+}
+
+var _ flag.Value = new(generateNamePolicy)
+
+func (p *generateNamePolicy) String() string {
+	return string(*p)
+}
+
+func (p *generateNamePolicy) Set(s string) error {
+	switch generateNamePolicy(s) {
+	case emptyName, prefixName:
+		*p = generateNamePolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid generate name policy %q, expected one of empty or prefix", s)
+}
+
+// generateName returns the generateName of the object a request creates, if
+// the object has no name yet
+func generateName(req *admissionv1beta1.AdmissionRequest) string {
+	if req.Operation != admissionv1beta1.Create || req.Name != "" || len(req.Object.Raw) == 0 {
+		return ""
+	}
+	obj := struct {
+		Metadata struct {
+			Name         string `json:"name"`
+			GenerateName string `json:"generateName"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil || obj.Metadata.Name != "" {
+		return ""
+	}
+	return obj.Metadata.GenerateName
+}
+
+// nameFromPrefix returns a copy of the request whose name, and whose object's
+// name, are the generateName prefix
+func nameFromPrefix(req *admissionv1beta1.AdmissionRequest, prefix string) *admissionv1beta1.AdmissionRequest {
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		log.Error(err, "unable to decode object, reviewing it without a name")
+		return req
+	}
+	meta, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return req
+	}
+	meta["name"] = prefix
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		log.Error(err, "unable to encode object, reviewing it without a name")
+		return req
+	}
+	// shallow copy, only the name and object are replaced
+	named := *req
+	named.Name = prefix
+	named.Object.Raw = raw
+	named.Object.Object = nil
+	return &named
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGenerateName(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Request  admissionv1beta1.AdmissionRequest
+		Expected string
+	}{
+		{
+			Name: "generated name",
+			Request: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(`{"metadata": {"generateName": "job-"}}`)},
+			},
+			Expected: "job-",
+		},
+		{
+			Name: "name set",
+			Request: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+				Name:      "job-abcde",
+				Object:    runtime.RawExtension{Raw: []byte(`{"metadata": {"name": "job-abcde", "generateName": "job-"}}`)},
+			},
+		},
+		{
+			Name: "update",
+			Request: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Update,
+				Object:    runtime.RawExtension{Raw: []byte(`{"metadata": {"generateName": "job-"}}`)},
+			},
+		},
+		{
+			Name: "no object",
+			Request: admissionv1beta1.AdmissionRequest{
+				Operation: admissionv1beta1.Create,
+			},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := generateName(&tt.Request); got != tt.Expected {
+				t.Errorf("generateName() = %q, want %q", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestNameFromPrefix(t *testing.T) {
+	req := &admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(`{"kind": "Job", "metadata": {"generateName": "job-"}}`)},
+	}
+	named := nameFromPrefix(req, "job-")
+	if named.Name != "job-" {
+		t.Errorf("name = %q, want job-", named.Name)
+	}
+	expected := `{"kind":"Job","metadata":{"generateName":"job-","name":"job-"}}`
+	if string(named.Object.Raw) != expected {
+		t.Errorf("object = %s, want %s", named.Object.Raw, expected)
+	}
+	if req.Name != "" || string(req.Object.Raw) == expected {
+		t.Error("original request was modified")
+	}
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// immutableOverrideAnnotation allows an update of a ConstraintTemplate to
+// change its immutable fields
+const immutableOverrideAnnotation = "templates.gatekeeper.sh/allow-immutable-changes"
+
+var enforceTemplateImmutability = flag.Bool("enforce-template-immutability", true, "reject updates of a ConstraintTemplate that change its constraint kind or target names, unless the template has the templates.gatekeeper.sh/allow-immutable-changes annotation set to true")
+
+// immutableTemplateFields returns, for each field of a template that breaks
+// its constraints when changed, a comparable representation of its value
+func immutableTemplateFields(templ *unstructured.Unstructured) map[string]string {
+	kind, _, _ := unstructured.NestedString(templ.Object, "spec", "crd", "spec", "names", "kind")
+	targetList, _, _ := unstructured.NestedSlice(templ.Object, "spec", "targets")
+	var targets []string
+	for _, t := range targetList {
+		if m, ok := t.(map[string]interface{}); ok {
+			name, _ := m["target"].(string)
+			targets = append(targets, name)
+		}
+	}
+	sort.Strings(targets)
+	return map[string]string{
+		"spec.crd.spec.names.kind": kind,
+		"spec.targets[].target":    strings.Join(targets, ","),
+	}
+}
+
+// checkTemplateImmutability rejects an update of a template that changes an
+// immutable field without the override annotation. Overrides are logged, so
+// changes made with them can be traced to the user who made them.
+func checkTemplateImmutability(req admission.Request) error {
+	if !*enforceTemplateImmutability || req.AdmissionRequest.Operation != admissionv1beta1.Update {
+		return nil
+	}
+	oldTempl := &unstructured.Unstructured{}
+	if err := json.Unmarshal(req.AdmissionRequest.OldObject.Raw, &oldTempl.Object); err != nil {
+		return err
+	}
+	newTempl := &unstructured.Unstructured{}
+	if err := json.Unmarshal(req.AdmissionRequest.Object.Raw, &newTempl.Object); err != nil {
+		return err
+	}
+	oldFields := immutableTemplateFields(oldTempl)
+	var changed []string
+	for field, v := range immutableTemplateFields(newTempl) {
+		if oldFields[field] != v {
+			changed = append(changed, field)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	if newTempl.GetAnnotations()[immutableOverrideAnnotation] != "true" {
+		return fmt.Errorf("changing %s of ConstraintTemplate %s would break its constraints, set the %s annotation to true to change it anyway",
+			strings.Join(changed, " and "), newTempl.GetName(), immutableOverrideAnnotation)
+	}
+	log.Info("immutable template fields changed with an override",
+		"process", "admission",
+		"event_type", "template_immutable_override",
+		"template_name", newTempl.GetName(),
+		"fields", changed,
+		"request_username", req.AdmissionRequest.UserInfo.Username,
+	)
+	return nil
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"fmt"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func immutableTestTemplate(kind, target, annotations string) []byte {
+	return []byte(fmt.Sprintf(`{
+  "apiVersion": "templates.gatekeeper.sh/v1beta1",
+  "kind": "ConstraintTemplate",
+  "metadata": {"name": "k8srequiredlabels", "annotations": {%s}},
+  "spec": {
+    "crd": {"spec": {"names": {"kind": %q}}},
+    "targets": [{"target": %q, "rego": "package foo"}]
+  }
+}`, annotations, kind, target))
+}
+
+func TestCheckTemplateImmutability(t *testing.T) {
+	old := immutableTestTemplate("K8sRequiredLabels", "admission.k8s.gatekeeper.sh", "")
+	tc := []struct {
+		Name      string
+		Operation admissionv1beta1.Operation
+		Object    []byte
+		ErrorExp  bool
+	}{
+		{
+			Name:      "unchanged",
+			Operation: admissionv1beta1.Update,
+			Object:    immutableTestTemplate("K8sRequiredLabels", "admission.k8s.gatekeeper.sh", `"foo": "bar"`),
+		},
+		{
+			Name:      "target changed",
+			Operation: admissionv1beta1.Update,
+			Object:    immutableTestTemplate("K8sRequiredLabels", "other.target", ""),
+			ErrorExp:  true,
+		},
+		{
+			Name:      "kind changed",
+			Operation: admissionv1beta1.Update,
+			Object:    immutableTestTemplate("K8sLabels", "admission.k8s.gatekeeper.sh", ""),
+			ErrorExp:  true,
+		},
+		{
+			Name:      "changed with override",
+			Operation: admissionv1beta1.Update,
+			Object:    immutableTestTemplate("K8sLabels", "other.target", fmt.Sprintf("%q: \"true\"", immutableOverrideAnnotation)),
+		},
+		{
+			Name:      "create",
+			Operation: admissionv1beta1.Create,
+			Object:    immutableTestTemplate("K8sLabels", "other.target", ""),
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := atypes.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Operation: tt.Operation,
+					Object:    runtime.RawExtension{Raw: tt.Object},
+					OldObject: runtime.RawExtension{Raw: old},
+				},
+			}
+			err := checkTemplateImmutability(req)
+			if (err != nil) != tt.ErrorExp {
+				t.Errorf("checkTemplateImmutability() error = %v, want error %v", err, tt.ErrorExp)
+			}
+		})
+	}
+}
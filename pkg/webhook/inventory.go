@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
+	"github.com/open-policy-agent/gatekeeper/pkg/syncdata"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxStalenessAnnotation sets how long the synced data a constraint reads may
+// be incomplete before the constraint can no longer be evaluated
+const maxStalenessAnnotation = "inventory.gatekeeper.sh/max-staleness"
+
+type staleInventoryPolicy string
+
+const (
+	// failClosedStale rejects requests matched by constraints whose data is
+	// too stale
+	failClosedStale staleInventoryPolicy = "fail-closed"
+	// failOpenStale skips constraints whose data is too stale
+	failOpenStale staleInventoryPolicy = "fail-open"
+	// warnStale evaluates constraints whose data is too stale and logs a
+	// warning
+	warnStale staleInventoryPolicy = "warn"
+)
+
+type unsyncedInventoryPolicy string
+
+const (
+	// failClosedUnsynced rejects requests matched by constraints whose
+	// required data is not synced yet
+	failClosedUnsynced unsyncedInventoryPolicy = "fail-closed"
+	// failOpenUnsynced skips constraints whose required data is not synced
+	// yet
+	failOpenUnsynced unsyncedInventoryPolicy = "fail-open"
+	// retryUnsynced rejects requests matched by constraints whose required
+	// data is not synced yet with a 429 status, asking the client to retry
+	retryUnsynced unsyncedInventoryPolicy = "retry"
+)
+
+// unsyncedRetryAfterSeconds is how long clients are asked to wait before
+// retrying under the retry policy
+const unsyncedRetryAfterSeconds = 5
+
+var (
+	staleInventory    = failClosedStale
+	unsyncedInventory = failClosedUnsynced
+)
+
+func init() {
+	flag.Var(&staleInventory, "stale-inventory-policy", "how constraints with a max staleness are handled while synced data is staler than that: fail-closed (reject), fail-open (skip the constraint), or warn (evaluate against the stale data and log)")
+	flag.Var(&unsyncedInventory, "unsynced-inventory-policy", "how constraints whose template requires sync data are handled while that data is not synced yet: fail-closed (reject), fail-open (skip the constraint), or retry (reject with a 429 status asking the client to retry)")
+}
+
+var _ flag.Value = new(staleInventoryPolicy)
+
+func (p *staleInventoryPolicy) String() string {
+	return string(*p)
+}
+
+func (p *staleInventoryPolicy) Set(s string) error {
+	switch staleInventoryPolicy(s) {
+	case failClosedStale, failOpenStale, warnStale:
+		*p = staleInventoryPolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid stale inventory policy %q, expected one of fail-closed, fail-open or warn", s)
+}
+
+var _ flag.Value = new(unsyncedInventoryPolicy)
+
+func (p *unsyncedInventoryPolicy) String() string {
+	return string(*p)
+}
+
+func (p *unsyncedInventoryPolicy) Set(s string) error {
+	switch unsyncedInventoryPolicy(s) {
+	case failClosedUnsynced, failOpenUnsynced, retryUnsynced:
+		*p = unsyncedInventoryPolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid unsynced inventory policy %q, expected one of fail-closed, fail-open or retry", s)
+}
+
+// validateMaxStaleness checks the constraint's max staleness annotation is a
+// duration
+func validateMaxStaleness(obj *unstructured.Unstructured) error {
+	maxStaleness, ok := obj.GetAnnotations()[maxStalenessAnnotation]
+	if !ok {
+		return nil
+	}
+	if _, err := time.ParseDuration(maxStaleness); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", maxStalenessAnnotation, err)
+	}
+	return nil
+}
+
+// hasDetail returns whether the result's details set the flag
+func hasDetail(r *rtypes.Result, flag string) bool {
+	details, ok := r.Metadata["details"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	set, _ := details[flag].(bool)
+	return set
+}
+
+// isStaleInventory returns whether the result was raised because the synced
+// data is staler than the constraint allows
+func isStaleInventory(r *rtypes.Result) bool {
+	return hasDetail(r, "staleInventory")
+}
+
+// isUnsyncedInventory returns whether the result was raised because the
+// data the constraint's template requires is not synced yet
+func isUnsyncedInventory(r *rtypes.Result) bool {
+	return hasDetail(r, "unsyncedInventory")
+}
+
+// applyStaleInventoryPolicy returns the results to act on under policy, and
+// the stale inventory results that were dropped
+func applyStaleInventoryPolicy(results []*rtypes.Result, policy staleInventoryPolicy) ([]*rtypes.Result, []*rtypes.Result) {
+	if policy == failClosedStale {
+		return results, nil
+	}
+	return dropRejections(results, isStaleInventory, policy == failOpenStale)
+}
+
+// applyUnsyncedInventoryPolicy returns the results to act on under policy,
+// and the unsynced inventory results that were dropped
+func applyUnsyncedInventoryPolicy(results []*rtypes.Result, policy unsyncedInventoryPolicy) ([]*rtypes.Result, []*rtypes.Result) {
+	if policy != failOpenUnsynced {
+		return results, nil
+	}
+	return dropRejections(results, isUnsyncedInventory, true)
+}
+
+// dropRejections drops the results rejected reports true for, and if skip
+// is set, the other results of their constraints
+func dropRejections(results []*rtypes.Result, rejected func(*rtypes.Result) bool, skip bool) ([]*rtypes.Result, []*rtypes.Result) {
+	type constraintKey struct{ kind, name string }
+	flagged := make(map[constraintKey]bool)
+	var dropped []*rtypes.Result
+	for _, r := range results {
+		if rejected(r) {
+			flagged[constraintKey{r.Constraint.GetKind(), r.Constraint.GetName()}] = true
+			dropped = append(dropped, r)
+		}
+	}
+	if len(flagged) == 0 {
+		return results, nil
+	}
+	var kept []*rtypes.Result
+	for _, r := range results {
+		if rejected(r) {
+			continue
+		}
+		if skip && flagged[constraintKey{r.Constraint.GetKind(), r.Constraint.GetName()}] {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept, dropped
+}
+
+// onlyUnsyncedDenials returns whether the request is only denied because
+// data required by the matching constraints is not synced yet
+func onlyUnsyncedDenials(results []*rtypes.Result) bool {
+	unsynced := false
+	for _, r := range results {
+		if r.EnforcementAction != "deny" {
+			continue
+		}
+		if !isUnsyncedInventory(r) {
+			return false
+		}
+		unsynced = true
+	}
+	return unsynced
+}
+
+// unsyncedData returns, by template name, the kinds each template requires
+// that are not synced yet
+func unsyncedData() map[string][]string {
+	var unsynced map[string][]string
+	for template, gvks := range syncdata.Templates.Required() {
+		missing := syncc.Freshness.Unsynced(gvks)
+		if len(missing) == 0 {
+			continue
+		}
+		if unsynced == nil {
+			unsynced = make(map[string][]string)
+		}
+		for _, gvk := range missing {
+			unsynced[template] = append(unsynced[template], gvk.GroupVersion().String()+"/"+gvk.Kind)
+		}
+	}
+	return unsynced
+}
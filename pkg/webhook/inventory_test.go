@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
+	"github.com/open-policy-agent/gatekeeper/pkg/detailsschema"
+	"github.com/open-policy-agent/gatekeeper/pkg/syncdata"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func staleTestResult(name string, stale bool) *rtypes.Result {
+	c := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	c.SetKind("K8sRequiredLabels")
+	c.SetName(name)
+	details := map[string]interface{}{}
+	if stale {
+		details["staleInventory"] = true
+	}
+	return &rtypes.Result{Constraint: c, Metadata: map[string]interface{}{"details": details}, EnforcementAction: "deny"}
+}
+
+func TestApplyStaleInventoryPolicy(t *testing.T) {
+	results := []*rtypes.Result{
+		staleTestResult("a", true),
+		staleTestResult("a", false),
+		staleTestResult("b", false),
+	}
+	tc := []struct {
+		Name        string
+		Policy      staleInventoryPolicy
+		WantKept    int
+		WantDropped int
+	}{
+		{Name: "fail-closed keeps the rejection", Policy: failClosedStale, WantKept: 3, WantDropped: 0},
+		{Name: "fail-open skips the stale constraint", Policy: failOpenStale, WantKept: 1, WantDropped: 1},
+		{Name: "warn keeps the stale constraint's violations", Policy: warnStale, WantKept: 2, WantDropped: 1},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			kept, dropped := applyStaleInventoryPolicy(results, tt.Policy)
+			if len(kept) != tt.WantKept || len(dropped) != tt.WantDropped {
+				t.Errorf("got %d kept and %d dropped, want %d and %d", len(kept), len(dropped), tt.WantKept, tt.WantDropped)
+			}
+			for _, r := range kept {
+				if tt.Policy != failClosedStale && isStaleInventory(r) {
+					t.Errorf("stale inventory result for %s was kept", r.Constraint.GetName())
+				}
+			}
+		})
+	}
+}
+
+// detailsSchema is a details schema the target's rejections don't match
+const detailsSchema = `
+type: object
+required: ["missing"]
+properties:
+  missing:
+    type: string
+`
+
+func TestStaleInventoryWithDetailsSchema(t *testing.T) {
+	if err := detailsschema.Templates.Set("K8sRequiredLabels", detailsSchema); err != nil {
+		t.Fatal(err)
+	}
+	defer detailsschema.Templates.Remove("K8sRequiredLabels")
+	results := []*rtypes.Result{staleTestResult("a", true)}
+	detailsschema.Templates.Sanitize(results, log)
+	if !isStaleInventory(results[0]) {
+		t.Fatal("expected the stale inventory rejection to keep its details")
+	}
+	if kept, _ := applyStaleInventoryPolicy(results, failOpenStale); len(kept) != 0 {
+		t.Errorf("got %d results under fail-open, want 0", len(kept))
+	}
+}
+
+func unsyncedTestResult(name string, unsynced bool, action string) *rtypes.Result {
+	r := staleTestResult(name, false)
+	if unsynced {
+		r.Metadata["details"] = map[string]interface{}{"unsyncedInventory": true}
+	}
+	r.EnforcementAction = action
+	return r
+}
+
+func TestApplyUnsyncedInventoryPolicy(t *testing.T) {
+	results := []*rtypes.Result{
+		unsyncedTestResult("a", true, "deny"),
+		unsyncedTestResult("a", false, "deny"),
+		unsyncedTestResult("b", false, "deny"),
+	}
+	tc := []struct {
+		Name        string
+		Policy      unsyncedInventoryPolicy
+		WantKept    int
+		WantDropped int
+	}{
+		{Name: "fail-closed keeps the rejection", Policy: failClosedUnsynced, WantKept: 3, WantDropped: 0},
+		{Name: "retry keeps the rejection", Policy: retryUnsynced, WantKept: 3, WantDropped: 0},
+		{Name: "fail-open skips the unsynced constraint", Policy: failOpenUnsynced, WantKept: 1, WantDropped: 1},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			kept, dropped := applyUnsyncedInventoryPolicy(results, tt.Policy)
+			if len(kept) != tt.WantKept || len(dropped) != tt.WantDropped {
+				t.Errorf("got %d kept and %d dropped, want %d and %d", len(kept), len(dropped), tt.WantKept, tt.WantDropped)
+			}
+		})
+	}
+}
+
+func TestUnsyncedInventoryWithDetailsSchema(t *testing.T) {
+	if err := detailsschema.Templates.Set("K8sRequiredLabels", detailsSchema); err != nil {
+		t.Fatal(err)
+	}
+	defer detailsschema.Templates.Remove("K8sRequiredLabels")
+	results := []*rtypes.Result{unsyncedTestResult("a", true, "deny")}
+	detailsschema.Templates.Sanitize(results, log)
+	if !isUnsyncedInventory(results[0]) {
+		t.Fatal("expected the unsynced inventory rejection to keep its details")
+	}
+	if !onlyUnsyncedDenials(results) {
+		t.Error("expected the request to be retried under the retry policy")
+	}
+	if kept, _ := applyUnsyncedInventoryPolicy(results, failOpenUnsynced); len(kept) != 0 {
+		t.Errorf("got %d results under fail-open, want 0", len(kept))
+	}
+}
+
+func TestOnlyUnsyncedDenials(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Results  []*rtypes.Result
+		Expected bool
+	}{
+		{Name: "no results"},
+		{Name: "unsynced", Results: []*rtypes.Result{unsyncedTestResult("a", true, "deny")}, Expected: true},
+		{Name: "unsynced and dryrun", Results: []*rtypes.Result{unsyncedTestResult("a", true, "deny"), unsyncedTestResult("b", false, "dryrun")}, Expected: true},
+		{Name: "unsynced and violation", Results: []*rtypes.Result{unsyncedTestResult("a", true, "deny"), unsyncedTestResult("b", false, "deny")}},
+		{Name: "unsynced dryrun", Results: []*rtypes.Result{unsyncedTestResult("a", true, "dryrun")}},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := onlyUnsyncedDenials(tt.Results); got != tt.Expected {
+				t.Errorf("onlyUnsyncedDenials() = %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestUnsyncedData(t *testing.T) {
+	pdbs := schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}
+	namespaces := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	syncdata.Templates.Set("k8srequiredpoddisruptionbudget", []schema.GroupVersionKind{pdbs, namespaces})
+	defer syncdata.Templates.Remove("k8srequiredpoddisruptionbudget")
+	syncc.Freshness.Replace([]schema.GroupVersionKind{pdbs, namespaces}, time.Now())
+	syncc.Freshness.MarkCurrent(namespaces)
+	syncc.Freshness.MarkLoaded()
+	defer syncc.Freshness.MarkCurrent(pdbs)
+
+	want := map[string][]string{"k8srequiredpoddisruptionbudget": {"policy/v1beta1/PodDisruptionBudget"}}
+	if got := unsyncedData(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unsyncedData() = %v, want %v", got, want)
+	}
+	syncc.Freshness.MarkCurrent(pdbs)
+	if got := unsyncedData(); got != nil {
+		t.Errorf("once synced, unsyncedData() = %v, want nil", got)
+	}
+}
+
+func TestValidateMaxStaleness(t *testing.T) {
+	tc := []struct {
+		Name          string
+		Annotations   map[string]string
+		ErrorExpected bool
+	}{
+		{Name: "no annotation"},
+		{Name: "valid duration", Annotations: map[string]string{maxStalenessAnnotation: "5m"}},
+		{Name: "invalid duration", Annotations: map[string]string{maxStalenessAnnotation: "5 minutes"}, ErrorExpected: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetAnnotations(tt.Annotations)
+			if err := validateMaxStaleness(obj); (err != nil) != tt.ErrorExpected {
+				t.Errorf("err = %v, want error: %v", err, tt.ErrorExpected)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// optionsGroupVersion is the group version of the operation options sent by
+// the API server
+const optionsGroupVersion = "meta.k8s.io/v1"
+
+// newOptions returns an empty options object of the type sent for the
+// operation, or nil if the operation's options are not normalized.
+// CONNECT options vary by subresource (e.g. PodExecOptions) and are passed
+// through as sent.
+func newOptions(op admissionv1beta1.Operation) k8sruntime.Object {
+	switch op {
+	case admissionv1beta1.Create:
+		return &metav1.CreateOptions{TypeMeta: metav1.TypeMeta{APIVersion: optionsGroupVersion, Kind: "CreateOptions"}}
+	case admissionv1beta1.Update:
+		return &metav1.UpdateOptions{TypeMeta: metav1.TypeMeta{APIVersion: optionsGroupVersion, Kind: "UpdateOptions"}}
+	case admissionv1beta1.Delete:
+		return &metav1.DeleteOptions{TypeMeta: metav1.TypeMeta{APIVersion: optionsGroupVersion, Kind: "DeleteOptions"}}
+	}
+	return nil
+}
+
+// normalizeOptions returns a copy of the request whose options are decoded
+// into the type expected for its operation, so policies can rely on
+// input.review.options always being an object with apiVersion and kind set.
+// Missing or empty options become an empty options object. Note that a
+// CREATE performed through a PATCH still carries CreateOptions.
+func normalizeOptions(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionRequest {
+	opts := newOptions(req.Operation)
+	if opts == nil {
+		return req
+	}
+	gvk := opts.GetObjectKind().GroupVersionKind()
+	if len(req.Options.Raw) > 0 {
+		if err := json.Unmarshal(req.Options.Raw, opts); err != nil {
+			log.Error(err, "unable to decode request options, using them as sent", "operation", req.Operation)
+			return req
+		}
+		// the decoded kind is ignored in favor of the operation's
+		opts.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		log.Error(err, "unable to encode request options, using them as sent", "operation", req.Operation)
+		return req
+	}
+	// shallow copy, only the options are replaced
+	normalized := *req
+	normalized.Options = k8sruntime.RawExtension{Raw: raw}
+	return &normalized
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNormalizeOptions(t *testing.T) {
+	tc := []struct {
+		Name      string
+		Operation admissionv1beta1.Operation
+		Options   string
+		Expected  string
+	}{
+		{
+			Name:      "Missing create options",
+			Operation: admissionv1beta1.Create,
+			Expected:  `{"kind":"CreateOptions","apiVersion":"meta.k8s.io/v1"}`,
+		},
+		{
+			Name:      "Empty update options",
+			Operation: admissionv1beta1.Update,
+			Options:   `{}`,
+			Expected:  `{"kind":"UpdateOptions","apiVersion":"meta.k8s.io/v1"}`,
+		},
+		{
+			Name:      "Force delete",
+			Operation: admissionv1beta1.Delete,
+			Options:   `{"apiVersion": "meta.k8s.io/v1", "kind": "DeleteOptions", "gracePeriodSeconds": 0, "unknown": true}`,
+			Expected:  `{"kind":"DeleteOptions","apiVersion":"meta.k8s.io/v1","gracePeriodSeconds":0}`,
+		},
+		{
+			Name:      "Create through patch",
+			Operation: admissionv1beta1.Create,
+			Options:   `{"apiVersion": "meta.k8s.io/v1", "kind": "PatchOptions", "fieldManager": "kubectl"}`,
+			Expected:  `{"kind":"CreateOptions","apiVersion":"meta.k8s.io/v1","fieldManager":"kubectl"}`,
+		},
+		{
+			Name:      "Connect options are passed through",
+			Operation: admissionv1beta1.Connect,
+			Options:   `{"kind": "PodExecOptions", "command": ["sh"]}`,
+			Expected:  `{"kind": "PodExecOptions", "command": ["sh"]}`,
+		},
+		{
+			Name:      "Malformed options are passed through",
+			Operation: admissionv1beta1.Delete,
+			Options:   `{"gracePeriodSeconds": "now"}`,
+			Expected:  `{"gracePeriodSeconds": "now"}`,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := &admissionv1beta1.AdmissionRequest{Operation: tt.Operation}
+			if tt.Options != "" {
+				req.Options = runtime.RawExtension{Raw: []byte(tt.Options)}
+			}
+			got := normalizeOptions(req)
+			if string(got.Options.Raw) != tt.Expected {
+				t.Errorf("options = %s, want %s", got.Options.Raw, tt.Expected)
+			}
+			if string(req.Options.Raw) != tt.Options {
+				t.Errorf("original options were modified: %s", req.Options.Raw)
+			}
+		})
+	}
+}
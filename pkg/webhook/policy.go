@@ -1,7 +1,9 @@
 package webhook
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -15,6 +17,18 @@ import (
 	"github.com/open-policy-agent/gatekeeper/api"
 	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
+	"github.com/open-policy-agent/gatekeeper/pkg/detailsschema"
+	"github.com/open-policy-agent/gatekeeper/pkg/deterministic"
+	"github.com/open-policy-agent/gatekeeper/pkg/export"
+	"github.com/open-policy-agent/gatekeeper/pkg/findings"
+	"github.com/open-policy-agent/gatekeeper/pkg/invalidparams"
+	"github.com/open-policy-agent/gatekeeper/pkg/nsenforcement"
+	"github.com/open-policy-agent/gatekeeper/pkg/objdiff"
+	"github.com/open-policy-agent/gatekeeper/pkg/policyset"
+	"github.com/open-policy-agent/gatekeeper/pkg/prune"
+	"github.com/open-policy-agent/gatekeeper/pkg/severity"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
@@ -48,6 +62,9 @@ const (
 	serviceName        = "gatekeeper-webhook-service"
 	caName             = "gatekeeper-ca"
 	caOrganization     = "gatekeeper"
+	// exportQueueSize is the number of admission decisions waiting to be
+	// exported before new ones are dropped
+	exportQueueSize = 1000
 )
 
 var (
@@ -59,6 +76,9 @@ var (
 	disableEnforcementActionValidation = flag.Bool("disable-enforcementaction-validation", false, "disable validation of the enforcementAction field of a constraint")
 	disableCertRotation                = flag.Bool("disable-cert-rotation", false, "disable automatic generation and rotation of webhook TLS certificates/keys")
 	logDenies                          = flag.Bool("log-denies", false, "log detailed info on each deny")
+	exemptGatekeeperResources          = flag.Bool("exempt-gatekeeper-resources", true, "admit Gatekeeper's namespace, the resources in it and its webhook configuration without evaluating constraints, so an overly broad constraint can't lock Gatekeeper out of managing itself")
+	exemptGatekeeperKinds              = flag.Bool("exempt-gatekeeper-kinds", true, "admit constraint templates, constraints and Gatekeeper's config without evaluating constraints, after validating them, so a constraint can't stop Gatekeeper from being managed")
+	pruneReviewObject                  = flag.Bool("prune-review-object", false, "only pass the fields of the admitted object that templates reference to OPA. The full object is used when any template's references can't be determined")
 	// webhookName is deprecated, set this on the manifest YAML if needed"
 )
 
@@ -71,7 +91,43 @@ func AddPolicyWebhook(mgr manager.Manager, opa *opa.Client) error {
 	if err != nil {
 		return err
 	}
-	wh := &admission.Webhook{Handler: &validationHandler{opa: opa, client: mgr.GetClient(), reporter: reporter}}
+	handler := &validationHandler{opa: opa, client: mgr.GetClient(), reporter: reporter}
+	if *decisionCacheTTL > 0 {
+		handler.decisions = decisioncache.New(*decisionCacheSize, *decisionCacheTTL)
+	}
+	if *retryWindow > 0 {
+		handler.retries = newRetryDeduper(*retryWindow)
+	}
+	if *userRateLimit > 0 {
+		handler.limiter = newUserRateLimiter(*userRateLimit, *userRateBurst, *userRateLimitSize)
+	}
+	if export.ExportAdmission() {
+		exporter, err := export.New()
+		if err != nil {
+			return err
+		}
+		if dr, ok := exporter.(export.DecisionReporter); ok {
+			handler.exports = export.NewQueue(dr, exportQueueSize)
+			if err := mgr.Add(handler.exports); err != nil {
+				return err
+			}
+		} else if exporter != nil {
+			log.Info("the evaluation reporter does not export admission decisions")
+		}
+	}
+	if *warmupDuration > 0 {
+		w, err := newWarmup(opa, mgr.GetCache(), *warmupDuration, *warmupObjects)
+		if err != nil {
+			return err
+		}
+		if err := mgr.Add(w); err != nil {
+			return err
+		}
+		if err := mgr.AddReadyzCheck("warmup", w.Checker); err != nil {
+			return err
+		}
+	}
+	wh := &admission.Webhook{Handler: handler}
 	mgr.GetWebhookServer().Register("/v1/admit", wh)
 
 	if !*disableCertRotation {
@@ -99,6 +155,14 @@ type validationHandler struct {
 	opa      *opa.Client
 	client   client.Client
 	reporter StatsReporter
+	// decisions is nil when the decision cache is disabled
+	decisions *decisioncache.Cache
+	// retries is nil when retries are not deduplicated
+	retries *retryDeduper
+	// limiter is nil when requests are not rate limited
+	limiter *userRateLimiter
+	// exports is nil when admission decisions are not exported
+	exports *export.Queue
 
 	// for testing
 	injectedConfig *v1alpha1.Config
@@ -123,6 +187,16 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		return admission.ValidationResponse(true, "Gatekeeper does not self-manage")
 	}
 
+	if *exemptGatekeeperResources && isGkResource(&req.AdmissionRequest) {
+		return admission.ValidationResponse(true, "Gatekeeper resources are exempt")
+	}
+
+	// CONNECT requests carry the options of the connection, e.g. a
+	// PodExecOptions, rather than the object being connected to
+	if req.AdmissionRequest.Operation == admissionv1beta1.Connect && connectRequests == skipConnect {
+		return admission.ValidationResponse(true, "CONNECT requests are not evaluated")
+	}
+
 	if req.AdmissionRequest.Operation == admissionv1beta1.Delete {
 		// oldObject is the existing object.
 		// It is null for DELETE operations in API servers prior to v1.15.0.
@@ -140,6 +214,12 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		req.AdmissionRequest.Object = req.AdmissionRequest.OldObject
 	}
 
+	// Results need an object to refer to, so a CONNECT request without
+	// options is reviewed as an empty object
+	if req.AdmissionRequest.Operation == admissionv1beta1.Connect && req.AdmissionRequest.Object.Raw == nil {
+		req.AdmissionRequest.Object.Raw = []byte(`{}`)
+	}
+
 	if userErr, err := h.validateGatekeeperResources(ctx, req); err != nil {
 		vResp := admission.ValidationResponse(false, err.Error())
 		if vResp.Result == nil {
@@ -153,16 +233,87 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		return vResp
 	}
 
+	if *exemptGatekeeperKinds && isGkKind(req.AdmissionRequest.Kind) {
+		return admission.ValidationResponse(true, "Gatekeeper resources are exempt")
+	}
+
 	requestResponse := unknownResponse
+	outcome := unknownOutcome
 	defer func() {
 		if h.reporter != nil {
 			if err := h.reporter.ReportRequest(
 				requestResponse, time.Since(timeStart)); err != nil {
 				log.Error(err, "failed to report request")
 			}
+			if err := h.reporter.ReportRequestOutcome(outcome); err != nil {
+				log.Error(err, "failed to report request outcome")
+			}
 		}
 	}()
 
+	if allowed, first := h.limiter.allow(req.AdmissionRequest.UserInfo.Username); !allowed {
+		if h.reporter != nil {
+			if err := h.reporter.ReportRateLimited(userRateLimitMode); err != nil {
+				log.Error(err, "failed to report rate limited request")
+			}
+		}
+		// only the first of a run of excess requests is logged
+		if first {
+			log.Info("user exceeded the admission rate limit",
+				"process", "admission",
+				"event_type", "user_rate_limited",
+				"user", req.AdmissionRequest.UserInfo.Username,
+				"policy", string(userRateLimitMode),
+			)
+		}
+		msg := fmt.Sprintf("user %q exceeded the limit of %g evaluated requests per second", req.AdmissionRequest.UserInfo.Username, *userRateLimit)
+		if userRateLimitMode == denyRateLimited {
+			vResp := admission.ValidationResponse(false, msg)
+			if vResp.Result == nil {
+				vResp.Result = &metav1.Status{}
+			}
+			vResp.Result.Code = http.StatusTooManyRequests
+			requestResponse = denyResponse
+			outcome = rateLimitedOutcome
+			return vResp
+		}
+		requestResponse = allowResponse
+		outcome = allowedOutcome
+		return admission.ValidationResponse(true, msg)
+	}
+
+	if size, oversized := objectSize(&req.AdmissionRequest); oversized {
+		if h.reporter != nil {
+			if err := h.reporter.ReportOversizedObject(oversizedObjectsPolicy); err != nil {
+				log.Error(err, "failed to report oversized object")
+			}
+		}
+		msg := fmt.Sprintf("object size %d bytes exceeds the %d byte limit for evaluation", size, *maxObjectSize)
+		switch oversizedObjectsPolicy {
+		case denyOversized:
+			vResp := admission.ValidationResponse(false, msg)
+			if vResp.Result == nil {
+				vResp.Result = &metav1.Status{}
+			}
+			vResp.Result.Code = http.StatusRequestEntityTooLarge
+			requestResponse = denyResponse
+			outcome = oversizedOutcome
+			return vResp
+		case warnOversized:
+			log.Info("admitting oversized object without evaluation",
+				"process", "admission",
+				"event_type", "oversized_object",
+				"resource_kind", req.AdmissionRequest.Kind.Kind,
+				"resource_namespace", req.AdmissionRequest.Namespace,
+				"resource_name", req.AdmissionRequest.Name,
+				"size", size,
+			)
+		}
+		requestResponse = allowResponse
+		outcome = allowedOutcome
+		return admission.ValidationResponse(true, msg)
+	}
+
 	resp, err := h.reviewRequest(ctx, req)
 	if err != nil {
 		log.Error(err, "error executing query")
@@ -172,11 +323,23 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		}
 		vResp.Result.Code = http.StatusInternalServerError
 		requestResponse = errorResponse
+		outcome = errorOutcome(err)
 		return vResp
 	}
 
-	res := resp.Results()
+	res := invalidparams.Filter(resp.Results())
+	// retries of a request are logged, counted and exported once
+	firstAttempt := h.retries.firstAttempt(req.AdmissionRequest.UID)
+	if firstAttempt {
+		if *logDenies {
+			h.logDenies(res, req)
+		}
+		h.reportConstraintDecisions(res)
+	}
 	msgs := h.getDenyMessages(res, req)
+	if firstAttempt {
+		h.exportDecision(res, req, len(msgs) == 0)
+	}
 	if len(msgs) > 0 {
 		vResp := admission.ValidationResponse(false, strings.Join(msgs, "\n"))
 		if vResp.Result == nil {
@@ -184,30 +347,104 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		}
 		vResp.Result.Code = http.StatusForbidden
 		requestResponse = denyResponse
+		outcome = policyDenyOutcome
+		if onlyUnsyncedDenials(res) {
+			outcome = unsyncedOutcome
+			// a retry can succeed once the required data is synced
+			if unsyncedInventory == retryUnsynced {
+				vResp.Result.Code = http.StatusTooManyRequests
+				vResp.Result.Details = &metav1.StatusDetails{RetryAfterSeconds: unsyncedRetryAfterSeconds}
+			}
+		}
 		return vResp
 	}
 
 	requestResponse = allowResponse
+	outcome = allowedOutcome
 	return admission.ValidationResponse(true, "")
 }
 
-func (h *validationHandler) getDenyMessages(res []*rtypes.Result, req admission.Request) []string {
-	var msgs []string
+// errorOutcome classifies an error reviewing a request
+func errorOutcome(err error) requestOutcome {
+	var timeoutErr *reviewTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutOutcome
+	}
+	return evalErrorOutcome
+}
+
+// reportConstraintDecisions counts the request once for every constraint that
+// found a violation in it
+func (h *validationHandler) reportConstraintDecisions(res []*rtypes.Result) {
+	if h.reporter == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, r := range res {
+		key := r.Constraint.GetKind() + "/" + r.Constraint.GetName()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		decision := allowWithViolationDecision
+		if r.EnforcementAction == "deny" {
+			decision = denyDecision
+		}
+		if err := h.reporter.ReportConstraintDecision(r.Constraint.GetKind(), decision); err != nil {
+			log.Error(err, "failed to report constraint decision")
+		}
+	}
+}
+
+// exportDecision queues the decision for export if the request has violations
+func (h *validationHandler) exportDecision(res []*rtypes.Result, req admission.Request, allowed bool) {
+	if h.exports == nil {
+		return
+	}
+	violations := export.Violations(res)
+	if len(violations) == 0 {
+		return
+	}
+	h.exports.Add(&export.Decision{
+		UID:        string(req.AdmissionRequest.UID),
+		Operation:  string(req.AdmissionRequest.Operation),
+		Group:      req.AdmissionRequest.Kind.Group,
+		Version:    req.AdmissionRequest.Kind.Version,
+		Kind:       req.AdmissionRequest.Kind.Kind,
+		Namespace:  req.AdmissionRequest.Namespace,
+		Name:       req.AdmissionRequest.Name,
+		Allowed:    allowed,
+		Violations: violations,
+	})
+}
+
+// logDenies logs the denies and dry run failures of a request
+func (h *validationHandler) logDenies(res []*rtypes.Result, req admission.Request) {
+	// objects created with generateName have no name yet
+	l := log
+	if genName := generateName(&req.AdmissionRequest); genName != "" {
+		l = l.WithValues("resource_generate_name", genName)
+	}
 	for _, r := range res {
 		if r.EnforcementAction == "deny" || r.EnforcementAction == "dryrun" {
-			if *logDenies {
-				log.WithValues(
-					"process", "admission",
-					"event_type", "violation",
-					"constraint_name", r.Constraint.GetName(),
-					"constraint_kind", r.Constraint.GetKind(),
-					"constraint_action", r.EnforcementAction,
-					"resource_kind", req.AdmissionRequest.Kind.Kind,
-					"resource_namespace", req.AdmissionRequest.Namespace,
-					"resource_name", req.AdmissionRequest.Name,
-				).Info("denied admission")
-			}
+			l.WithValues(
+				"process", "admission",
+				"event_type", "violation",
+				"constraint_name", r.Constraint.GetName(),
+				"constraint_kind", r.Constraint.GetKind(),
+				"constraint_action", r.EnforcementAction,
+				"resource_kind", req.AdmissionRequest.Kind.Kind,
+				"resource_namespace", req.AdmissionRequest.Namespace,
+				"resource_name", req.AdmissionRequest.Name,
+				"request_uid", req.AdmissionRequest.UID,
+			).Info("denied admission")
 		}
+	}
+}
+
+func (h *validationHandler) getDenyMessages(res []*rtypes.Result, req admission.Request) []string {
+	var msgs []string
+	for _, r := range res {
 		// only deny enforcementAction should prompt deny admission response
 		if r.EnforcementAction == "deny" {
 			msgs = append(msgs, fmt.Sprintf("[denied by %s] %s", r.Constraint.GetName(), r.Msg))
@@ -232,6 +469,36 @@ func isGkServiceAccount(user authenticationv1.UserInfo) bool {
 	return user.Username == sa
 }
 
+// isGkResource returns whether the request is for Gatekeeper's namespace, a
+// resource in it or Gatekeeper's webhook configuration
+func isGkResource(req *admissionv1beta1.AdmissionRequest) bool {
+	ns := util.GetNamespace()
+	if req.Namespace == ns {
+		return true
+	}
+	switch req.Kind {
+	case metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"}:
+		return req.Name == ns
+	case metav1.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"},
+		metav1.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingWebhookConfiguration"}:
+		return req.Name == vwhName
+	}
+	return false
+}
+
+// gkGroups are the API groups of Gatekeeper's own kinds
+var gkGroups = map[string]bool{
+	"templates.gatekeeper.sh":   true,
+	"constraints.gatekeeper.sh": true,
+	"config.gatekeeper.sh":      true,
+}
+
+// isGkKind returns whether the kind is one of Gatekeeper's, wherever the
+// object lives
+func isGkKind(kind metav1.GroupVersionKind) bool {
+	return gkGroups[kind.Group]
+}
+
 // validateGatekeeperResources returns whether an issue is user error (vs internal) and any errors
 // validating internal resources
 func (h *validationHandler) validateGatekeeperResources(ctx context.Context, req admission.Request) (bool, error) {
@@ -256,6 +523,15 @@ func (h *validationHandler) validateTemplate(ctx context.Context, req admission.
 	if _, err := h.opa.CreateCRD(ctx, unversioned); err != nil {
 		return true, err
 	}
+	if _, err := detailsschema.Parse(unversioned.GetAnnotations()[detailsschema.Annotation]); err != nil {
+		return true, err
+	}
+	if err := deterministic.Verify(unversioned); err != nil {
+		return true, err
+	}
+	if err := checkTemplateImmutability(req); err != nil {
+		return true, err
+	}
 	return false, nil
 }
 
@@ -267,6 +543,15 @@ func (h *validationHandler) validateConstraint(ctx context.Context, req admissio
 	if err := h.opa.ValidateConstraint(ctx, obj); err != nil {
 		return true, err
 	}
+	if err := validateMaxStaleness(obj); err != nil {
+		return true, err
+	}
+	if err := validateSeverityActions(obj); err != nil {
+		return true, err
+	}
+	if err := validateMatchBreadth(obj, req); err != nil {
+		return true, err
+	}
 
 	enforcementActionString, found, err := unstructured.NestedString(obj.Object, "spec", "enforcementAction")
 	if err != nil {
@@ -286,6 +571,101 @@ func (h *validationHandler) validateConstraint(ctx context.Context, req admissio
 	return false, nil
 }
 
+// validateSeverityActions checks that the constraint's severityActions map
+// severities to supported enforcement actions
+func validateSeverityActions(obj *unstructured.Unstructured) error {
+	actions, _, err := severity.Actions(obj)
+	if err != nil {
+		return err
+	}
+	if *disableEnforcementActionValidation {
+		return nil
+	}
+	for s, action := range actions {
+		if err := util.ValidateEnforcementAction(util.EnforcementAction(action)); err != nil {
+			return fmt.Errorf("severityActions[%s]: %v", s, err)
+		}
+	}
+	return nil
+}
+
+// pruneRequest returns a copy of the request whose object and oldObject only
+// hold the fields referenced by templates. The request is returned as-is if
+// those fields can't be determined.
+func pruneRequest(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionRequest {
+	paths, ok := prune.Templates.Paths()
+	if !ok {
+		return req
+	}
+	// shallow copy, the raw objects are replaced below
+	pruned := *req
+	var err error
+	if pruned.Object.Raw, err = pruneRaw(req.Object.Raw, paths); err != nil {
+		log.Error(err, "unable to prune object, using the full request")
+		return req
+	}
+	if pruned.OldObject.Raw, err = pruneRaw(req.OldObject.Raw, paths); err != nil {
+		log.Error(err, "unable to prune oldObject, using the full request")
+		return req
+	}
+	pruned.Object.Object = nil
+	pruned.OldObject.Object = nil
+	return &pruned
+}
+
+// stripManagedFields returns a copy of the request whose object and oldObject
+// don't have metadata.managedFields. The request is returned as-is if neither
+// has them.
+func stripManagedFields(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionRequest {
+	if !bytes.Contains(req.Object.Raw, managedFieldsKey) && !bytes.Contains(req.OldObject.Raw, managedFieldsKey) {
+		return req
+	}
+	// shallow copy, the raw objects are replaced below
+	stripped := *req
+	var err error
+	if stripped.Object.Raw, err = stripRaw(req.Object.Raw); err != nil {
+		log.Error(err, "unable to strip managedFields from object, using the full request")
+		return req
+	}
+	if stripped.OldObject.Raw, err = stripRaw(req.OldObject.Raw); err != nil {
+		log.Error(err, "unable to strip managedFields from oldObject, using the full request")
+		return req
+	}
+	stripped.Object.Object = nil
+	stripped.OldObject.Object = nil
+	return &stripped
+}
+
+var managedFieldsKey = []byte(`"managedFields"`)
+
+func stripRaw(raw []byte) ([]byte, error) {
+	if !bytes.Contains(raw, managedFieldsKey) {
+		return raw, nil
+	}
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	if obj == nil || !prune.StripManagedFields(obj) {
+		return raw, nil
+	}
+	return json.Marshal(obj)
+}
+
+func pruneRaw(raw []byte, paths []prune.Path) ([]byte, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return raw, nil
+	}
+	return json.Marshal(prune.Object(obj, paths))
+}
+
 // traceSwitch returns true if a request should be traced
 func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Request) (*rtypes.Responses, error) {
 	cfg, _ := h.getConfig(ctx)
@@ -307,7 +687,44 @@ func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Req
 			}
 		}
 	}
-	review := &target.AugmentedReview{AdmissionRequest: &req.AdmissionRequest}
+	timeout := kindReviewTimeouts.timeoutFor(req.AdmissionRequest.Kind, *reviewTimeout)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	admissionRequest := normalizeOptions(&req.AdmissionRequest)
+	genName := generateName(admissionRequest)
+	if genName != "" && generateNames == prefixName {
+		admissionRequest = nameFromPrefix(admissionRequest, genName)
+	}
+	if !prune.KeepManagedFields() {
+		admissionRequest = stripManagedFields(admissionRequest)
+	}
+	// diffed before pruning, which could hide changed fields
+	var diff []objdiff.Change
+	if objdiff.Enabled() {
+		changes, ok, err := objdiff.Request(admissionRequest)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			diff = changes
+		}
+	}
+	if *pruneReviewObject {
+		admissionRequest = pruneRequest(admissionRequest)
+	}
+	review := &target.AugmentedReview{
+		AdmissionRequest:   admissionRequest,
+		InventoryStaleness: syncc.Freshness.Staleness(time.Now()),
+		GenerateName:       genName,
+		Diff:               diff,
+	}
+	// required data can only be unsynced while synced data is incomplete
+	if review.InventoryStaleness > 0 {
+		review.UnsyncedData = unsyncedData()
+	}
 	if req.AdmissionRequest.Namespace != "" {
 		ns := &corev1.Namespace{}
 		if err := h.client.Get(ctx, types.NamespacedName{Name: req.AdmissionRequest.Namespace}, ns); err != nil {
@@ -316,7 +733,10 @@ func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Req
 		review.Namespace = ns
 	}
 
-	resp, err := h.opa.Review(ctx, review, opa.Tracing(traceEnabled))
+	resp, err := h.review(ctx, review, traceEnabled)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = &reviewTimeoutError{kind: req.AdmissionRequest.Kind.Kind, timeout: timeout, err: err}
+	}
 	if traceEnabled {
 		log.Info(resp.TraceDump())
 	}
@@ -328,5 +748,77 @@ func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Req
 			log.Info(dump)
 		}
 	}
+	if err == nil {
+		// resp may be cached, so it is filtered into a copy
+		active := policyset.ActiveSets(cfg)
+		filtered := &rtypes.Responses{ByTarget: make(map[string]*rtypes.Response), Handled: resp.Handled}
+		for t, r := range resp.ByTarget {
+			f := *r
+			// informational findings never deny a request
+			f.Results = findings.Violations(active.Filter(r.Results))
+			f.Results = nsenforcement.Resolve(cfg, review.Namespace, f.Results)
+			var dropped []*rtypes.Result
+			f.Results, dropped = applyStaleInventoryPolicy(f.Results, staleInventory)
+			for _, d := range dropped {
+				log.Info("synced data is staler than the constraint allows",
+					"event_type", "stale_inventory",
+					"constraint_kind", d.Constraint.GetKind(),
+					"constraint_name", d.Constraint.GetName(),
+					"inventory_staleness", review.InventoryStaleness.String(),
+					"policy", string(staleInventory),
+				)
+			}
+			f.Results, dropped = applyUnsyncedInventoryPolicy(f.Results, unsyncedInventory)
+			for _, d := range dropped {
+				log.Info("skipping constraint whose required sync data is not synced yet",
+					"event_type", "unsynced_inventory",
+					"constraint_kind", d.Constraint.GetKind(),
+					"constraint_name", d.Constraint.GetName(),
+					"policy", string(unsyncedInventory),
+				)
+			}
+			filtered.ByTarget[t] = &f
+		}
+		resp = filtered
+	}
 	return resp, err
 }
+
+// review evaluates the review, reusing the decision for an identical review
+// if the decision cache is enabled. Traced reviews, reviews made while synced
+// data is incomplete, and reviews made while a template is not declared
+// deterministic are always evaluated.
+func (h *validationHandler) review(ctx context.Context, review *target.AugmentedReview, traceEnabled bool) (*rtypes.Responses, error) {
+	if h.decisions == nil || traceEnabled || review.InventoryStaleness > 0 || !deterministic.Templates.All() {
+		return h.evaluate(ctx, review, opa.Tracing(traceEnabled))
+	}
+	// read before evaluating, so a change made during evaluation discards
+	// the decision
+	rev := decisioncache.Revision()
+	key, err := decisionKey(review)
+	if err != nil {
+		log.Error(err, "unable to compute decision cache key")
+		return h.evaluate(ctx, review)
+	}
+	if cached, ok := h.decisions.Get(key); ok {
+		return cached.(*rtypes.Responses), nil
+	}
+	resp, err := h.evaluate(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+	h.decisions.Add(key, rev, resp)
+	return resp, nil
+}
+
+// evaluate reviews the object and sanitizes the results' details. Results
+// may be cached and shared between requests, so they must not be modified
+// after evaluate returns; enforcement actions are resolved into copies.
+func (h *validationHandler) evaluate(ctx context.Context, review *target.AugmentedReview, opts ...opa.QueryOpt) (*rtypes.Responses, error) {
+	resp, err := h.opa.Review(ctx, review, opts...)
+	if err != nil {
+		return resp, err
+	}
+	detailsschema.Templates.Sanitize(resp.Results(), log)
+	return resp, nil
+}
@@ -3,6 +3,7 @@ package webhook
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
@@ -11,13 +12,22 @@ import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
 	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	syncc "github.com/open-policy-agent/gatekeeper/pkg/controller/sync"
+	"github.com/open-policy-agent/gatekeeper/pkg/decisioncache"
+	"github.com/open-policy-agent/gatekeeper/pkg/detailsschema"
+	"github.com/open-policy-agent/gatekeeper/pkg/invalidparams"
+	"github.com/open-policy-agent/gatekeeper/pkg/nsenforcement"
+	"github.com/open-policy-agent/gatekeeper/pkg/prune"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8schema "k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -547,3 +557,348 @@ func TestGetDenyMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestPruneRequest(t *testing.T) {
+	defer prune.Templates.Remove("K8sPruneTest")
+	prune.Templates.Set("K8sPruneTest", `package prunetest
+violation[{"msg": "x"}] { input.review.object.spec.replicas > 3 }`)
+
+	req := &admissionv1beta1.AdmissionRequest{
+		Object: runtime.RawExtension{
+			Raw: []byte(`{"kind": "Deployment", "metadata": {"name": "foo"}, "spec": {"replicas": 5, "template": {"big": "object"}}}`),
+		},
+	}
+	pruned := pruneRequest(req)
+	expected := `{"kind":"Deployment","metadata":{"name":"foo"},"spec":{"replicas":5}}`
+	if string(pruned.Object.Raw) != expected {
+		t.Errorf("pruned object = %s, want %s", pruned.Object.Raw, expected)
+	}
+	if pruned.OldObject.Raw != nil {
+		t.Errorf("pruned oldObject = %s, want nil", pruned.OldObject.Raw)
+	}
+	if string(req.Object.Raw) == expected {
+		t.Error("original request was modified")
+	}
+
+	prune.Templates.Set("K8sPruneTest", `package prunetest
+violation[{"msg": "x"}] { obj := input.review.object; obj.spec.replicas > 3 }`)
+	if pruneRequest(req) != req {
+		t.Error("expected the full request when references are unknown")
+	}
+}
+
+func TestPruneRequestMatchesOS(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(goodRegoTemplate), cstr); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	constraint := newConstraint("K8sGoodRego", "windows-only", "deny", t)
+	constraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	if err := unstructured.SetNestedStringSlice(constraint.Object, []string{"windows"}, "spec", "match", "os"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opa.AddConstraint(context.Background(), constraint); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+	// the template references nothing in the object, so everything but the
+	// fields matching needs is pruned
+	defer prune.Templates.Remove("K8sGoodRego")
+	prune.Templates.Set("K8sGoodRego", cstr.Spec.Targets[0].Rego)
+	*pruneReviewObject = true
+	defer func() { *pruneReviewObject = false }()
+
+	handler := validationHandler{opa: opa, injectedConfig: &v1alpha1.Config{}}
+	tc := []struct {
+		Name     string
+		Object   string
+		Expected int
+	}{
+		{Name: "Windows pod", Object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "w"}, "spec": {"nodeSelector": {"kubernetes.io/os": "windows"}, "containers": [{"image": "iis"}]}}`, Expected: 1},
+		{Name: "Windows deployment", Object: `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "w"}, "spec": {"template": {"spec": {"os": {"name": "windows"}}}}}`, Expected: 1},
+		{Name: "Linux pod", Object: `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "l"}, "spec": {"containers": [{"image": "nginx"}]}}`, Expected: 0},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := u.UnmarshalJSON([]byte(tt.Object)); err != nil {
+				t.Fatal(err)
+			}
+			gvk := u.GroupVersionKind()
+			req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(tt.Object)},
+			}}
+			resp, err := handler.reviewRequest(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if n := len(resp.Results()); n != tt.Expected {
+				t.Errorf("got %d results, want %d", n, tt.Expected)
+			}
+		})
+	}
+}
+
+// namespaceClient serves Get requests for namespaces
+type namespaceClient struct {
+	ctrlclient.Client
+	namespaces map[string]*corev1.Namespace
+}
+
+func (c *namespaceClient) Get(ctx context.Context, key ctrlclient.ObjectKey, obj runtime.Object) error {
+	ns, ok := c.namespaces[key.Name]
+	if !ok {
+		return apierrors.NewNotFound(k8schema.GroupResource{Resource: "namespaces"}, key.Name)
+	}
+	ns.DeepCopyInto(obj.(*corev1.Namespace))
+	return nil
+}
+
+func TestNamespaceEnforcementAfterSeverity(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(goodRegoTemplate), cstr); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	cstr.Spec.Targets[0].Rego = `package goodrego
+
+violation[{"msg": "low severity", "details": {"severity": "low"}}] {
+  true
+}`
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	constraint := newConstraint("K8sGoodRego", "graded", "deny", t)
+	constraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	if err := unstructured.SetNestedStringMap(constraint.Object, map[string]string{"low": "dryrun"}, "spec", "severityActions"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opa.AddConstraint(context.Background(), constraint); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+
+	cfg := &v1alpha1.Config{Spec: v1alpha1.ConfigSpec{NamespaceEnforcement: v1alpha1.NamespaceEnforcement{
+		Mode: nsenforcement.Escalate,
+		Rules: []v1alpha1.NamespaceEnforcementRule{
+			{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "production"}}, EnforcementAction: "deny"},
+		},
+	}}}
+	namespaces := &namespaceClient{namespaces: map[string]*corev1.Namespace{
+		"payments": {ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"tier": "production"}}},
+		"sandbox":  {ObjectMeta: metav1.ObjectMeta{Name: "sandbox"}},
+	}}
+	// decisions are only cached once synced data is current
+	syncc.Freshness.MarkLoaded()
+	for _, cached := range []bool{false, true} {
+		handler := validationHandler{opa: opa, client: namespaces, injectedConfig: cfg}
+		if cached {
+			handler.decisions = decisioncache.New(10, time.Minute)
+		}
+		// the severity maps the violation to dryrun, and the production
+		// namespace then escalates it to deny. Each namespace is reviewed
+		// twice, so the second review of a cached handler uses the cache.
+		for _, tt := range []struct {
+			Namespace string
+			Expected  string
+		}{
+			{Namespace: "payments", Expected: "deny"},
+			{Namespace: "sandbox", Expected: "dryrun"},
+			{Namespace: "payments", Expected: "deny"},
+			{Namespace: "sandbox", Expected: "dryrun"},
+		} {
+			req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+				Namespace: tt.Namespace,
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo", "namespace": "` + tt.Namespace + `"}}`)},
+			}}
+			resp, err := handler.reviewRequest(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			results := resp.Results()
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			if results[0].EnforcementAction != tt.Expected {
+				t.Errorf("cached=%v namespace %s: got action %q, want %q", cached, tt.Namespace, results[0].EnforcementAction, tt.Expected)
+			}
+		}
+	}
+}
+
+func TestStripManagedFields(t *testing.T) {
+	req := &admissionv1beta1.AdmissionRequest{
+		Object: runtime.RawExtension{
+			Raw: []byte(`{"kind": "Pod", "metadata": {"name": "foo", "managedFields": [{"manager": "kubectl"}]}}`),
+		},
+		OldObject: runtime.RawExtension{
+			Raw: []byte(`{"kind": "Pod", "metadata": {"name": "foo"}}`),
+		},
+	}
+	stripped := stripManagedFields(req)
+	expected := `{"kind":"Pod","metadata":{"name":"foo"}}`
+	if string(stripped.Object.Raw) != expected {
+		t.Errorf("stripped object = %s, want %s", stripped.Object.Raw, expected)
+	}
+	if string(stripped.OldObject.Raw) != string(req.OldObject.Raw) {
+		t.Errorf("stripped oldObject = %s, want it unchanged", stripped.OldObject.Raw)
+	}
+	if string(req.Object.Raw) == expected {
+		t.Error("original request was modified")
+	}
+	if stripManagedFields(stripped) != stripped {
+		t.Error("expected a request without managedFields to be returned as-is")
+	}
+}
+
+func TestValidateSeverityActions(t *testing.T) {
+	tc := []struct {
+		Name          string
+		Spec          map[string]interface{}
+		ErrorExpected bool
+	}{
+		{Name: "no severityActions", Spec: map[string]interface{}{}},
+		{Name: "supported actions", Spec: map[string]interface{}{"severityActions": map[string]interface{}{"critical": "deny", "low": "dryrun"}}},
+		{Name: "unsupported action", Spec: map[string]interface{}{"severityActions": map[string]interface{}{"low": "warn"}}, ErrorExpected: true},
+		{Name: "not a map of strings", Spec: map[string]interface{}{"severityActions": []interface{}{"deny"}}, ErrorExpected: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.Spec}}
+			if err := validateSeverityActions(obj); (err != nil) != tt.ErrorExpected {
+				t.Errorf("err = %v, want error: %v", err, tt.ErrorExpected)
+			}
+		})
+	}
+}
+
+func TestIsGkResource(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Request  admissionv1beta1.AdmissionRequest
+		Expected bool
+	}{
+		{
+			Name:     "Resource in the Gatekeeper namespace",
+			Request:  admissionv1beta1.AdmissionRequest{Kind: metav1.GroupVersionKind{Version: "v1", Kind: "Pod"}, Namespace: "gatekeeper-system", Name: "foo"},
+			Expected: true,
+		},
+		{
+			Name:     "Gatekeeper namespace",
+			Request:  admissionv1beta1.AdmissionRequest{Kind: metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"}, Name: "gatekeeper-system"},
+			Expected: true,
+		},
+		{
+			Name:     "Gatekeeper webhook configuration",
+			Request:  admissionv1beta1.AdmissionRequest{Kind: metav1.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}, Name: vwhName},
+			Expected: true,
+		},
+		{
+			Name:    "Other webhook configuration",
+			Request: admissionv1beta1.AdmissionRequest{Kind: metav1.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}, Name: "other"},
+		},
+		{
+			Name:    "Other namespace",
+			Request: admissionv1beta1.AdmissionRequest{Kind: metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"}, Name: "default"},
+		},
+		{
+			Name:    "Resource named after the Gatekeeper namespace",
+			Request: admissionv1beta1.AdmissionRequest{Kind: metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Namespace: "default", Name: "gatekeeper-system"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := isGkResource(&tt.Request); got != tt.Expected {
+				t.Errorf("isGkResource() = %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestIsGkKind(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Kind     metav1.GroupVersionKind
+		Expected bool
+	}{
+		{Name: "Template", Kind: metav1.GroupVersionKind{Group: "templates.gatekeeper.sh", Version: "v1beta1", Kind: "ConstraintTemplate"}, Expected: true},
+		{Name: "Constraint", Kind: metav1.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: "K8sRequiredLabels"}, Expected: true},
+		{Name: "Config", Kind: metav1.GroupVersionKind{Group: "config.gatekeeper.sh", Version: "v1alpha1", Kind: "Config"}, Expected: true},
+		{Name: "Other group", Kind: metav1.GroupVersionKind{Group: "gatekeeper.sh.example.com", Version: "v1", Kind: "Config"}},
+		{Name: "Core kind", Kind: metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := isGkKind(tt.Kind); got != tt.Expected {
+				t.Errorf("isGkKind() = %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestInvalidParametersStandIn(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(goodRegoTemplate), cstr); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	c := newConstraint("K8sGoodRego", "invalid", "deny", t)
+	c.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	if _, err := opa.AddConstraint(context.Background(), invalidparams.StandIn(c, "labels must be of type array")); err != nil {
+		t.Fatalf("Could not add stand-in: %s", err)
+	}
+	handler := validationHandler{opa: opa, injectedConfig: &v1alpha1.Config{}}
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo"}}`)},
+		},
+	}
+	// the rejection is raised by the target, so a details schema the
+	// rejection's details don't match must not drop it
+	defer detailsschema.Templates.Remove("K8sGoodRego")
+	for _, schema := range []string{"", "type: object\nrequired: [\"missing\"]\nproperties:\n  missing:\n    type: string\n"} {
+		if err := detailsschema.Templates.Set("K8sGoodRego", schema); err != nil {
+			t.Fatal(err)
+		}
+		resp := handler.Handle(context.Background(), req)
+		if resp.Allowed {
+			t.Fatalf("expected the request to be rejected with details schema %q", schema)
+		}
+		want := "[denied by invalid] Constraint parameters do not match the template schema: labels must be of type array"
+		if msg := string(resp.Result.Reason); msg != want {
+			t.Errorf("got %q, want %q", msg, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"container/list"
+	"flag"
+	"fmt"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+type rateLimitPolicy string
+
+const (
+	// allowRateLimited admits a user's excess requests without evaluating them
+	allowRateLimited rateLimitPolicy = "allow"
+	// denyRateLimited rejects a user's excess requests
+	denyRateLimited rateLimitPolicy = "deny"
+)
+
+var (
+	userRateLimit     = flag.Float64("user-rate-limit", 0, "maximum number of admission requests per second evaluated for a single user, 0 for no limit. Excess requests are handled according to --user-rate-limit-policy")
+	userRateBurst     = flag.Int("user-rate-burst", 20, "number of admission requests a user can make at once before --user-rate-limit applies")
+	userRateLimitSize = flag.Int("user-rate-limit-users", 10000, "maximum number of users whose request rates are tracked. The least recently seen users are forgotten first")
+	userRateLimitMode = allowRateLimited
+)
+
+func init() {
+	flag.Var(&userRateLimitMode, "user-rate-limit-policy", "how requests exceeding --user-rate-limit are handled: allow (admit without evaluation) or deny (reject with a 429 status)")
+}
+
+var _ flag.Value = new(rateLimitPolicy)
+
+func (p *rateLimitPolicy) String() string {
+	return string(*p)
+}
+
+func (p *rateLimitPolicy) Set(s string) error {
+	switch rateLimitPolicy(s) {
+	case allowRateLimited, denyRateLimited:
+		*p = rateLimitPolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid user rate limit policy %q, expected one of allow or deny", s)
+}
+
+type userBucket struct {
+	user    string
+	limiter *rate.Limiter
+	// limited is true while the user's requests exceed the limit
+	limited bool
+}
+
+// userRateLimiter keeps a token bucket for each of the most recently seen
+// users
+type userRateLimiter struct {
+	mux     sync.Mutex
+	limit   rate.Limit
+	burst   int
+	size    int
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+func newUserRateLimiter(limit float64, burst, size int) *userRateLimiter {
+	return &userRateLimiter{
+		limit:   rate.Limit(limit),
+		burst:   burst,
+		size:    size,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// allow takes a token from the user's bucket. It returns false if the bucket
+// is empty, and whether this is the first request of the user to be limited
+// since it was last allowed. Requests without a user are never limited.
+func (l *userRateLimiter) allow(user string) (bool, bool) {
+	if l == nil || user == "" {
+		return true, false
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	el, ok := l.buckets[user]
+	if ok {
+		l.lru.MoveToFront(el)
+	} else {
+		el = l.lru.PushFront(&userBucket{user: user, limiter: rate.NewLimiter(l.limit, l.burst)})
+		l.buckets[user] = el
+		for l.lru.Len() > l.size {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*userBucket).user)
+		}
+	}
+	b := el.Value.(*userBucket)
+	if b.limiter.Allow() {
+		b.limited = false
+		return true, false
+	}
+	first := !b.limited
+	b.limited = true
+	return false, first
+}
+
+// tracked returns the number of users whose buckets are kept
+func (l *userRateLimiter) tracked() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.lru.Len()
+}
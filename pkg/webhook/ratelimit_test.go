@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestRateLimitPolicySet(t *testing.T) {
+	var p rateLimitPolicy
+	for _, v := range []string{"allow", "deny"} {
+		if err := p.Set(v); err != nil || string(p) != v {
+			t.Errorf("Set(%q) = %v, policy %q", v, err, p)
+		}
+	}
+	if err := p.Set("warn"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestUserRateLimiter(t *testing.T) {
+	// a negligible refill rate makes the burst the only tokens available
+	l := newUserRateLimiter(0.0001, 2, 2)
+	for i := 0; i < 2; i++ {
+		if allowed, _ := l.allow("noisy"); !allowed {
+			t.Fatalf("request %d within the burst was limited", i)
+		}
+	}
+	if allowed, first := l.allow("noisy"); allowed || !first {
+		t.Errorf("allow() = %v, %v; want false, true for the first excess request", allowed, first)
+	}
+	if allowed, first := l.allow("noisy"); allowed || first {
+		t.Errorf("allow() = %v, %v; want false, false for later excess requests", allowed, first)
+	}
+	if allowed, _ := l.allow("quiet"); !allowed {
+		t.Error("expected other users not to be limited")
+	}
+	if allowed, _ := l.allow(""); !allowed {
+		t.Error("expected requests without a user not to be limited")
+	}
+
+	// a third user evicts the least recently seen one, whose bucket is
+	// refilled when it is seen again
+	if allowed, _ := l.allow("other"); !allowed {
+		t.Error("expected a new user not to be limited")
+	}
+	if got := l.tracked(); got != 2 {
+		t.Errorf("tracked %d users, want 2", got)
+	}
+	if allowed, _ := l.allow("noisy"); !allowed {
+		t.Error("expected an evicted user to start with a full bucket")
+	}
+
+	var disabled *userRateLimiter
+	if allowed, _ := disabled.allow("noisy"); !allowed {
+		t.Error("expected no limit when rate limiting is disabled")
+	}
+}
+
+func TestRateLimitedRequests(t *testing.T) {
+	defer func(policy rateLimitPolicy) { userRateLimitMode = policy }(userRateLimitMode)
+	tc := []struct {
+		Name            string
+		Policy          rateLimitPolicy
+		ExpectedAllowed bool
+		ExpectedCode    int32
+	}{
+		{
+			Name:            "Allow",
+			Policy:          allowRateLimited,
+			ExpectedAllowed: true,
+			ExpectedCode:    http.StatusOK,
+		},
+		{
+			Name:         "Deny",
+			Policy:       denyRateLimited,
+			ExpectedCode: http.StatusTooManyRequests,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			userRateLimitMode = tt.Policy
+			handler := validationHandler{limiter: newUserRateLimiter(0.0001, 1, 10)}
+			req := atypes.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+					Operation: admissionv1beta1.Create,
+					Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap"}`)},
+					UserInfo:  authenticationv1.UserInfo{Username: "system:serviceaccount:default:noisy"},
+				},
+			}
+			// the burst is used up without reaching OPA
+			handler.limiter.allow(req.AdmissionRequest.UserInfo.Username)
+			resp := handler.Handle(context.Background(), req)
+			if resp.Allowed != tt.ExpectedAllowed {
+				t.Errorf("allowed = %v, want %v", resp.Allowed, tt.ExpectedAllowed)
+			}
+			code := int32(http.StatusOK)
+			if resp.Result != nil && resp.Result.Code != 0 {
+				code = resp.Result.Code
+			}
+			if code != tt.ExpectedCode {
+				t.Errorf("code = %d, want %d", code, tt.ExpectedCode)
+			}
+		})
+	}
+}
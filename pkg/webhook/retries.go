@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var retryWindow = flag.Duration("admission-retry-window", time.Minute, "how long the UID of an admission request is remembered, so that deny logs and decision metrics are only emitted once for retries of the request. 0 to emit them for every attempt")
+
+// retryDeduper remembers the UIDs of recently reviewed requests
+type retryDeduper struct {
+	mux       sync.Mutex
+	window    time.Duration
+	seen      map[types.UID]time.Time
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func newRetryDeduper(window time.Duration) *retryDeduper {
+	return &retryDeduper{
+		window: window,
+		seen:   make(map[types.UID]time.Time),
+		now:    time.Now,
+	}
+}
+
+// firstAttempt returns true unless a request with the same UID was reviewed
+// within the window. Requests without a UID are never deduplicated.
+func (d *retryDeduper) firstAttempt(uid types.UID) bool {
+	if d == nil || uid == "" {
+		return true
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	now := d.now()
+	if now.Sub(d.lastPrune) >= d.window {
+		for k, t := range d.seen {
+			if now.Sub(t) >= d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastPrune = now
+	}
+	if t, ok := d.seen[uid]; ok && now.Sub(t) < d.window {
+		return false
+	}
+	d.seen[uid] = now
+	return true
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDeduper(t *testing.T) {
+	now := time.Now()
+	d := newRetryDeduper(time.Minute)
+	d.now = func() time.Time { return now }
+
+	if !d.firstAttempt("a") {
+		t.Error("expected the first attempt of a to be first")
+	}
+	if d.firstAttempt("a") {
+		t.Error("expected a retry of a to be deduplicated")
+	}
+	if !d.firstAttempt("b") {
+		t.Error("expected b to be first")
+	}
+	if !d.firstAttempt("") || !d.firstAttempt("") {
+		t.Error("expected requests without a UID to never be deduplicated")
+	}
+
+	now = now.Add(time.Minute)
+	if !d.firstAttempt("a") {
+		t.Error("expected a to be forgotten after the window")
+	}
+	if len(d.seen) != 1 {
+		t.Errorf("remembered %d UIDs, want 1", len(d.seen))
+	}
+
+	var disabled *retryDeduper
+	if !disabled.firstAttempt("a") || !disabled.firstAttempt("a") {
+		t.Error("expected a nil deduper to never deduplicate")
+	}
+}
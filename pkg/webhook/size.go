@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"flag"
+	"fmt"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+type oversizedPolicy string
+
+const (
+	// allowOversized admits oversized objects without evaluating them
+	allowOversized oversizedPolicy = "allow"
+	// denyOversized rejects oversized objects
+	denyOversized oversizedPolicy = "deny"
+	// warnOversized admits oversized objects and logs a warning
+	warnOversized oversizedPolicy = "warn"
+)
+
+var (
+	maxObjectSize          = flag.Int("max-object-size", 0, "maximum size in bytes of an admitted object that is evaluated against constraints, 0 for no limit. Larger objects are handled according to --oversized-object-policy")
+	oversizedObjectsPolicy = warnOversized
+)
+
+func init() {
+	flag.Var(&oversizedObjectsPolicy, "oversized-object-policy", "how objects larger than --max-object-size are handled: allow (admit without evaluation), deny, or warn (admit without evaluation and log)")
+}
+
+var _ flag.Value = new(oversizedPolicy)
+
+func (p *oversizedPolicy) String() string {
+	return string(*p)
+}
+
+func (p *oversizedPolicy) Set(s string) error {
+	switch oversizedPolicy(s) {
+	case allowOversized, denyOversized, warnOversized:
+		*p = oversizedPolicy(s)
+		return nil
+	}
+	return fmt.Errorf("invalid oversized object policy %q, expected one of allow, deny or warn", s)
+}
+
+// objectSize returns the size of the larger of the request's object and
+// oldObject, and whether it exceeds --max-object-size
+func objectSize(req *admissionv1beta1.AdmissionRequest) (int, bool) {
+	size := len(req.Object.Raw)
+	if len(req.OldObject.Raw) > size {
+		size = len(req.OldObject.Raw)
+	}
+	return size, *maxObjectSize > 0 && size > *maxObjectSize
+}
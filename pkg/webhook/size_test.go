@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestOversizedPolicySet(t *testing.T) {
+	var p oversizedPolicy
+	for _, v := range []string{"allow", "deny", "warn"} {
+		if err := p.Set(v); err != nil || string(p) != v {
+			t.Errorf("Set(%q) = %v, policy %q", v, err, p)
+		}
+	}
+	if err := p.Set("ignore"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestOversizedObjects(t *testing.T) {
+	defer func(size int, policy oversizedPolicy) {
+		*maxObjectSize = size
+		oversizedObjectsPolicy = policy
+	}(*maxObjectSize, oversizedObjectsPolicy)
+	*maxObjectSize = 32
+
+	large := []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "data": {"key": "a large value"}}`)
+	tc := []struct {
+		Name            string
+		Policy          oversizedPolicy
+		Object          []byte
+		OldObject       []byte
+		ExpectedAllowed bool
+		ExpectedCode    int32
+	}{
+		{
+			Name:            "Allow",
+			Policy:          allowOversized,
+			Object:          large,
+			ExpectedAllowed: true,
+			ExpectedCode:    http.StatusOK,
+		},
+		{
+			Name:            "Warn",
+			Policy:          warnOversized,
+			Object:          large,
+			ExpectedAllowed: true,
+			ExpectedCode:    http.StatusOK,
+		},
+		{
+			Name:         "Deny",
+			Policy:       denyOversized,
+			Object:       large,
+			ExpectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:         "Deny oversized old object",
+			Policy:       denyOversized,
+			Object:       []byte(`{}`),
+			OldObject:    large,
+			ExpectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			oversizedObjectsPolicy = tt.Policy
+			handler := validationHandler{}
+			req := atypes.Request{
+				AdmissionRequest: admissionv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+					Operation: admissionv1beta1.Update,
+					Object:    runtime.RawExtension{Raw: tt.Object},
+					OldObject: runtime.RawExtension{Raw: tt.OldObject},
+				},
+			}
+			resp := handler.Handle(context.Background(), req)
+			if resp.Allowed != tt.ExpectedAllowed {
+				t.Errorf("allowed = %v, want %v", resp.Allowed, tt.ExpectedAllowed)
+			}
+			if resp.Result.Code != tt.ExpectedCode {
+				t.Errorf("code = %d, want %d", resp.Result.Code, tt.ExpectedCode)
+			}
+		})
+	}
+}
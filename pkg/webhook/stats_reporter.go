@@ -13,6 +13,40 @@ import (
 const (
 	requestCountMetricName    = "request_count"
 	requestDurationMetricName = "request_duration_seconds"
+	oversizedObjectMetricName = "oversized_object_count"
+	constraintDecisionsName   = "constraint_admission_decisions_total"
+	requestOutcomeMetricName  = "request_outcome_total"
+	rateLimitedMetricName     = "rate_limited_request_count"
+)
+
+type constraintDecision string
+
+const (
+	// denyDecision is a constraint denying a request
+	denyDecision constraintDecision = "deny"
+	// allowWithViolationDecision is a constraint finding a violation in a
+	// request it doesn't deny, e.g. in dry run
+	allowWithViolationDecision constraintDecision = "allow-with-violation"
+)
+
+// requestOutcome is the terminal cause of a request's response
+type requestOutcome string
+
+const (
+	allowedOutcome    requestOutcome = "allowed"
+	policyDenyOutcome requestOutcome = "policy_deny"
+	// timeoutOutcome is a review that exceeded its deadline
+	timeoutOutcome requestOutcome = "timeout"
+	// evalErrorOutcome is any other error evaluating the request
+	evalErrorOutcome requestOutcome = "eval_error"
+	// oversizedOutcome is an object denied for being too large to evaluate
+	oversizedOutcome requestOutcome = "oversized"
+	// rateLimitedOutcome is a request denied for exceeding its user's rate
+	rateLimitedOutcome requestOutcome = "rate_limited"
+	// unsyncedOutcome is a request only denied because data required by the
+	// matching constraints is not synced yet
+	unsyncedOutcome requestOutcome = "unsynced_inventory"
+	unknownOutcome  requestOutcome = "unknown"
 )
 
 var (
@@ -21,7 +55,31 @@ var (
 		"The response time in seconds",
 		stats.UnitSeconds)
 
+	oversizedObjectM = stats.Int64(
+		oversizedObjectMetricName,
+		"The number of admitted objects too large to be evaluated",
+		stats.UnitDimensionless)
+
+	constraintDecisionsM = stats.Int64(
+		constraintDecisionsName,
+		"The number of admission requests each kind of constraint found a violation in",
+		stats.UnitDimensionless)
+
+	requestOutcomeM = stats.Int64(
+		requestOutcomeMetricName,
+		"The number of admission requests by the cause of their response",
+		stats.UnitDimensionless)
+
+	rateLimitedM = stats.Int64(
+		rateLimitedMetricName,
+		"The number of admission requests exceeding their user's rate limit",
+		stats.UnitDimensionless)
+
 	admissionStatusKey = tag.MustNewKey("admission_status")
+	outcomeReasonKey   = tag.MustNewKey("reason")
+	policyKey          = tag.MustNewKey("policy")
+	constraintKindKey  = tag.MustNewKey("constraint_kind")
+	decisionTagKey     = tag.MustNewKey("decision")
 )
 
 func init() {
@@ -33,6 +91,10 @@ func init() {
 // StatsReporter reports webhook metrics
 type StatsReporter interface {
 	ReportRequest(response requestResponse, d time.Duration) error
+	ReportOversizedObject(policy oversizedPolicy) error
+	ReportConstraintDecision(kind string, decision constraintDecision) error
+	ReportRequestOutcome(outcome requestOutcome) error
+	ReportRateLimited(policy rateLimitPolicy) error
 }
 
 // reporter implements StatsReporter interface
@@ -65,6 +127,63 @@ func (r *reporter) ReportRequest(response requestResponse, d time.Duration) erro
 	return r.report(ctx, responseTimeInSecM.M(d.Seconds()))
 }
 
+// ReportOversizedObject counts an object that was too large to evaluate
+func (r *reporter) ReportOversizedObject(policy oversizedPolicy) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(policyKey, string(policy)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.report(ctx, oversizedObjectM.M(1))
+}
+
+// ReportConstraintDecision counts a request a constraint of the given kind
+// found a violation in. Constraint names are left out to bound the number of
+// series.
+func (r *reporter) ReportConstraintDecision(kind string, decision constraintDecision) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(constraintKindKey, kind),
+		tag.Insert(decisionTagKey, string(decision)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.report(ctx, constraintDecisionsM.M(1))
+}
+
+// ReportRequestOutcome counts a request by the cause of its response, so
+// denials by policy can be told apart from timeouts and evaluation errors
+func (r *reporter) ReportRequestOutcome(outcome requestOutcome) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(outcomeReasonKey, string(outcome)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.report(ctx, requestOutcomeM.M(1))
+}
+
+// ReportRateLimited counts a request that exceeded its user's rate limit.
+// Users are left out to bound the number of series.
+func (r *reporter) ReportRateLimited(policy rateLimitPolicy) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(policyKey, string(policy)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.report(ctx, rateLimitedM.M(1))
+}
+
 func (r *reporter) report(ctx context.Context, m stats.Measurement) error {
 	return metrics.Record(ctx, m)
 }
@@ -85,6 +204,34 @@ func register() error {
 			Aggregation: view.Distribution(0.001, 0.002, 0.003, 0.004, 0.005, 0.006, 0.007, 0.008, 0.009, 0.01, 0.02, 0.03, 0.04, 0.05),
 			TagKeys:     []tag.Key{admissionStatusKey},
 		},
+		{
+			Name:        oversizedObjectMetricName,
+			Description: oversizedObjectM.Description(),
+			Measure:     oversizedObjectM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{policyKey},
+		},
+		{
+			Name:        constraintDecisionsName,
+			Description: constraintDecisionsM.Description(),
+			Measure:     constraintDecisionsM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{constraintKindKey, decisionTagKey},
+		},
+		{
+			Name:        requestOutcomeMetricName,
+			Description: requestOutcomeM.Description(),
+			Measure:     requestOutcomeM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{outcomeReasonKey},
+		},
+		{
+			Name:        rateLimitedMetricName,
+			Description: rateLimitedM.Description(),
+			Measure:     rateLimitedM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{policyKey},
+		},
 	}
 	return view.Register(views...)
 }
@@ -1,9 +1,13 @@
 package webhook
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
 	"go.opencensus.io/stats/view"
 )
 
@@ -65,6 +69,74 @@ func TestReportRequest(t *testing.T) {
 	}
 }
 
+func TestReportConstraintDecisions(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	h := &validationHandler{reporter: r}
+	deny := newConstraint("K8sRequiredLabels", "must-have-owner", "deny", t)
+	dryrun := newConstraint("K8sRequiredLabels", "must-have-team", "dryrun", t)
+	h.reportConstraintDecisions([]*rtypes.Result{
+		{Constraint: deny, EnforcementAction: "deny"},
+		{Constraint: deny, EnforcementAction: "deny"},
+		{Constraint: dryrun, EnforcementAction: "dryrun"},
+	})
+
+	rows, err := view.RetrieveData(constraintDecisionsName)
+	if err != nil {
+		t.Fatalf("Error when retrieving data: %v", err)
+	}
+	got := make(map[string]int64)
+	for _, row := range rows {
+		tags := make(map[string]string)
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		got[tags["constraint_kind"]+"/"+tags["decision"]] = row.Data.(*view.CountData).Value
+	}
+	want := map[string]int64{
+		"K8sRequiredLabels/deny":                 1,
+		"K8sRequiredLabels/allow-with-violation": 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReportRequestOutcome(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	timeout := &reviewTimeoutError{kind: "Pod", timeout: time.Second, err: errors.New("context deadline exceeded")}
+	for _, outcome := range []requestOutcome{
+		policyDenyOutcome,
+		errorOutcome(timeout),
+		errorOutcome(fmt.Errorf("wrapped: %w", timeout)),
+		errorOutcome(errors.New("rego_type_error")),
+	} {
+		if err := r.ReportRequestOutcome(outcome); err != nil {
+			t.Fatalf("ReportRequestOutcome error %v", err)
+		}
+	}
+
+	rows, err := view.RetrieveData(requestOutcomeMetricName)
+	if err != nil {
+		t.Fatalf("Error when retrieving data: %v", err)
+	}
+	got := make(map[string]int64)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			got[tag.Value] = row.Data.(*view.CountData).Value
+		}
+	}
+	want := map[string]int64{"policy_deny": 1, "timeout": 2, "eval_error": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func checkData(t *testing.T, name string, expectedRowLength int) *view.Row {
 	row, err := view.RetrieveData(name)
 	if err != nil {
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	reviewTimeout      = flag.Duration("review-timeout", 0, "maximum time spent evaluating constraints for a single admission request, 0 for no limit")
+	kindReviewTimeouts = newKindTimeouts()
+)
+
+func init() {
+	flag.Var(kindReviewTimeouts, "kind-review-timeout", "override --review-timeout for a kind, in the form <group>/<kind>=<duration> (e.g. apps/Deployment=8s, /Pod=2s for the core group). This flag can be declared more than once.")
+}
+
+// kindTimeouts maps a kind to the deadline for reviewing requests on it
+type kindTimeouts map[schema.GroupKind]time.Duration
+
+var _ flag.Value = kindTimeouts{}
+
+func newKindTimeouts() kindTimeouts {
+	return make(map[schema.GroupKind]time.Duration)
+}
+
+func (k kindTimeouts) String() string {
+	contents := make([]string, 0, len(k))
+	for gk, d := range k {
+		contents = append(contents, fmt.Sprintf("%s/%s=%v", gk.Group, gk.Kind, d))
+	}
+	sort.Strings(contents)
+	return fmt.Sprintf("%s", contents)
+}
+
+func (k kindTimeouts) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid kind timeout %q, expected <group>/<kind>=<duration>", s)
+	}
+	gk := strings.SplitN(parts[0], "/", 2)
+	if len(gk) != 2 || gk[1] == "" {
+		return fmt.Errorf("invalid kind %q, expected <group>/<kind>", parts[0])
+	}
+	d, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid duration for kind %q: %v", parts[0], err)
+	}
+	if d < 0 {
+		return fmt.Errorf("duration for kind %q must not be negative", parts[0])
+	}
+	k[schema.GroupKind{Group: gk[0], Kind: gk[1]}] = d
+	return nil
+}
+
+// timeoutFor returns the review deadline for the kind, falling back to the
+// default when the kind has no override
+func (k kindTimeouts) timeoutFor(kind metav1.GroupVersionKind, defaultTimeout time.Duration) time.Duration {
+	if d, ok := k[schema.GroupKind{Group: kind.Group, Kind: kind.Kind}]; ok {
+		return d
+	}
+	return defaultTimeout
+}
+
+// reviewTimeoutError is returned for reviews that exceeded their deadline
+type reviewTimeoutError struct {
+	kind    string
+	timeout time.Duration
+	err     error
+}
+
+func (e *reviewTimeoutError) Error() string {
+	return fmt.Sprintf("review of %s exceeded its %v deadline: %v", e.kind, e.timeout, e.err)
+}
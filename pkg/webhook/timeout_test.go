@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKindTimeouts(t *testing.T) {
+	tc := []struct {
+		Name         string
+		Flags        []string
+		ExpectErr    bool
+		Kind         string
+		Group        string
+		ExpectedTime time.Duration
+	}{
+		{
+			Name:         "Default",
+			Kind:         "Pod",
+			ExpectedTime: time.Second,
+		},
+		{
+			Name:         "Core group override",
+			Flags:        []string{"/Pod=2s"},
+			Kind:         "Pod",
+			ExpectedTime: 2 * time.Second,
+		},
+		{
+			Name:         "Named group override",
+			Flags:        []string{"/Pod=2s", "apps/Deployment=8s"},
+			Group:        "apps",
+			Kind:         "Deployment",
+			ExpectedTime: 8 * time.Second,
+		},
+		{
+			Name:         "Override for other group",
+			Flags:        []string{"apps/Deployment=8s"},
+			Group:        "extensions",
+			Kind:         "Deployment",
+			ExpectedTime: time.Second,
+		},
+		{
+			Name:      "Missing duration",
+			Flags:     []string{"apps/Deployment"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "Missing group separator",
+			Flags:     []string{"Deployment=8s"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "Bad duration",
+			Flags:     []string{"apps/Deployment=soon"},
+			ExpectErr: true,
+		},
+		{
+			Name:      "Negative duration",
+			Flags:     []string{"apps/Deployment=-1s"},
+			ExpectErr: true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			k := newKindTimeouts()
+			var err error
+			for _, f := range tt.Flags {
+				if err = k.Set(f); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.ExpectErr {
+				t.Fatalf("err = %v, expected error: %v", err, tt.ExpectErr)
+			}
+			if tt.ExpectErr {
+				return
+			}
+			d := k.timeoutFor(gvk(tt.Group, "v1", tt.Kind), time.Second)
+			if d != tt.ExpectedTime {
+				t.Errorf("timeoutFor = %v, want %v", d, tt.ExpectedTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var (
+	warmupDuration = flag.Duration("warmup-duration", 0, "how long the webhook evaluates synthetic objects after its caches sync before reporting ready, to prime policy evaluation. 0 to report ready without warming up")
+	warmupObjects  = flag.String("warmup-objects", "", "path to a YAML file of the objects evaluated during warmup, separated by ---. A Namespace, Pod and Deployment are used if unset")
+)
+
+// warmupInterval is the pause between rounds of warmup evaluations
+const warmupInterval = 100 * time.Millisecond
+
+const defaultWarmupObjects = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: gatekeeper-warmup
+  labels:
+    app: gatekeeper-warmup
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: gatekeeper-warmup
+  namespace: gatekeeper-warmup
+  labels:
+    app: gatekeeper-warmup
+spec:
+  containers:
+  - name: warmup
+    image: gatekeeper-warmup:latest
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: gatekeeper-warmup
+  namespace: gatekeeper-warmup
+  labels:
+    app: gatekeeper-warmup
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: gatekeeper-warmup
+  template:
+    metadata:
+      labels:
+        app: gatekeeper-warmup
+    spec:
+      containers:
+      - name: warmup
+        image: gatekeeper-warmup:latest
+`
+
+type reviewer interface {
+	Review(ctx context.Context, obj interface{}, opts ...client.QueryOpt) (*types.Responses, error)
+}
+
+type cacheSyncer interface {
+	WaitForCacheSync(stop <-chan struct{}) bool
+}
+
+var _ manager.Runnable = &warmup{}
+
+// warmup evaluates synthetic objects once the caches have synced, so the
+// first admission requests don't pay for cold policy evaluation. The webhook
+// is not ready until the warmup is done.
+type warmup struct {
+	opa      reviewer
+	cache    cacheSyncer
+	objects  []*unstructured.Unstructured
+	duration time.Duration
+	done     chan struct{}
+}
+
+func newWarmup(opa reviewer, cache cacheSyncer, duration time.Duration, path string) (*warmup, error) {
+	raw := []byte(defaultWarmupObjects)
+	if path != "" {
+		var err error
+		if raw, err = ioutil.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("reading warmup objects: %w", err)
+		}
+	}
+	objects, err := parseWarmupObjects(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &warmup{
+		opa:      opa,
+		cache:    cache,
+		objects:  objects,
+		duration: duration,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// parseWarmupObjects parses a stream of YAML or JSON objects, each of which
+// must have an apiVersion and kind
+func parseWarmupObjects(raw []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(raw), 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("parsing warmup objects: %w", err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" {
+			return nil, fmt.Errorf("warmup object %d has no apiVersion or kind", len(objects))
+		}
+		objects = append(objects, u)
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("no warmup objects")
+	}
+	return objects, nil
+}
+
+// Start waits for the caches to sync, then evaluates every warmup object in
+// rounds until the warmup duration has passed
+func (w *warmup) Start(stop <-chan struct{}) error {
+	if !w.cache.WaitForCacheSync(stop) {
+		return nil
+	}
+	log.Info("warming up", logging.EventType, "warmup_started", "duration", w.duration.String(), "objects", len(w.objects))
+	deadline := time.Now().Add(w.duration)
+	rounds := 0
+	for {
+		for _, obj := range w.objects {
+			if _, err := w.opa.Review(context.Background(), target.AugmentedUnstructured{Object: *obj}); err != nil {
+				log.V(1).Info("warmup evaluation failed", "error", err.Error(), "kind", obj.GetKind())
+			}
+		}
+		rounds++
+		if !time.Now().Add(warmupInterval).Before(deadline) {
+			break
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(warmupInterval):
+		}
+	}
+	close(w.done)
+	log.Info("warmup finished", logging.EventType, "warmup_finished", "rounds", rounds)
+	return nil
+}
+
+// Checker is a readiness check that fails until the warmup is done
+func (w *warmup) Checker(_ *http.Request) error {
+	select {
+	case <-w.done:
+		return nil
+	default:
+		return errors.New("warming up")
+	}
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+)
+
+type fakeSyncer struct {
+	synced chan struct{}
+}
+
+func (f *fakeSyncer) WaitForCacheSync(stop <-chan struct{}) bool {
+	select {
+	case <-f.synced:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+type countingReviewer struct {
+	mux   sync.Mutex
+	kinds map[string]int
+}
+
+func (c *countingReviewer) Review(ctx context.Context, obj interface{}, opts ...client.QueryOpt) (*types.Responses, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	u := obj.(target.AugmentedUnstructured).Object
+	c.kinds[u.GetKind()]++
+	return &types.Responses{}, nil
+}
+
+func TestParseWarmupObjects(t *testing.T) {
+	objects, err := parseWarmupObjects([]byte(defaultWarmupObjects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, obj := range objects {
+		kinds = append(kinds, obj.GetKind())
+	}
+	if len(kinds) != 3 || kinds[0] != "Namespace" || kinds[1] != "Pod" || kinds[2] != "Deployment" {
+		t.Errorf("got kinds %v, want [Namespace Pod Deployment]", kinds)
+	}
+
+	for name, raw := range map[string]string{
+		"empty":      "---\n",
+		"no kind":    "apiVersion: v1\nmetadata:\n  name: foo\n",
+		"not an obj": "- a\n- b\n",
+	} {
+		if _, err := parseWarmupObjects([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	opa := &countingReviewer{kinds: make(map[string]int)}
+	syncer := &fakeSyncer{synced: make(chan struct{})}
+	w, err := newWarmup(opa, syncer, 250*time.Millisecond, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	finished := make(chan error)
+	go func() { finished <- w.Start(stop) }()
+
+	if err := w.Checker(nil); err == nil {
+		t.Error("expected the webhook to be unready before the caches sync")
+	}
+	close(syncer.synced)
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("warmup did not finish")
+	}
+	if err := w.Checker(nil); err != nil {
+		t.Errorf("expected the webhook to be ready after warming up, got %v", err)
+	}
+	for _, kind := range []string{"Namespace", "Pod", "Deployment"} {
+		if opa.kinds[kind] < 2 {
+			t.Errorf("%s evaluated %d times, want several rounds", kind, opa.kinds[kind])
+		}
+	}
+}
@@ -93,7 +93,7 @@ func (ip *dynamicInformerCache) List(ctx context.Context, out runtime.Object, op
 	} else {
 		itemsPtr, err := apimeta.GetItemsPtr(out)
 		if err != nil {
-			return nil
+			return err
 		}
 		// http://knowyourmeme.com/memes/this-is-fine
 		elemType := reflect.Indirect(reflect.ValueOf(itemsPtr)).Type().Elem()
@@ -120,7 +120,7 @@ func (ip *dynamicInformerCache) List(ctx context.Context, out runtime.Object, op
 // GetInformerForKind returns the informer for the GroupVersionKind
 func (ip *dynamicInformerCache) GetInformerForKind(gvk schema.GroupVersionKind) (cache.Informer, error) {
 	// Map the gvk to an object
-	obj, err := ip.Scheme.New(gvk)
+	obj, err := newObjectForKind(ip.Scheme, gvk)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +131,24 @@ func (ip *dynamicInformerCache) GetInformerForKind(gvk schema.GroupVersionKind)
 	return i.Informer, err
 }
 
+// newObjectForKind returns a typed object for kinds registered in the scheme,
+// and an unstructured object for any other kind, such as custom resources
+func newObjectForKind(s *runtime.Scheme, gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if gvk.Kind == "" || gvk.Version == "" {
+		return nil, fmt.Errorf("cannot get informer for incomplete kind %q", gvk)
+	}
+	obj, err := s.New(gvk)
+	if err == nil {
+		return obj, nil
+	}
+	if !runtime.IsNotRegisteredError(err) {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
 // GetInformer returns the informer for the obj
 func (ip *dynamicInformerCache) GetInformer(obj runtime.Object) (cache.Informer, error) {
 	gvk, err := apiutil.GVKForObject(obj, ip.Scheme)
@@ -123,6 +123,13 @@ func (m *InformersMap) Remove(gvk schema.GroupVersionKind, obj runtime.Object) {
 	}
 }
 
+// SetFieldSelector restricts informers created for gvk to objects matching
+// selector. Existing informers are unaffected until they are removed.
+func (m *InformersMap) SetFieldSelector(gvk schema.GroupVersionKind, selector string) {
+	m.structured.SetFieldSelector(gvk, selector)
+	m.unstructured.SetFieldSelector(gvk, selector)
+}
+
 // newStructuredInformersMap creates a new InformersMap for structured objects.
 func newStructuredInformersMap(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper, resync time.Duration, namespace string) *specificInformersMap {
 	return newSpecificInformersMap(config, scheme, mapper, resync, namespace, createStructuredListWatch)
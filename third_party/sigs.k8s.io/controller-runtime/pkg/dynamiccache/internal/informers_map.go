@@ -55,6 +55,7 @@ func newSpecificInformersMap(config *rest.Config,
 		Scheme:            scheme,
 		mapper:            mapper,
 		informersByGVK:    make(map[schema.GroupVersionKind]*MapEntry),
+		fieldSelectors:    make(map[schema.GroupVersionKind]string),
 		codecs:            serializer.NewCodecFactory(scheme),
 		paramCodec:        runtime.NewParameterCodec(scheme),
 		resync:            resync,
@@ -123,6 +124,11 @@ type specificInformersMap struct {
 	// namespace is the namespace that all ListWatches are restricted to
 	// default or empty string means all namespaces
 	namespace string
+
+	// fieldSelectors restricts the ListWatches of the given kinds. They are
+	// read when an informer is created, so changing one only takes effect
+	// once the informer has been removed.
+	fieldSelectors map[schema.GroupVersionKind]string
 }
 
 // Start starts the informer managed by a MapEntry.
@@ -244,6 +250,19 @@ func (ip *specificInformersMap) addInformerToMap(gvk schema.GroupVersionKind, ob
 	return i, ip.started, nil
 }
 
+// SetFieldSelector sets the field selector used by informers created for gvk.
+// An empty selector lists everything.
+func (ip *specificInformersMap) SetFieldSelector(gvk schema.GroupVersionKind, selector string) {
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+
+	if selector == "" {
+		delete(ip.fieldSelectors, gvk)
+		return
+	}
+	ip.fieldSelectors[gvk] = selector
+}
+
 // Remove removes an informer entry and stops it if it was running.
 func (ip *specificInformersMap) Remove(gvk schema.GroupVersionKind) {
 	ip.mu.Lock()
@@ -275,10 +294,12 @@ func createStructuredListWatch(gvk schema.GroupVersionKind, ip *specificInformer
 	if err != nil {
 		return nil, err
 	}
+	fieldSelector := ip.fieldSelectors[gvk]
 
 	// Create a new ListWatch for the obj
 	return &cache.ListWatch{
 		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = fieldSelector
 			res := listObj.DeepCopyObject()
 			isNamespaceScoped := ip.namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot
 			err := client.Get().NamespaceIfScoped(ip.namespace, isNamespaceScoped).Resource(mapping.Resource.Resource).VersionedParams(&opts, ip.paramCodec).Do().Into(res)
@@ -288,6 +309,7 @@ func createStructuredListWatch(gvk schema.GroupVersionKind, ip *specificInformer
 		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
 			// Watch needs to be set to true separately
 			opts.Watch = true
+			opts.FieldSelector = fieldSelector
 			isNamespaceScoped := ip.namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot
 			return client.Get().NamespaceIfScoped(ip.namespace, isNamespaceScoped).Resource(mapping.Resource.Resource).VersionedParams(&opts, ip.paramCodec).Watch()
 		},
@@ -305,10 +327,12 @@ func createUnstructuredListWatch(gvk schema.GroupVersionKind, ip *specificInform
 	if err != nil {
 		return nil, err
 	}
+	fieldSelector := ip.fieldSelectors[gvk]
 
 	// Create a new ListWatch for the obj
 	return &cache.ListWatch{
 		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = fieldSelector
 			if ip.namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot {
 				return dynamicClient.Resource(mapping.Resource).Namespace(ip.namespace).List(opts)
 			}
@@ -318,6 +342,7 @@ func createUnstructuredListWatch(gvk schema.GroupVersionKind, ip *specificInform
 		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
 			// Watch needs to be set to true separately
 			opts.Watch = true
+			opts.FieldSelector = fieldSelector
 			if ip.namespace != "" && mapping.Scope.Name() != meta.RESTScopeNameRoot {
 				return dynamicClient.Resource(mapping.Resource).Namespace(ip.namespace).Watch(opts)
 			}
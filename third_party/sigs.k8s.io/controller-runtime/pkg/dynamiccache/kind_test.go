@@ -0,0 +1,37 @@
+package dynamiccache
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestNewObjectForKind(t *testing.T) {
+	obj, err := newObjectForKind(scheme.Scheme, schema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*corev1.Pod); !ok {
+		t.Errorf("got %T, want *v1.Pod for a registered kind", obj)
+	}
+
+	crd := schema.GroupVersionKind{Group: "stable.example.com", Version: "v1", Kind: "CronTab"}
+	obj, err = newObjectForKind(scheme.Scheme, crd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		t.Fatalf("got %T, want *unstructured.Unstructured for an unregistered kind", obj)
+	}
+	if u.GroupVersionKind() != crd {
+		t.Errorf("got kind %v, want %v", u.GroupVersionKind(), crd)
+	}
+
+	if _, err := newObjectForKind(scheme.Scheme, schema.GroupVersionKind{Group: "stable.example.com", Version: "v1"}); err == nil {
+		t.Error("expected an error for a kind without a name")
+	}
+}